@@ -0,0 +1,204 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Matching defaults.
+const (
+	// DefaultMargin is the number of dead cells required around a match.
+	DefaultMargin = 1
+	// MaxQueryPeriod is the longest period of a pattern whose phases are
+	// looked for.
+	MaxQueryPeriod = 64
+)
+
+// Match is an occurrence of a query pattern in a world.
+type Match struct {
+	// X and Y are the top left corner of the bounding box of the match.
+	X, Y int64
+	// Orientation is how the pattern was turned to match: Orientation%4
+	// quarter turns clockwise, after a reflection left to right when it is 4
+	// or more.
+	Orientation int
+	// Phase is the number of generations the pattern was run to match.
+	Phase int
+	// Cells are the living cells of the match.
+	Cells []Cell
+}
+
+// variant is a pattern in one orientation and phase, with its cells in
+// reading order from the top left corner of its bounding box.
+type variant struct {
+	cells         []Cell
+	width, height int64
+	orientation   int
+	phase         int
+}
+
+// Query is a pattern to look for in worlds, in every orientation and
+// optionally in every phase.
+type Query struct {
+	variants []variant
+}
+
+// NewQuery creates a query for the living cells of the pattern, in all 8
+// rotations and reflections. With phases, the pattern is also run, on a copy
+// following the same rule, until it repeats, and every phase is looked for as
+// well, so that a query for one phase of a glider finds all gliders.
+func NewQuery(pattern types.World, phases bool) (*Query, error) {
+	var cells []Cell
+	pattern.ForEachCell(func(x, y, turn int64) {
+		cells = append(cells, Cell{x, y})
+	})
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	shapes := [][]Cell{cells}
+	if phases {
+		var err error
+		if shapes, err = phasesOf(pattern, cells); err != nil {
+			return nil, err
+		}
+	}
+
+	q := &Query{}
+	seen := make(map[string]bool)
+	for phase, shape := range shapes {
+		start := normalized(shape)
+		for orientation := 0; orientation < 8; orientation++ {
+			v := oriented(start, orientation)
+			v.phase = phase
+			// Symmetric patterns look the same in several orientations
+			key := fmt.Sprint(v.cells)
+			if !seen[key] {
+				seen[key] = true
+				q.variants = append(q.variants, v)
+			}
+		}
+	}
+	return q, nil
+}
+
+// phasesOf runs a copy of the pattern, starting with the given cells, until
+// its shape repeats, and returns the cells of each phase.
+func phasesOf(pattern types.World, cells []Cell) ([][]Cell, error) {
+	w := model.NewWorld()
+	if reporter, ok := pattern.(types.RuleReporter); ok {
+		var err error
+		if w, err = model.NewWorldWithRule(reporter.Rule()); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range cells {
+		w.AddCellIn(c.X, c.Y, 0)
+	}
+
+	start := fmt.Sprint(normalized(cells).cells)
+	shapes := [][]Cell{cells}
+	for p := 1; p <= MaxQueryPeriod; p++ {
+		w.Evolve()
+		var current []Cell
+		w.ForEachCell(func(x, y, turn int64) {
+			current = append(current, Cell{x, y})
+		})
+		if len(current) == 0 {
+			return nil, fmt.Errorf("pattern dies after %d generations", p)
+		}
+		if fmt.Sprint(normalized(current).cells) == start {
+			return shapes, nil
+		}
+		shapes = append(shapes, current)
+	}
+	return nil, fmt.Errorf("pattern does not repeat within %d generations", MaxQueryPeriod)
+}
+
+// normalized moves the cells so that their bounding box starts at (0, 0), and
+// sorts them in reading order.
+func normalized(cells []Cell) variant {
+	o := newObject(append([]Cell(nil), cells...))
+	v := variant{width: o.Width(), height: o.Height()}
+	for _, c := range o.Cells {
+		v.cells = append(v.cells, Cell{c.X - o.Left, c.Y - o.Top})
+	}
+	return v
+}
+
+// oriented returns the normalized variant reflected left to right if
+// orientation is 4 or more, then turned orientation%4 quarter turns
+// clockwise.
+func oriented(v variant, orientation int) variant {
+	cells := append([]Cell(nil), v.cells...)
+	width, height := v.width, v.height
+	if orientation >= 4 {
+		for i, c := range cells {
+			cells[i] = Cell{width - 1 - c.X, c.Y}
+		}
+	}
+	for i := 0; i < orientation%4; i++ {
+		for j, c := range cells {
+			cells[j] = Cell{height - 1 - c.Y, c.X}
+		}
+		width, height = height, width
+	}
+	sort.Slice(cells, func(i, j int) bool { return before(cells[i], cells[j]) })
+	return variant{cells: cells, width: width, height: height, orientation: orientation}
+}
+
+// Find returns the places where the query matches the world exactly: the
+// bounding box of the pattern holds the same living cells, and so many
+// columns and rows of cells around it, given by the margin, are all dead.
+// Matches are sorted by their top left corner in reading order.
+func (q *Query) Find(w types.World, margin int) []Match {
+	var alive []Cell
+	w.ForEachCell(func(x, y, turn int64) {
+		alive = append(alive, Cell{x, y})
+	})
+	m := int64(max(margin, 0))
+
+	var matches []Match
+	for _, v := range q.variants {
+		anchor := v.cells[0]
+		for _, c := range alive {
+			left, top := c.X-anchor.X, c.Y-anchor.Y
+			if !v.matches(w, left, top, m) {
+				continue
+			}
+			match := Match{X: left, Y: top, Orientation: v.orientation, Phase: v.phase}
+			for _, vc := range v.cells {
+				match.Cells = append(match.Cells, Cell{left + vc.X, top + vc.Y})
+			}
+			matches = append(matches, match)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return before(Cell{matches[i].X, matches[i].Y}, Cell{matches[j].X, matches[j].Y})
+	})
+	return matches
+}
+
+// matches reports whether the variant, with its top left corner at (left,
+// top), matches the world with the given margin of dead cells.
+func (v variant) matches(w types.World, left, top, margin int64) bool {
+	for _, c := range v.cells {
+		if !w.IsAlive(left+c.X, top+c.Y) {
+			return false
+		}
+	}
+	count := 0
+	for y := top - margin; y < top+v.height+margin; y++ {
+		for x := left - margin; x < left+v.width+margin; x++ {
+			if w.IsAlive(x, y) {
+				count++
+				if count > len(v.cells) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
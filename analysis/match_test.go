@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/daniel-munoz/life/model"
+)
+
+// block returns the cells of a block with its top left corner at (x, y).
+func block(x, y int64) []Cell {
+	return []Cell{{x, y}, {x + 1, y}, {x, y + 1}, {x + 1, y + 1}}
+}
+
+// reflected returns the cells reflected left to right around x = 0.
+func reflected(cells []Cell) []Cell {
+	var result []Cell
+	for _, c := range cells {
+		result = append(result, Cell{-c.X, c.Y})
+	}
+	return result
+}
+
+func TestQuery_Find(t *testing.T) {
+	var cells []Cell
+	cells = append(cells, glider(0, 0)...)
+	cells = append(cells, reflected(glider(-20, 0))...) // Moving down and left
+	cells = append(cells, glider(0, 20)...)
+	cells = append(cells, block(3, 20)...) // Next to the last glider
+	cells = append(cells, block(40, 40)...)
+	w := newWorld(cells...)
+
+	tests := []struct {
+		name    string
+		pattern []Cell
+		phases  bool
+		margin  int
+		want    [][2]int64
+	}{
+		{name: "gliders", pattern: glider(100, 100), margin: 1, want: [][2]int64{{0, 0}, {18, 0}}},
+		{name: "no margin", pattern: glider(100, 100), margin: 0, want: [][2]int64{{0, 0}, {18, 0}, {0, 20}}},
+		{name: "blocks", pattern: block(0, 0), margin: 1, want: [][2]int64{{40, 40}}},
+		{name: "blocks without margin", pattern: block(0, 0), margin: 0, want: [][2]int64{{3, 20}, {40, 40}}},
+		// The other phase of the glider, upside down, is not found without phases
+		{name: "other phase", pattern: []Cell{{0, 0}, {2, 0}, {1, 1}, {2, 1}, {1, 2}}, margin: 1},
+		{name: "other phase with phases", pattern: []Cell{{0, 0}, {2, 0}, {1, 1}, {2, 1}, {1, 2}}, phases: true, margin: 1,
+			want: [][2]int64{{0, 0}, {18, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewQuery(newWorld(tt.pattern...), tt.phases)
+			if err != nil {
+				t.Fatalf("NewQuery() unexpected error: %v", err)
+			}
+			matches := q.Find(w, tt.margin)
+			if len(matches) != len(tt.want) {
+				t.Fatalf("Find() = %v, want matches at %v", matches, tt.want)
+			}
+			for i, m := range matches {
+				if m.X != tt.want[i][0] || m.Y != tt.want[i][1] {
+					t.Errorf("match %d at (%d,%d), want (%d,%d)", i, m.X, m.Y, tt.want[i][0], tt.want[i][1])
+				}
+				for _, c := range m.Cells {
+					if !w.IsAlive(c.X, c.Y) {
+						t.Errorf("match %d has dead cell %v", i, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestQuery_Phase(t *testing.T) {
+	// A glider moving down and left, one generation on, which no orientation
+	// of the first phase matches
+	w := newWorld(reflected(glider(0, 0))...)
+	w.Evolve()
+
+	q, err := NewQuery(newWorld(glider(0, 0)...), true)
+	if err != nil {
+		t.Fatalf("NewQuery() unexpected error: %v", err)
+	}
+	matches := q.Find(w, DefaultMargin)
+	if len(matches) != 1 {
+		t.Fatalf("Find() = %v, want one match", matches)
+	}
+	if m := matches[0]; m.Phase != 1 {
+		t.Errorf("match in phase %d, want 1", m.Phase)
+	}
+}
+
+func TestNewQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern []Cell
+	}{
+		{name: "empty", pattern: nil},
+		{name: "dies", pattern: []Cell{{0, 0}}},
+		// The R-pentomino takes 1103 generations to settle
+		{name: "does not repeat", pattern: []Cell{{1, 0}, {2, 0}, {0, 1}, {1, 1}, {1, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewQuery(newWorld(tt.pattern...), true); err == nil {
+				t.Error("NewQuery() expected an error")
+			}
+		})
+	}
+}
+
+func TestNewQuery_Rule(t *testing.T) {
+	// Under HighLife the replicator is not an oscillator, but a block is
+	pattern, err := model.NewWorldWithRule("B36/S23")
+	if err != nil {
+		t.Fatalf("NewWorldWithRule() unexpected error: %v", err)
+	}
+	for _, c := range block(0, 0) {
+		pattern.AddCellIn(c.X, c.Y, 0)
+	}
+	q, err := NewQuery(pattern, true)
+	if err != nil {
+		t.Fatalf("NewQuery() unexpected error: %v", err)
+	}
+	if len(q.variants) != 1 {
+		t.Errorf("a block query has %d variants, want 1", len(q.variants))
+	}
+}
@@ -0,0 +1,112 @@
+// Package analysis finds the objects of a world: groups of living cells close
+// enough to each other to be taken as one thing, such as a glider or a block.
+// A Tracker follows the objects from one generation to the next, keeping their
+// identity and reporting when they appear, disappear, merge, split or collide,
+// and a Query finds the places where a given pattern occurs.
+package analysis
+
+import (
+	"sort"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// Cell is a living cell, by its coordinates.
+type Cell struct {
+	X, Y int64
+}
+
+// Object is a group of living cells where every cell can be reached from any
+// other through cells of the group, stepping over at most the allowed gap of
+// dead cells at a time.
+type Object struct {
+	// ID identifies the object across generations when it comes from a
+	// Tracker, and is its index in the result of Segment otherwise.
+	ID    int
+	Cells []Cell
+	// Left, Top, Right and Bottom bound the cells of the object, inclusive.
+	Left, Top, Right, Bottom int64
+}
+
+// Population returns the number of cells of the object.
+func (o Object) Population() int {
+	return len(o.Cells)
+}
+
+// Width returns the number of columns of the bounding box of the object.
+func (o Object) Width() int64 {
+	return o.Right - o.Left + 1
+}
+
+// Height returns the number of rows of the bounding box of the object.
+func (o Object) Height() int64 {
+	return o.Bottom - o.Top + 1
+}
+
+// Segment splits the living cells of the world into objects. With a gap of 0,
+// objects are the groups of cells connected through their 8 neighbors; a gap
+// of n also joins cells with up to n dead cells between them, horizontally,
+// vertically or diagonally. Objects are sorted by their first cell and their
+// cells in reading order: top to bottom, then left to right.
+func Segment(w types.World, gap int) []Object {
+	parent := make(map[Cell]Cell)
+	var find func(c Cell) Cell
+	find = func(c Cell) Cell {
+		if parent[c] != c {
+			parent[c] = find(parent[c])
+		}
+		return parent[c]
+	}
+	w.ForEachCell(func(x, y, turn int64) {
+		parent[Cell{x, y}] = Cell{x, y}
+	})
+
+	reach := int64(gap) + 1
+	for c := range parent {
+		for dy := -reach; dy <= reach; dy++ {
+			for dx := -reach; dx <= reach; dx++ {
+				neighbor := Cell{c.X + dx, c.Y + dy}
+				if _, alive := parent[neighbor]; alive {
+					parent[find(neighbor)] = find(c)
+				}
+			}
+		}
+	}
+
+	groups := make(map[Cell][]Cell)
+	for c := range parent {
+		root := find(c)
+		groups[root] = append(groups[root], c)
+	}
+	objects := make([]Object, 0, len(groups))
+	for _, cells := range groups {
+		objects = append(objects, newObject(cells))
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return before(objects[i].Cells[0], objects[j].Cells[0])
+	})
+	for i := range objects {
+		objects[i].ID = i
+	}
+	return objects
+}
+
+// newObject creates an object with the cells, sorting them in reading order
+// and computing their bounding box.
+func newObject(cells []Cell) Object {
+	sort.Slice(cells, func(i, j int) bool { return before(cells[i], cells[j]) })
+	o := Object{Cells: cells, Left: cells[0].X, Top: cells[0].Y, Right: cells[0].X, Bottom: cells[0].Y}
+	for _, c := range cells {
+		o.Left, o.Right = min(o.Left, c.X), max(o.Right, c.X)
+		o.Top, o.Bottom = min(o.Top, c.Y), max(o.Bottom, c.Y)
+	}
+	return o
+}
+
+// before reports whether a comes before b in reading order.
+func before(a, b Cell) bool {
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
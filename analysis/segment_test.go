@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// newWorld creates a Conway world with the given living cells.
+func newWorld(cells ...Cell) types.World {
+	w := model.NewWorld()
+	for _, c := range cells {
+		w.AddCellIn(c.X, c.Y, 0)
+	}
+	return w
+}
+
+func TestSegment(t *testing.T) {
+	// A block, a cell two columns to its right and a cell four rows below it
+	w := newWorld(
+		Cell{0, 0}, Cell{1, 0}, Cell{0, 1}, Cell{1, 1},
+		Cell{3, 1},
+		Cell{0, 5},
+	)
+
+	tests := []struct {
+		name  string
+		gap   int
+		cells [][]Cell
+	}{
+		{
+			name: "neighbors only",
+			gap:  0,
+			cells: [][]Cell{
+				{{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+				{{3, 1}},
+				{{0, 5}},
+			},
+		},
+		{
+			name: "one cell gap",
+			gap:  1,
+			cells: [][]Cell{
+				{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {3, 1}},
+				{{0, 5}},
+			},
+		},
+		{
+			name: "three cell gap",
+			gap:  3,
+			cells: [][]Cell{
+				{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {3, 1}, {0, 5}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := Segment(w, tt.gap)
+			var got [][]Cell
+			for i, o := range objects {
+				if o.ID != i {
+					t.Errorf("object %d has ID %d", i, o.ID)
+				}
+				got = append(got, o.Cells)
+			}
+			if !reflect.DeepEqual(got, tt.cells) {
+				t.Errorf("Segment(%d) = %v, want %v", tt.gap, got, tt.cells)
+			}
+		})
+	}
+}
+
+func TestSegment_BoundingBox(t *testing.T) {
+	// A glider
+	w := newWorld(Cell{1, -3}, Cell{2, -2}, Cell{0, -1}, Cell{1, -1}, Cell{2, -1})
+
+	objects := Segment(w, 0)
+	if len(objects) != 1 {
+		t.Fatalf("Segment() found %d objects, want 1", len(objects))
+	}
+	o := objects[0]
+	if o.Left != 0 || o.Top != -3 || o.Right != 2 || o.Bottom != -1 {
+		t.Errorf("bounding box (%d,%d) -> (%d,%d), want (0,-3) -> (2,-1)", o.Left, o.Top, o.Right, o.Bottom)
+	}
+	if o.Population() != 5 || o.Width() != 3 || o.Height() != 3 {
+		t.Errorf("population %d, size %dx%d, want 5 and 3x3", o.Population(), o.Width(), o.Height())
+	}
+}
+
+func TestSegment_Empty(t *testing.T) {
+	if objects := Segment(model.NewWorld(), 1); len(objects) != 0 {
+		t.Errorf("Segment() of an empty world = %v", objects)
+	}
+}
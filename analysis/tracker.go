@@ -0,0 +1,229 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// EventKind is what happened to objects between two generations.
+type EventKind int
+
+// Kinds of tracker events.
+const (
+	Birth     EventKind = iota // An object appeared away from any other
+	Death                      // An object disappeared
+	Merge                      // Objects joined into one
+	Collision                  // Objects joined into one, at least one of them moving
+	Split                      // An object broke apart; one event for each new part
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case Birth:
+		return "birth"
+	case Death:
+		return "death"
+	case Merge:
+		return "merge"
+	case Collision:
+		return "collision"
+	case Split:
+		return "split"
+	}
+	return "unknown"
+}
+
+// Event is something that happened to objects in a generation.
+type Event struct {
+	Kind EventKind
+	Turn int64
+	// ID is the object born, merged or split off, or the object that died.
+	ID int
+	// Parents are the objects it came from: those that merged or collided,
+	// or the object it split from.
+	Parents []int
+}
+
+// String describes the event, such as "turn 12: collision of 1 and 3 into 1".
+func (e Event) String() string {
+	parents := make([]string, len(e.Parents))
+	for i, p := range e.Parents {
+		parents[i] = fmt.Sprint(p)
+	}
+	switch e.Kind {
+	case Merge, Collision:
+		return fmt.Sprintf("turn %d: %s of %s into %d", e.Turn, e.Kind, strings.Join(parents, " and "), e.ID)
+	case Split:
+		return fmt.Sprintf("turn %d: %d split from %s", e.Turn, e.ID, parents[0])
+	}
+	return fmt.Sprintf("turn %d: %s of %d", e.Turn, e.Kind, e.ID)
+}
+
+// tracked is an object followed by a Tracker.
+type tracked struct {
+	Object
+	moving bool
+}
+
+// Tracker follows the objects of a world across generations. It must see
+// every generation: an object is taken to come from the objects of the
+// previous generation that had a cell within one cell of its cells.
+//
+// An object keeps its ID while it changes alone. When objects merge, the
+// result keeps the ID of the largest of them, and when an object splits, its
+// largest part keeps its ID and the others get new ones. Objects whose
+// bounding box has moved without changing size, such as gliders, are taken to
+// be moving, and a merge involving a moving object is reported as a collision.
+type Tracker struct {
+	gap     int
+	nextID  int
+	started bool
+	objects []tracked
+}
+
+// NewTracker creates a tracker that segments worlds with the given gap, as
+// Segment does.
+func NewTracker(gap int) *Tracker {
+	return &Tracker{gap: gap}
+}
+
+// Objects returns the objects found by the last update, sorted as by Segment.
+func (t *Tracker) Objects() []Object {
+	objects := make([]Object, len(t.objects))
+	for i, o := range t.objects {
+		objects[i] = o.Object
+	}
+	return objects
+}
+
+// Moving reports whether the object with the given ID has been seen moving.
+func (t *Tracker) Moving(id int) bool {
+	for _, o := range t.objects {
+		if o.ID == id {
+			return o.moving
+		}
+	}
+	return false
+}
+
+// Update segments the world, which should be one generation after the world
+// of the previous update, and matches its objects with the previous ones. It
+// returns what happened to them, in the order of the objects. The first update
+// only numbers the objects.
+func (t *Tracker) Update(w types.World) []Event {
+	objects := Segment(w, t.gap)
+	current := make([]tracked, len(objects))
+	if !t.started {
+		t.started = true
+		for i, o := range objects {
+			o.ID = t.newID()
+			current[i] = tracked{Object: o}
+		}
+		t.objects = current
+		return nil
+	}
+
+	// The previous objects each object comes from
+	owner := make(map[Cell]int)
+	for i, o := range t.objects {
+		for _, c := range o.Cells {
+			owner[c] = i
+		}
+	}
+	parents := make([][]int, len(objects))
+	children := make([]int, len(t.objects))
+	for i, o := range objects {
+		seen := make(map[int]bool)
+		for _, c := range o.Cells {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dx := int64(-1); dx <= 1; dx++ {
+					if p, found := owner[Cell{c.X + dx, c.Y + dy}]; found && !seen[p] {
+						seen[p] = true
+						parents[i] = append(parents[i], p)
+						children[p]++
+					}
+				}
+			}
+		}
+		// The largest parent first, as it gives its ID
+		sort.Slice(parents[i], func(a, b int) bool {
+			pa, pb := t.objects[parents[i][a]], t.objects[parents[i][b]]
+			if pa.Population() != pb.Population() {
+				return pa.Population() > pb.Population()
+			}
+			return pa.ID < pb.ID
+		})
+	}
+
+	// The largest objects pick their ID first
+	order := make([]int, len(objects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return objects[order[a]].Population() > objects[order[b]].Population()
+	})
+	given := make([]bool, len(t.objects))
+	for _, i := range order {
+		current[i] = tracked{Object: objects[i]}
+		current[i].ID = -1
+		for _, p := range parents[i] {
+			if !given[p] {
+				given[p] = true
+				current[i].ID = t.objects[p].ID
+				if len(parents[i]) == 1 {
+					current[i].moving = t.objects[p].moving || movedAlone(t.objects[p].Object, objects[i])
+				}
+				break
+			}
+		}
+		if current[i].ID < 0 {
+			current[i].ID = t.newID()
+		}
+	}
+
+	turn := w.Turn()
+	var events []Event
+	for i, o := range current {
+		ids := make([]int, len(parents[i]))
+		moving := false
+		for j, p := range parents[i] {
+			ids[j] = t.objects[p].ID
+			moving = moving || t.objects[p].moving
+		}
+		switch {
+		case len(ids) == 0:
+			events = append(events, Event{Kind: Birth, Turn: turn, ID: o.ID})
+		case len(ids) > 1 && moving:
+			events = append(events, Event{Kind: Collision, Turn: turn, ID: o.ID, Parents: ids})
+		case len(ids) > 1:
+			events = append(events, Event{Kind: Merge, Turn: turn, ID: o.ID, Parents: ids})
+		case children[parents[i][0]] > 1 && o.ID != ids[0]:
+			events = append(events, Event{Kind: Split, Turn: turn, ID: o.ID, Parents: ids})
+		}
+	}
+	for p, o := range t.objects {
+		if children[p] == 0 {
+			events = append(events, Event{Kind: Death, Turn: turn, ID: o.ID})
+		}
+	}
+	t.objects = current
+	return events
+}
+
+// newID returns an ID not used before.
+func (t *Tracker) newID() int {
+	t.nextID++
+	return t.nextID
+}
+
+// movedAlone reports whether an object changed position between two
+// generations while keeping the size of its bounding box.
+func movedAlone(before, after Object) bool {
+	return before.Width() == after.Width() && before.Height() == after.Height() &&
+		(before.Left != after.Left || before.Top != after.Top)
+}
@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daniel-munoz/life/model"
+)
+
+// glider returns the cells of a glider moving down and right, with its top
+// left corner at (x, y).
+func glider(x, y int64) []Cell {
+	return []Cell{{x + 1, y}, {x + 2, y + 1}, {x, y + 2}, {x + 1, y + 2}, {x + 2, y + 2}}
+}
+
+// kinds counts the events of each kind.
+func kinds(events []Event) map[EventKind]int {
+	counts := make(map[EventKind]int)
+	for _, e := range events {
+		counts[e.Kind]++
+	}
+	return counts
+}
+
+func TestTracker_Glider(t *testing.T) {
+	w := newWorld(glider(0, 0)...)
+	tracker := NewTracker(1)
+	if events := tracker.Update(w); events != nil {
+		t.Errorf("first Update() = %v, want no events", events)
+	}
+
+	for i := 0; i < 8; i++ {
+		w.Evolve()
+		if events := tracker.Update(w); len(events) != 0 {
+			t.Fatalf("Update() at turn %d = %v, want no events", w.Turn(), events)
+		}
+	}
+	objects := tracker.Objects()
+	if len(objects) != 1 || objects[0].ID != 1 {
+		t.Fatalf("Objects() = %v, want the glider with ID 1", objects)
+	}
+	if objects[0].Left != 2 || objects[0].Top != 2 {
+		t.Errorf("glider at (%d,%d) after 8 turns, want (2,2)", objects[0].Left, objects[0].Top)
+	}
+	if !tracker.Moving(1) {
+		t.Error("Moving(1) = false, want true for a glider")
+	}
+}
+
+func TestTracker_BirthAndDeath(t *testing.T) {
+	// A block and a lone cell, which dies
+	w := newWorld(Cell{0, 0}, Cell{1, 0}, Cell{0, 1}, Cell{1, 1}, Cell{10, 10})
+	tracker := NewTracker(0)
+	tracker.Update(w)
+
+	w.Evolve()
+	w.AddCellIn(-10, -10, w.Turn())
+	events := tracker.Update(w)
+	want := []Event{
+		{Kind: Birth, Turn: 1, ID: 3},
+		{Kind: Death, Turn: 1, ID: 2},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Update() = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i].String() != want[i].String() {
+			t.Errorf("event %d = %q, want %q", i, events[i], want[i])
+		}
+	}
+	if tracker.Moving(1) {
+		t.Error("Moving(1) = true, want false for a block")
+	}
+}
+
+func TestTracker_Collision(t *testing.T) {
+	// A glider heading for a block
+	w := newWorld(append(glider(0, 0), Cell{8, 8}, Cell{9, 8}, Cell{8, 9}, Cell{9, 9})...)
+	tracker := NewTracker(1)
+	tracker.Update(w)
+
+	var collision *Event
+	for i := 0; i < 30 && collision == nil; i++ {
+		w.Evolve()
+		for _, e := range tracker.Update(w) {
+			if e.Kind == Collision {
+				collision = &e
+				break
+			}
+		}
+	}
+	if collision == nil {
+		t.Fatal("no collision between the glider and the block")
+	}
+	// The glider is the largest, so the result keeps its ID
+	if collision.ID != 1 || len(collision.Parents) != 2 || collision.Parents[0] != 1 || collision.Parents[1] != 2 {
+		t.Errorf("collision = %v, want the glider (1) and the block (2) into 1", collision)
+	}
+}
+
+func TestTracker_CollisionSample(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+	if err := os.Chdir(filepath.Join(originalWd, "..")); err != nil {
+		t.Fatalf("Failed to change to project directory: %v", err)
+	}
+	w, err := model.ReadWorld("collision")
+	if err != nil {
+		t.Fatalf("ReadWorld() unexpected error: %v", err)
+	}
+
+	tracker := NewTracker(1)
+	tracker.Update(w)
+	if got := len(tracker.Objects()); got != 7 {
+		t.Errorf("collision starts with %d objects, want 7", got)
+	}
+	var events []Event
+	for i := 0; i < 100; i++ {
+		w.Evolve()
+		events = append(events, tracker.Update(w)...)
+	}
+	counts := kinds(events)
+	for _, kind := range []EventKind{Merge, Collision, Split, Death} {
+		if counts[kind] == 0 {
+			t.Errorf("no %s in the first 100 turns of collision", kind)
+		}
+	}
+	if got := len(tracker.Objects()); got != 7 {
+		t.Errorf("collision ends with %d objects, want 7", got)
+	}
+}
+
+func TestEventKind_String(t *testing.T) {
+	tests := []struct {
+		kind EventKind
+		want string
+	}{
+		{Birth, "birth"},
+		{Death, "death"},
+		{Merge, "merge"},
+		{Collision, "collision"},
+		{Split, "split"},
+		{EventKind(99), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := tt.kind.String(); got != tt.want {
+			t.Errorf("EventKind(%d).String() = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/daniel-munoz/life/model/bench"
+)
+
+// runBench implements the "bench" subcommand: it runs the benchmark workloads
+// against the world engines and reports how fast each one evolved.
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	workloadNames := flags.String("workload", "", "comma separated `names` of the workloads to run (all if empty)")
+	engineNames := flags.String("engine", "", "comma separated `names` of the engines to run them on (all if empty)")
+	format := flags.String("format", "text", "report format: text or json")
+	output := flags.String("o", "", "write the report to `file` instead of the standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life bench [-workload names] [-engine names] [-format text|json] [-o file]")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "Workloads:")
+		for _, w := range bench.Workloads {
+			fmt.Fprintf(flags.Output(), "  %s: %s\n", w.Name, w.Description)
+		}
+		fmt.Fprint(flags.Output(), "Engines:")
+		for _, e := range bench.Engines {
+			fmt.Fprintf(flags.Output(), " %s", e.Name)
+		}
+		fmt.Fprintln(flags.Output())
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	workloads, err := bench.SelectWorkloads(*workloadNames)
+	if err != nil {
+		return err
+	}
+	engines, err := bench.SelectEngines(*engineNames)
+	if err != nil {
+		return err
+	}
+	var write func(r *bench.Report, out io.Writer) error
+	switch *format {
+	case "text":
+		write = (*bench.Report).WriteText
+	case "json":
+		write = (*bench.Report).WriteJSON
+	default:
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+
+	report := bench.NewReport()
+	for _, w := range workloads {
+		for _, e := range engines {
+			fmt.Fprintf(os.Stderr, "Running %s on %s\n", w.Name, e.Name)
+			result, err := bench.Run(w, e)
+			if err != nil {
+				return err
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+
+	if *output == "" {
+		return write(report, os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(report, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
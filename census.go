@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/model/census"
+)
+
+// runCensus implements the "census" subcommand: it runs many random soups
+// without a display and writes a tally of the objects they leave behind.
+func runCensus(args []string) error {
+	options := census.DefaultOptions()
+	flags := flag.NewFlagSet("census", flag.ContinueOnError)
+	seed := flags.Uint64("seed", 0, "seed of the first soup, the others following it (random if not given)")
+	flags.IntVar(&options.Soups, "soups", options.Soups, "number of soups to run")
+	flags.IntVar(&options.Workers, "workers", 0, "number of soups run at the same time (the number of CPUs if 0)")
+	flags.StringVar(&options.Rule, "rule", "", "run the soups with `rule`, such as B36/S23")
+	flags.Int64Var(&options.Soup.Width, "width", model.DefaultSoupSize, "soup width in cells")
+	flags.Int64Var(&options.Soup.Height, "height", model.DefaultSoupSize, "soup height in cells")
+	flags.Float64Var(&options.Soup.Density, "density", model.DefaultSoupDensity, "probability of each cell being alive, between 0 and 1")
+	symmetry := flags.String("symmetry", string(model.C1), "soup symmetry: C1, D2, D4 or D8")
+	flags.Int64Var(&options.MaxGenerations, "generations", options.MaxGenerations, "give up on soups not stable after this many generations")
+	format := flags.String("format", "csv", "report format: csv or json")
+	output := flags.String("o", "", "write the report to `file` instead of the standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life census [-soups n] [-seed n] [-rule rule] [-format csv|json] [-o file] [soup flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			options.Soup.Seed = *seed
+		}
+	})
+	var err error
+	if options.Soup.Symmetry, err = model.ParseSymmetry(*symmetry); err != nil {
+		return err
+	}
+	var write func(r *census.Report, out io.Writer) error
+	switch *format {
+	case "csv":
+		write = (*census.Report).WriteCSV
+	case "json":
+		write = (*census.Report).WriteJSON
+	default:
+		return fmt.Errorf("unknown format %q (want csv or json)", *format)
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %d soups from seed %d\n", options.Soups, options.Soup.Seed)
+	report, err := census.Run(options)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d soups stabilized, %d did not, %d kinds of objects found\n",
+		report.Stable, len(report.Unstable), len(report.Objects))
+
+	if *output == "" {
+		return write(report, os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(report, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,265 @@
+// Package control provides a line-based command channel, over a local Unix
+// domain socket, to drive a running simulation from other tools.
+package control
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/daniel-munoz/life/event"
+)
+
+// Controller performs the commands that act on the world directly rather than
+// through events.
+type Controller interface {
+	// Step advances the world by n generations.
+	Step(n int64)
+	// Turn returns the generation of the world.
+	Turn() int64
+	// Move moves the view window by dx columns and dy rows.
+	Move(dx, dy int64)
+	// Load replaces the world with the named sample.
+	Load(sampleName string) error
+	// Save writes the world to the file at path.
+	Save(path string) error
+	// Stats describes the world in one line.
+	Stats() string
+}
+
+// maxChunk is the largest number of generations a step or goto command runs
+// in one Check, so that long runs leave the game loop free to draw and take
+// other input between chunks.
+const maxChunk = 100
+
+// command is a line received from a client, waiting to be executed.
+type command struct {
+	line  string
+	reply chan string
+}
+
+// Server accepts connections on a Unix socket and reads one command per line.
+// It implements event.Listener: commands are executed when Check is called,
+// from the game loop, so they never race with the world evolving. Commands
+// that match an event name ("pause", "quit", "up", ...) are returned as that
+// event; the others ("step 10", "goto 500", "move 5 -3", "load gun",
+// "save out.rle", "stats") are performed through the Controller; "save" alone
+// is the event that saves the session. Every command
+// is answered with one line: "ok", "error: <reason>" or the requested stats.
+// Step and goto commands run in chunks of generations, one per Check, and are
+// answered once they are done; the commands after them wait until then.
+type Server struct {
+	listener   net.Listener
+	path       string
+	controller Controller
+	commands   chan command
+	done       chan struct{}
+	stopOnce   sync.Once
+	// running is the step or goto command being run, with the number of
+	// generations it has left.
+	running *command
+	left    int64
+}
+
+// Listen creates the socket at path. A stale socket left by a previous run is
+// replaced; any other existing file is an error.
+func Listen(path string, c Controller) (*Server, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		listener:   l,
+		path:       path,
+		controller: c,
+		commands:   make(chan command),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Start begins accepting connections.
+func (s *Server) Start() {
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+// serve reads commands from a connection and writes back their replies.
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		cmd := command{line: line, reply: make(chan string, 1)}
+		select {
+		case s.commands <- cmd:
+		case <-s.done:
+			return
+		}
+		select {
+		case reply := <-cmd.reply:
+			if _, err := fmt.Fprintln(conn, reply); err != nil {
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Check executes the next pending command, if any, and returns its event, or
+// runs the next chunk of the running step or goto command.
+func (s *Server) Check() event.Event {
+	if s.running == nil {
+		select {
+		case cmd := <-s.commands:
+			e, reply := s.execute(cmd.line)
+			if s.left == 0 {
+				cmd.reply <- reply
+				return e
+			}
+			s.running = &cmd
+		default:
+			return event.None
+		}
+	}
+	s.advance()
+	return event.None
+}
+
+// advance runs the next chunk of generations of the running command, and
+// answers it once it has run them all.
+func (s *Server) advance() {
+	n := min(s.left, maxChunk)
+	s.controller.Step(n)
+	if s.left -= n; s.left == 0 {
+		s.running.reply <- "ok"
+		s.running = nil
+	}
+}
+
+// Stop closes the socket and all connections.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.listener.Close()
+		os.Remove(s.path)
+	})
+}
+
+// execute runs a command line, returning the event to pass on and the reply.
+// Step and goto commands are not run but set the number of generations left to
+// run.
+func (s *Server) execute(line string) (event.Event, string) {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+
+	// An event name alone is the event; "save" with a file is a command
+	if e, err := event.ParseEvent(name); err == nil && len(args) == 0 {
+		return e, "ok"
+	}
+
+	var err error
+	switch name {
+	case "step":
+		n := int64(1)
+		if len(args) > 0 {
+			n, err = parseNumber(args, "step [generations]")
+		}
+		if err == nil && n < 0 {
+			err = fmt.Errorf("cannot step %d generations", n)
+		}
+		if err == nil {
+			s.left = n
+		}
+	case "goto":
+		var n int64
+		if n, err = parseNumber(args, "goto <generation>"); err == nil {
+			if turn := s.controller.Turn(); n < turn {
+				err = fmt.Errorf("cannot go back to generation %d from %d", n, turn)
+			} else {
+				s.left = n - turn
+			}
+		}
+	case "move":
+		var dx, dy int64
+		if dx, dy, err = parsePair(args, "move <dx> <dy>"); err == nil {
+			s.controller.Move(dx, dy)
+		}
+	case "load":
+		if len(args) != 1 {
+			err = fmt.Errorf("usage: load <sample>")
+		} else {
+			err = s.controller.Load(args[0])
+		}
+	case "save":
+		if len(args) != 1 {
+			err = fmt.Errorf("usage: save <file>")
+		} else {
+			err = s.controller.Save(args[0])
+		}
+	case "stats":
+		if len(args) != 0 {
+			err = fmt.Errorf("usage: stats")
+		} else {
+			return event.None, s.controller.Stats()
+		}
+	default:
+		if _, parseErr := event.ParseEvent(name); parseErr == nil {
+			err = fmt.Errorf("%s takes no arguments", name)
+		} else {
+			err = fmt.Errorf("unknown command %q", name)
+		}
+	}
+	if err != nil {
+		return event.None, "error: " + err.Error()
+	}
+	return event.None, "ok"
+}
+
+// parseNumber parses a single integer argument.
+func parseNumber(args []string, usage string) (int64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: %s", usage)
+	}
+	n, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", args[0])
+	}
+	return n, nil
+}
+
+// parsePair parses two integer arguments.
+func parsePair(args []string, usage string) (int64, int64, error) {
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("usage: %s", usage)
+	}
+	a, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid number %q", args[0])
+	}
+	b, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid number %q", args[1])
+	}
+	return a, b, nil
+}
@@ -0,0 +1,182 @@
+package control
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/daniel-munoz/life/event"
+)
+
+// mockController records the calls made by the server.
+type mockController struct {
+	calls []string
+}
+
+func (mc *mockController) Step(n int64) {
+	mc.calls = append(mc.calls, fmt.Sprintf("step %d", n))
+}
+
+func (mc *mockController) Turn() int64 {
+	return 7
+}
+
+func (mc *mockController) Move(dx, dy int64) {
+	mc.calls = append(mc.calls, fmt.Sprintf("move %d %d", dx, dy))
+}
+
+func (mc *mockController) Load(sampleName string) error {
+	if sampleName == "missing" {
+		return fmt.Errorf("no such sample")
+	}
+	mc.calls = append(mc.calls, "load "+sampleName)
+	return nil
+}
+
+func (mc *mockController) Save(path string) error {
+	mc.calls = append(mc.calls, "save "+path)
+	return nil
+}
+
+func (mc *mockController) Stats() string {
+	return "generation 7 population 3"
+}
+
+func TestServer_Execute(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantEvent event.Event
+		wantReply string
+		wantCall  string
+		wantLeft  int64
+	}{
+		{line: "pause", wantEvent: event.Pause, wantReply: "ok"},
+		{line: "quit", wantEvent: event.Stop, wantReply: "ok"},
+		{line: "page-left", wantEvent: event.PageLeft, wantReply: "ok"},
+		{line: "pause now", wantEvent: event.None, wantReply: "error: pause takes no arguments"},
+		{line: "step", wantEvent: event.None, wantReply: "ok", wantLeft: 1},
+		{line: "step 10", wantEvent: event.None, wantReply: "ok", wantLeft: 10},
+		{line: "step -1", wantEvent: event.None, wantReply: "error: cannot step -1 generations"},
+		{line: "step ten", wantEvent: event.None, wantReply: `error: invalid number "ten"`},
+		{line: "goto 500", wantEvent: event.None, wantReply: "ok", wantLeft: 493},
+		{line: "goto -5", wantEvent: event.None, wantReply: "error: cannot go back to generation -5 from 7"},
+		{line: "goto", wantEvent: event.None, wantReply: "error: usage: goto <generation>"},
+		{line: "move 5 -3", wantEvent: event.None, wantReply: "ok", wantCall: "move 5 -3"},
+		{line: "move 5", wantEvent: event.None, wantReply: "error: usage: move <dx> <dy>"},
+		{line: "load gun", wantEvent: event.None, wantReply: "ok", wantCall: "load gun"},
+		{line: "load missing", wantEvent: event.None, wantReply: "error: no such sample"},
+		{line: "save out.rle", wantEvent: event.None, wantReply: "ok", wantCall: "save out.rle"},
+		{line: "save", wantEvent: event.Save, wantReply: "ok"},
+		{line: "stats", wantEvent: event.None, wantReply: "generation 7 population 3"},
+		{line: "dance", wantEvent: event.None, wantReply: `error: unknown command "dance"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			mc := &mockController{}
+			s := &Server{controller: mc}
+
+			gotEvent, gotReply := s.execute(tt.line)
+			if gotEvent != tt.wantEvent {
+				t.Errorf("execute(%q) event = %v, want %v", tt.line, gotEvent, tt.wantEvent)
+			}
+			if gotReply != tt.wantReply {
+				t.Errorf("execute(%q) reply = %q, want %q", tt.line, gotReply, tt.wantReply)
+			}
+			if tt.wantCall == "" && len(mc.calls) != 0 {
+				t.Errorf("execute(%q) made unexpected calls %v", tt.line, mc.calls)
+			}
+			if tt.wantCall != "" && (len(mc.calls) != 1 || mc.calls[0] != tt.wantCall) {
+				t.Errorf("execute(%q) calls = %v, want [%s]", tt.line, mc.calls, tt.wantCall)
+			}
+			if s.left != tt.wantLeft {
+				t.Errorf("execute(%q) generations left = %d, want %d", tt.line, s.left, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestServer_Socket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "life.sock")
+	mc := &mockController{}
+	s, err := Listen(path, mc)
+	if err != nil {
+		t.Fatalf("Listen() unexpected error: %v", err)
+	}
+	s.Start()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial() unexpected error: %v", err)
+	}
+	defer conn.Close()
+	replies := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			replies <- scanner.Text()
+		}
+	}()
+
+	// Play the game loop: check the server until the command has been answered
+	send := func(line string) (event.Event, string) {
+		fmt.Fprintln(conn, line)
+		got := event.None
+		timeout := time.After(time.Second)
+		for {
+			if e := s.Check(); e != event.None {
+				got = e
+			}
+			select {
+			case reply := <-replies:
+				return got, reply
+			case <-timeout:
+				t.Fatalf("no reply to %q", line)
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}
+
+	if e, reply := send("pause"); e != event.Pause || reply != "ok" {
+		t.Errorf("pause = %v, %q, want %v, \"ok\"", e, reply, event.Pause)
+	}
+	if e, reply := send("step 3"); e != event.None || reply != "ok" {
+		t.Errorf("step 3 = %v, %q, want None, \"ok\"", e, reply)
+	}
+	if _, reply := send("stats"); reply != "generation 7 population 3" {
+		t.Errorf("stats reply = %q", reply)
+	}
+	if len(mc.calls) != 1 || mc.calls[0] != "step 3" {
+		t.Errorf("controller calls = %v, want [step 3]", mc.calls)
+	}
+
+	// Long runs go in chunks, one per check
+	mc.calls = nil
+	if _, reply := send("goto 257"); reply != "ok" {
+		t.Errorf("goto 257 reply = %q, want \"ok\"", reply)
+	}
+	if want := []string{"step 100", "step 100", "step 50"}; !reflect.DeepEqual(mc.calls, want) {
+		t.Errorf("controller calls = %v, want %v", mc.calls, want)
+	}
+
+	s.Stop()
+	s.Stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stop() should remove the socket, Stat() error = %v", err)
+	}
+}
+
+func TestListen_NotASocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regular")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if _, err := Listen(path, &mockController{}); err == nil {
+		t.Error("Listen() expected error when path is a regular file")
+	}
+}
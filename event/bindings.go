@@ -0,0 +1,298 @@
+package event
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// bindingsFileName is the name of the key bindings file inside the user config directory.
+const bindingsFileName = "keys.conf"
+
+// stopKey is always bound to Stop, so a broken configuration can never trap the user.
+const stopKey = "ctrl+c"
+
+// eventNames holds the configuration name of every bindable event, in help order.
+var eventNames = []struct {
+	event Event
+	name  string
+}{
+	{Up, "up"},
+	{Down, "down"},
+	{Left, "left"},
+	{Right, "right"},
+	{PageUp, "page-up"},
+	{PageDown, "page-down"},
+	{PageLeft, "page-left"},
+	{PageRight, "page-right"},
+	{Stop, "quit"},
+	{Help, "help"},
+	{Pause, "pause"},
+	{Edit, "edit"},
+	{Save, "save"},
+	{Soup, "soup"},
+	{Objects, "objects"},
+	{Charts, "charts"},
+	{Heatmap, "heatmap"},
+}
+
+// presets holds the built-in binding tables that a configuration file can start from.
+var presets = map[string]map[Event][]string{
+	"default": {
+		Up:        {"up"},
+		Down:      {"down"},
+		Left:      {"left"},
+		Right:     {"right"},
+		PageUp:    {"i"},
+		PageDown:  {"k"},
+		PageLeft:  {"j"},
+		PageRight: {"l"},
+		Stop:      {"q", stopKey},
+		Help:      {"h"},
+		Pause:     {"space"},
+		Edit:      {"e"},
+		Save:      {"w"},
+		Soup:      {"r"},
+		Objects:   {"o"},
+		Charts:    {"g"},
+		Heatmap:   {"m"},
+	},
+	"vi": {
+		Up:        {"k", "up"},
+		Down:      {"j", "down"},
+		Left:      {"h", "left"},
+		Right:     {"l", "right"},
+		PageUp:    {"K"},
+		PageDown:  {"J"},
+		PageLeft:  {"H"},
+		PageRight: {"L"},
+		Stop:      {"q", stopKey},
+		Help:      {"?"},
+		Pause:     {"space"},
+		Edit:      {"e"},
+		Save:      {"w"},
+		Soup:      {"r"},
+		Objects:   {"o"},
+		Charts:    {"g"},
+		Heatmap:   {"m"},
+	},
+	"wasd": {
+		Up:        {"w", "up"},
+		Down:      {"s", "down"},
+		Left:      {"a", "left"},
+		Right:     {"d", "right"},
+		PageUp:    {"W"},
+		PageDown:  {"S"},
+		PageLeft:  {"A"},
+		PageRight: {"D"},
+		Stop:      {"q", stopKey},
+		Help:      {"h"},
+		Pause:     {"space"},
+		Edit:      {"e"},
+		Save:      {"v"},
+		Soup:      {"r"},
+		Objects:   {"o"},
+		Charts:    {"g"},
+		Heatmap:   {"m"},
+	},
+}
+
+// String returns the configuration name of the event.
+func (e Event) String() string {
+	for _, en := range eventNames {
+		if en.event == e {
+			return en.name
+		}
+	}
+	return "none"
+}
+
+// ParseEvent returns the event with the given configuration name.
+func ParseEvent(name string) (Event, error) {
+	for _, en := range eventNames {
+		if en.name == name {
+			return en.event, nil
+		}
+	}
+	return None, fmt.Errorf("unknown action %q", name)
+}
+
+// Bindings maps key names, as reported by the keyboard library (e.g. "q", "up",
+// "space", "ctrl+c"), to events. An event may be bound to several keys.
+type Bindings struct {
+	events map[string]Event
+	keys   map[Event][]string
+}
+
+// NewBindings creates the bindings of the named preset ("default", "vi" or "wasd").
+func NewBindings(preset string) (*Bindings, error) {
+	table, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown key binding preset %q", preset)
+	}
+	b := &Bindings{
+		events: make(map[string]Event),
+		keys:   make(map[Event][]string),
+	}
+	for _, en := range eventNames {
+		b.Bind(en.event, table[en.event]...)
+	}
+	return b, nil
+}
+
+// DefaultBindings returns the bindings of the default preset.
+func DefaultBindings() *Bindings {
+	b, _ := NewBindings("default")
+	return b
+}
+
+// Bind replaces the keys bound to the event. Keys previously bound to another
+// event are moved to this one, except for ctrl+c, which always stops.
+func (b *Bindings) Bind(e Event, keyNames ...string) {
+	for _, k := range b.keys[e] {
+		delete(b.events, k)
+	}
+	b.keys[e] = nil
+	for _, k := range keyNames {
+		if k == stopKey && e != Stop {
+			continue
+		}
+		if previous, found := b.events[k]; found {
+			b.keys[previous] = removeKey(b.keys[previous], k)
+		}
+		b.events[k] = e
+		b.keys[e] = append(b.keys[e], k)
+	}
+	if e == Stop && b.events[stopKey] != Stop {
+		b.events[stopKey] = Stop
+		b.keys[Stop] = append(b.keys[Stop], stopKey)
+	}
+}
+
+// Keys returns the names of the keys bound to the event.
+func (b *Bindings) Keys(e Event) []string {
+	return b.keys[e]
+}
+
+// Events returns every bindable event, in help order.
+func (b *Bindings) Events() []Event {
+	events := make([]Event, len(eventNames))
+	for i, en := range eventNames {
+		events[i] = en.event
+	}
+	return events
+}
+
+// lookup returns the event bound to the named key and whether to stop listening.
+func (b *Bindings) lookup(keyName string) (event Event, stop bool) {
+	e, found := b.events[keyName]
+	if !found {
+		return None, false
+	}
+	return e, e == Stop
+}
+
+// removeKey returns keyNames without the given key.
+func removeKey(keyNames []string, key string) []string {
+	result := keyNames[:0]
+	for _, k := range keyNames {
+		if k != key {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// DefaultBindingsPath returns the location of the key bindings file in the user
+// config directory, e.g. ~/.config/life/keys.conf.
+func DefaultBindingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "life", bindingsFileName), nil
+}
+
+// LoadBindings reads the key bindings file at path. A missing file yields the
+// default bindings.
+func LoadBindings(path string) (*Bindings, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return DefaultBindings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := ParseBindings(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// ParseBindings reads key bindings in the configuration file format. Each line
+// holds "action: key, key, ...", where action is an event name such as "up" or
+// "pause". A "preset: name" line selects the table the other lines modify and
+// must come first. Empty lines and lines starting with '#' are ignored.
+func ParseBindings(r io.Reader) (*Bindings, error) {
+	var (
+		lineNumber int
+		b          *Bindings
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		separator := strings.Index(line, ":")
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: expected \"action: keys\", got %q", lineNumber, line)
+		}
+		action := strings.TrimSpace(line[:separator])
+		value := strings.TrimSpace(line[separator+1:])
+
+		if action == "preset" {
+			if b != nil {
+				return nil, fmt.Errorf("line %d: preset must come before any binding", lineNumber)
+			}
+			preset, err := NewBindings(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			b = preset
+			continue
+		}
+		if b == nil {
+			b = DefaultBindings()
+		}
+
+		e, err := ParseEvent(action)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		var keyNames []string
+		for _, k := range strings.Split(value, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keyNames = append(keyNames, k)
+			}
+		}
+		if len(keyNames) == 0 {
+			return nil, fmt.Errorf("line %d: no keys given for %q", lineNumber, action)
+		}
+		b.Bind(e, keyNames...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = DefaultBindings()
+	}
+	return b, nil
+}
@@ -0,0 +1,194 @@
+package event
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewBindings_Presets(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		key       string
+		wantEvent Event
+	}{
+		{name: "default arrow", preset: "default", key: "up", wantEvent: Up},
+		{name: "default page", preset: "default", key: "j", wantEvent: PageLeft},
+		{name: "vi move", preset: "vi", key: "j", wantEvent: Down},
+		{name: "vi page", preset: "vi", key: "L", wantEvent: PageRight},
+		{name: "vi help", preset: "vi", key: "?", wantEvent: Help},
+		{name: "wasd move", preset: "wasd", key: "a", wantEvent: Left},
+		{name: "wasd page", preset: "wasd", key: "W", wantEvent: PageUp},
+		{name: "wasd ctrl+c", preset: "wasd", key: "ctrl+c", wantEvent: Stop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBindings(tt.preset)
+			if err != nil {
+				t.Fatalf("NewBindings(%q) unexpected error: %v", tt.preset, err)
+			}
+			if got, _ := b.lookup(tt.key); got != tt.wantEvent {
+				t.Errorf("lookup(%q) = %v, want %v", tt.key, got, tt.wantEvent)
+			}
+		})
+	}
+
+	if _, err := NewBindings("emacs"); err == nil {
+		t.Error("NewBindings() expected error for unknown preset")
+	}
+}
+
+func TestBindings_Bind(t *testing.T) {
+	b := DefaultBindings()
+
+	b.Bind(Pause, "p", "space")
+	if got := b.Keys(Pause); !reflect.DeepEqual(got, []string{"p", "space"}) {
+		t.Errorf("Keys(Pause) = %v, want [p space]", got)
+	}
+
+	// Moving a key from one event to another unbinds it from the first
+	b.Bind(Help, "q")
+	if got, _ := b.lookup("q"); got != Help {
+		t.Errorf("lookup(q) = %v, want %v", got, Help)
+	}
+	if got := b.Keys(Stop); !reflect.DeepEqual(got, []string{"ctrl+c"}) {
+		t.Errorf("Keys(Stop) = %v, want [ctrl+c]", got)
+	}
+
+	// ctrl+c can never be taken away from Stop
+	b.Bind(Pause, "ctrl+c")
+	if got, stop := b.lookup("ctrl+c"); got != Stop || !stop {
+		t.Errorf("lookup(ctrl+c) = %v, %v, want %v, true", got, stop, Stop)
+	}
+	b.Bind(Stop, "x")
+	if got := b.Keys(Stop); !reflect.DeepEqual(got, []string{"x", "ctrl+c"}) {
+		t.Errorf("Keys(Stop) = %v, want [x ctrl+c]", got)
+	}
+}
+
+func TestParseBindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		wantKeys map[Event][]string
+		wantErr  string
+	}{
+		{
+			name:   "empty file",
+			config: "",
+			wantKeys: map[Event][]string{
+				Up:   {"up"},
+				Stop: {"q", "ctrl+c"},
+			},
+		},
+		{
+			name:   "preset with overrides",
+			config: "# my keys\npreset: vi\n\npause: p, space\nhelp: F1\n",
+			wantKeys: map[Event][]string{
+				Down:  {"j", "down"},
+				Pause: {"p", "space"},
+				Help:  {"F1"},
+			},
+		},
+		{
+			name:   "overrides without preset",
+			config: "page-up: pgup\n",
+			wantKeys: map[Event][]string{
+				PageUp:   {"pgup"},
+				PageDown: {"k"},
+			},
+		},
+		{
+			name:    "unknown action",
+			config:  "jump: x\n",
+			wantErr: `line 1: unknown action "jump"`,
+		},
+		{
+			name:    "missing separator",
+			config:  "preset: wasd\nup w\n",
+			wantErr: "line 2: expected",
+		},
+		{
+			name:    "late preset",
+			config:  "up: w\npreset: vi\n",
+			wantErr: "line 2: preset must come before any binding",
+		},
+		{
+			name:    "unknown preset",
+			config:  "preset: emacs\n",
+			wantErr: `line 1: unknown key binding preset "emacs"`,
+		},
+		{
+			name:    "no keys",
+			config:  "quit: ,\n",
+			wantErr: `line 1: no keys given for "quit"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ParseBindings(strings.NewReader(tt.config))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseBindings() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBindings() unexpected error: %v", err)
+			}
+			for e, want := range tt.wantKeys {
+				if got := b.Keys(e); !reflect.DeepEqual(got, want) {
+					t.Errorf("Keys(%v) = %v, want %v", e, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	dir := t.TempDir()
+
+	b, err := LoadBindings(filepath.Join(dir, "missing.conf"))
+	if err != nil {
+		t.Fatalf("LoadBindings() on missing file unexpected error: %v", err)
+	}
+	if got, _ := b.lookup("i"); got != PageUp {
+		t.Errorf("missing file should give default bindings, lookup(i) = %v", got)
+	}
+
+	path := filepath.Join(dir, "keys.conf")
+	if err := os.WriteFile(path, []byte("preset: wasd\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	b, err = LoadBindings(path)
+	if err != nil {
+		t.Fatalf("LoadBindings() unexpected error: %v", err)
+	}
+	if got, _ := b.lookup("w"); got != Up {
+		t.Errorf("lookup(w) = %v, want %v", got, Up)
+	}
+
+	if err := os.WriteFile(path, []byte("bogus\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err = LoadBindings(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadBindings() error = %v, want it to name the file", err)
+	}
+}
+
+func TestEventString(t *testing.T) {
+	for _, e := range DefaultBindings().Events() {
+		parsed, err := ParseEvent(e.String())
+		if err != nil || parsed != e {
+			t.Errorf("ParseEvent(%q) = %v, %v, want %v", e.String(), parsed, err, e)
+		}
+	}
+	if None.String() != "none" {
+		t.Errorf("None.String() = %q, want \"none\"", None.String())
+	}
+}
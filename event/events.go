@@ -17,6 +17,11 @@ const (
 	Help                   // Display help information
 	Stop                   // Stop the simulation and exit
 	Pause                  // Toggle pause state
+	Edit                   // Toggle the cell at the center of the view window
+	Save                   // Save the session
+	Soup                   // Replace the world with a new random soup
+	Objects                // Show or hide the labels of the objects
+	Charts                 // Show or hide the charts of the population and activity
+	Heatmap                // Show or hide the heatmap of where cells changed
 	None                   // No event (default/empty state)
 )
-
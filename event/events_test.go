@@ -10,7 +10,7 @@ func TestEventConstants(t *testing.T) {
 	events := []Event{
 		Up, Down, Left, Right,
 		PageUp, PageDown, PageLeft, PageRight,
-		Help, Stop, Pause, None,
+		Help, Stop, Pause, Edit, Save, Soup, Objects, Charts, Heatmap, None,
 	}
 
 	seen := make(map[Event]bool)
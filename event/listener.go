@@ -20,57 +20,26 @@ type Listener interface {
 	Stop()
 }
 
-// NewListener creates a new keyboard event listener.
+// NewListener creates a new keyboard event listener using the default key bindings.
 func NewListener() Listener {
-	return &gameListener{}
+	return NewListenerWithBindings(DefaultBindings())
+}
+
+// NewListenerWithBindings creates a new keyboard event listener using the given key bindings.
+func NewListenerWithBindings(bindings *Bindings) Listener {
+	return &gameListener{bindings: bindings}
 }
 
 // gameListener implements the Listener interface using atomicgo/keyboard.
 type gameListener struct {
-	queue   chan Event
-	running bool
+	queue    chan Event
+	running  bool
+	bindings *Bindings
 }
 
 // mapKeyToEvent maps a keyboard key to an Event and returns whether to stop listening.
-func mapKeyToEvent(k keys.Key) (event Event, stop bool) {
-	switch k.Code {
-	case keys.Up:
-		return Up, false
-	case keys.Down:
-		return Down, false
-	case keys.Left:
-		return Left, false
-	case keys.Right:
-		return Right, false
-	case keys.CtrlC:
-		return Stop, true
-	case keys.Space:
-		return Pause, false
-	case keys.RuneKey:
-		return mapRuneKeyToEvent(k.String())
-	default:
-		return None, false
-	}
-}
-
-// mapRuneKeyToEvent maps a rune key string to an Event and returns whether to stop listening.
-func mapRuneKeyToEvent(key string) (event Event, stop bool) {
-	switch key {
-	case "q":
-		return Stop, true
-	case "i":
-		return PageUp, false
-	case "k":
-		return PageDown, false
-	case "j":
-		return PageLeft, false
-	case "l":
-		return PageRight, false
-	case "h":
-		return Help, false
-	default:
-		return None, false
-	}
+func (b *Bindings) mapKeyToEvent(k keys.Key) (event Event, stop bool) {
+	return b.lookup(k.String())
 }
 
 // startSignalHandler starts a goroutine to handle OS signals.
@@ -99,7 +68,7 @@ func (gl *gameListener) Start() {
 	gl.startSignalHandler(sigs)
 
 	go keyboard.Listen(func(k keys.Key) (bool, error) {
-		event, stop := mapKeyToEvent(k)
+		event, stop := gl.bindings.mapKeyToEvent(k)
 		if event != None {
 			gl.queue <- event
 		}
@@ -120,7 +120,7 @@ func TestMapKeyToEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotEvent, gotStop := mapKeyToEvent(tt.key)
+			gotEvent, gotStop := DefaultBindings().mapKeyToEvent(tt.key)
 			if gotEvent != tt.wantEvent {
 				t.Errorf("mapKeyToEvent() event = %v, want %v", gotEvent, tt.wantEvent)
 			}
@@ -131,7 +131,7 @@ func TestMapKeyToEvent(t *testing.T) {
 	}
 }
 
-func TestMapRuneKeyToEvent(t *testing.T) {
+func TestBindingsLookup(t *testing.T) {
 	tests := []struct {
 		name      string
 		key       string
@@ -184,19 +184,19 @@ func TestMapRuneKeyToEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotEvent, gotStop := mapRuneKeyToEvent(tt.key)
+			gotEvent, gotStop := DefaultBindings().lookup(tt.key)
 			if gotEvent != tt.wantEvent {
-				t.Errorf("mapRuneKeyToEvent() event = %v, want %v", gotEvent, tt.wantEvent)
+				t.Errorf("lookup() event = %v, want %v", gotEvent, tt.wantEvent)
 			}
 			if gotStop != tt.wantStop {
-				t.Errorf("mapRuneKeyToEvent() stop = %v, want %v", gotStop, tt.wantStop)
+				t.Errorf("lookup() stop = %v, want %v", gotStop, tt.wantStop)
 			}
 		})
 	}
 }
 
 func TestMapKeyToEvent_RuneKey(t *testing.T) {
-	// Test that RuneKey properly delegates to mapRuneKeyToEvent
+	// Test that RuneKey is looked up by its rune
 	tests := []struct {
 		name      string
 		runes     []rune
@@ -220,7 +220,7 @@ func TestMapKeyToEvent_RuneKey(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			key := keys.Key{Code: keys.RuneKey, Runes: tt.runes}
-			gotEvent, gotStop := mapKeyToEvent(key)
+			gotEvent, gotStop := DefaultBindings().mapKeyToEvent(key)
 			if gotEvent != tt.wantEvent {
 				t.Errorf("mapKeyToEvent() with RuneKey event = %v, want %v", gotEvent, tt.wantEvent)
 			}
@@ -0,0 +1,40 @@
+package event
+
+// mergedListener combines several listeners into one, taking turns so that a
+// busy listener cannot starve the others.
+type mergedListener struct {
+	listeners []Listener
+	next      int
+}
+
+// Merge creates a listener that returns the events of all the given listeners.
+func Merge(listeners ...Listener) Listener {
+	return &mergedListener{listeners: listeners}
+}
+
+// Start starts all the merged listeners.
+func (ml *mergedListener) Start() {
+	for _, l := range ml.listeners {
+		l.Start()
+	}
+}
+
+// Check returns the next event from the first listener that has one, starting
+// after the listener that returned the previous event.
+func (ml *mergedListener) Check() Event {
+	for i := range ml.listeners {
+		current := (ml.next + i) % len(ml.listeners)
+		if e := ml.listeners[current].Check(); e != None {
+			ml.next = current + 1
+			return e
+		}
+	}
+	return None
+}
+
+// Stop stops all the merged listeners.
+func (ml *mergedListener) Stop() {
+	for _, l := range ml.listeners {
+		l.Stop()
+	}
+}
@@ -0,0 +1,32 @@
+package event
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	first := &scriptedListener{events: []Event{Up, Up, Up}}
+	second := &scriptedListener{events: []Event{None, Down, Pause}}
+
+	l := Merge(first, second)
+	l.Start()
+
+	want := []Event{Up, Up, Down, Up, Pause, None}
+	for i, w := range want {
+		if got := l.Check(); got != w {
+			t.Errorf("Check() %d = %v, want %v", i, got, w)
+		}
+	}
+
+	l.Stop()
+	if !first.stopped || !second.stopped {
+		t.Error("Stop() should stop every merged listener")
+	}
+}
+
+func TestMerge_Empty(t *testing.T) {
+	l := Merge()
+	l.Start()
+	if got := l.Check(); got != None {
+		t.Errorf("Check() = %v, want None", got)
+	}
+	l.Stop()
+}
@@ -0,0 +1,193 @@
+package event
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recordingHeader starts every recording file and is followed by the recording label.
+const recordingHeader = "# life recording:"
+
+// Recorder is a Listener that passes through the events of another listener,
+// writing each one to a recording with the time and generation it happened at.
+//
+// Each line of a recording holds "<milliseconds> <generation> <event>", where
+// milliseconds counts from Start and event is the event name (see Event.String).
+type Recorder struct {
+	inner      Listener
+	out        *bufio.Writer
+	generation func() int64
+	label      string
+	start      time.Time
+	err        error
+}
+
+// NewRecorder creates a listener that records the events of inner to out.
+// The generation function reports the current world generation, and the label
+// (usually the name of the pattern) is written to the recording header.
+func NewRecorder(inner Listener, out io.Writer, generation func() int64, label string) *Recorder {
+	return &Recorder{
+		inner:      inner,
+		out:        bufio.NewWriter(out),
+		generation: generation,
+		label:      label,
+	}
+}
+
+// Start starts the wrapped listener and writes the recording header.
+func (r *Recorder) Start() {
+	r.start = time.Now()
+	r.write("%s %s\n", recordingHeader, r.label)
+	r.inner.Start()
+}
+
+// Check returns the next event of the wrapped listener, recording it.
+func (r *Recorder) Check() Event {
+	e := r.inner.Check()
+	if e != None {
+		r.write("%d %d %s\n", time.Since(r.start).Milliseconds(), r.generation(), e)
+	}
+	return e
+}
+
+// Stop stops the wrapped listener and flushes the recording.
+func (r *Recorder) Stop() {
+	r.inner.Stop()
+	if err := r.out.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error found while writing the recording.
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// write appends a line to the recording, keeping the first error.
+func (r *Recorder) write(format string, args ...interface{}) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintf(r.out, format, args...)
+}
+
+// recordedEvent is one line of a recording.
+type recordedEvent struct {
+	elapsed    time.Duration
+	generation int64
+	event      Event
+}
+
+// Replayer is a Listener that plays back a recording. Each event is returned
+// once the world reaches the generation it was recorded at. Events recorded at
+// the same generation as the previous one (which happens only while the game
+// is paused) also wait for the time that passed between them, so pauses and
+// viewport moves during pauses are replayed at their original pace.
+type Replayer struct {
+	events     []recordedEvent
+	next       int
+	generation func() int64
+	label      string
+	running    bool
+	lastFired  time.Time
+}
+
+// NewReplayer reads a recording written by a Recorder. The generation function
+// reports the current world generation.
+func NewReplayer(in io.Reader, generation func() int64) (*Replayer, error) {
+	r := &Replayer{generation: generation}
+	lineNumber := 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, recordingHeader) {
+			r.label = strings.TrimSpace(strings.TrimPrefix(line, recordingHeader))
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := parseRecordedEvent(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		r.events = append(r.events, re)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// parseRecordedEvent parses a "<milliseconds> <generation> <event>" line.
+func parseRecordedEvent(line string) (recordedEvent, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return recordedEvent{}, fmt.Errorf("expected \"<milliseconds> <generation> <event>\", got %q", line)
+	}
+	ms, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return recordedEvent{}, fmt.Errorf("invalid time %q", fields[0])
+	}
+	generation, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return recordedEvent{}, fmt.Errorf("invalid generation %q", fields[1])
+	}
+	e, err := ParseEvent(fields[2])
+	if err != nil {
+		return recordedEvent{}, err
+	}
+	return recordedEvent{
+		elapsed:    time.Duration(ms) * time.Millisecond,
+		generation: generation,
+		event:      e,
+	}, nil
+}
+
+// Label returns the label written in the recording header.
+func (r *Replayer) Label() string {
+	return r.label
+}
+
+// Start begins the playback.
+func (r *Replayer) Start() {
+	if r.running {
+		return
+	}
+	r.running = true
+	r.lastFired = time.Now()
+}
+
+// Check returns the next recorded event if the world has reached its
+// generation, or None otherwise.
+func (r *Replayer) Check() Event {
+	if !r.running || r.next >= len(r.events) {
+		return None
+	}
+	re := r.events[r.next]
+	if r.generation() < re.generation {
+		return None
+	}
+	if r.next > 0 {
+		previous := r.events[r.next-1]
+		if previous.generation == re.generation && time.Since(r.lastFired) < re.elapsed-previous.elapsed {
+			return None
+		}
+	}
+	r.next++
+	r.lastFired = time.Now()
+	if re.event == Stop {
+		r.running = false
+	}
+	return re.event
+}
+
+// Stop ends the playback.
+func (r *Replayer) Stop() {
+	r.running = false
+}
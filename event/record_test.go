@@ -0,0 +1,179 @@
+package event
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedListener returns one scripted event per Check call.
+type scriptedListener struct {
+	events  []Event
+	stopped bool
+}
+
+func (sl *scriptedListener) Start() {}
+
+func (sl *scriptedListener) Check() Event {
+	if len(sl.events) == 0 {
+		return None
+	}
+	e := sl.events[0]
+	sl.events = sl.events[1:]
+	return e
+}
+
+func (sl *scriptedListener) Stop() {
+	sl.stopped = true
+}
+
+func TestRecorder(t *testing.T) {
+	inner := &scriptedListener{events: []Event{Up, None, Pause, PageLeft, Stop}}
+	generation := int64(0)
+	out := &bytes.Buffer{}
+
+	r := NewRecorder(inner, out, func() int64 { return generation }, "gliders")
+	r.Start()
+	var got []Event
+	for i := 0; i < 5; i++ {
+		generation++
+		got = append(got, r.Check())
+	}
+	r.Stop()
+
+	if !inner.stopped {
+		t.Error("Stop() should stop the wrapped listener")
+	}
+	if r.Err() != nil {
+		t.Errorf("Err() = %v, want nil", r.Err())
+	}
+	want := []Event{Up, None, Pause, PageLeft, Stop}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Check() %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	wantLines := []string{"# life recording: gliders", " 1 up", " 3 pause", " 4 page-left", " 5 quit"}
+	if len(lines) != len(wantLines) {
+		t.Fatalf("recording has %d lines, want %d:\n%s", len(lines), len(wantLines), out.String())
+	}
+	if lines[0] != wantLines[0] {
+		t.Errorf("header = %q, want %q", lines[0], wantLines[0])
+	}
+	for i := 1; i < len(wantLines); i++ {
+		if !strings.HasSuffix(lines[i], wantLines[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], wantLines[i])
+		}
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	recording := "# life recording: gun\n" +
+		"200 2 right\n" +
+		"400 3 pause\n" +
+		"450 3 down\n" +
+		"900 3 pause\n" +
+		"1100 5 quit\n"
+	generation := int64(0)
+
+	r, err := NewReplayer(strings.NewReader(recording), func() int64 { return generation })
+	if err != nil {
+		t.Fatalf("NewReplayer() unexpected error: %v", err)
+	}
+	if r.Label() != "gun" {
+		t.Errorf("Label() = %q, want \"gun\"", r.Label())
+	}
+	if e := r.Check(); e != None {
+		t.Errorf("Check() before Start = %v, want None", e)
+	}
+
+	r.Start()
+	generation = 1
+	if e := r.Check(); e != None {
+		t.Errorf("Check() at generation 1 = %v, want None", e)
+	}
+	generation = 2
+	if e := r.Check(); e != Right {
+		t.Errorf("Check() at generation 2 = %v, want %v", e, Right)
+	}
+	generation = 3
+	if e := r.Check(); e != Pause {
+		t.Errorf("Check() at generation 3 = %v, want %v", e, Pause)
+	}
+
+	// The next events happened while paused, 50ms and 450ms later
+	if e := r.Check(); e != None {
+		t.Errorf("Check() right after pause = %v, want None", e)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if e := r.Check(); e != Down {
+		t.Errorf("Check() 60ms after pause = %v, want %v", e, Down)
+	}
+	if e := r.Check(); e != None {
+		t.Errorf("Check() before resume time = %v, want None", e)
+	}
+	time.Sleep(460 * time.Millisecond)
+	if e := r.Check(); e != Pause {
+		t.Errorf("Check() at resume time = %v, want %v", e, Pause)
+	}
+
+	generation = 5
+	if e := r.Check(); e != Stop {
+		t.Errorf("Check() at generation 5 = %v, want %v", e, Stop)
+	}
+	if e := r.Check(); e != None {
+		t.Errorf("Check() after Stop = %v, want None", e)
+	}
+}
+
+func TestNewReplayer_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		recording string
+		wantErr   string
+	}{
+		{name: "missing field", recording: "100 2\n", wantErr: "line 1: expected"},
+		{name: "bad time", recording: "# life recording: x\nsoon 2 up\n", wantErr: `line 2: invalid time "soon"`},
+		{name: "bad generation", recording: "100 two up\n", wantErr: `line 1: invalid generation "two"`},
+		{name: "bad event", recording: "100 2 jump\n", wantErr: `line 1: unknown action "jump"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReplayer(strings.NewReader(tt.recording), func() int64 { return 0 })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewReplayer() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	events := []Event{Left, Left, PageDown, Help, Stop}
+	generation := int64(0)
+	out := &bytes.Buffer{}
+
+	r := NewRecorder(&scriptedListener{events: events}, out, func() int64 { return generation }, "")
+	r.Start()
+	for range events {
+		generation++
+		r.Check()
+	}
+	r.Stop()
+
+	generation = 0
+	replayer, err := NewReplayer(out, func() int64 { return generation })
+	if err != nil {
+		t.Fatalf("NewReplayer() unexpected error: %v", err)
+	}
+	replayer.Start()
+	for i, want := range events {
+		generation++
+		if got := replayer.Check(); got != want {
+			t.Errorf("replayed event %d = %v, want %v", i, got, want)
+		}
+	}
+}
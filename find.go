@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/model"
+)
+
+// runFind implements the "find" subcommand: it looks for a pattern in a world,
+// in every orientation, and lists where it occurs.
+func runFind(args []string) error {
+	flags := flag.NewFlagSet("find", flag.ContinueOnError)
+	phases := flags.Bool("phases", false, "also look for the other phases of an oscillator or spaceship")
+	margin := flags.Int("margin", analysis.DefaultMargin, "number of dead cells required around each match")
+	generation := flags.Int64("generation", 0, "run the world to `generation` before looking")
+	rule := flags.String("rule", "", "run the world and the pattern with `rule`, such as B36/S23")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life find [-phases] [-margin n] [-generation n] [-rule rule] <pattern> <sample>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("find needs a pattern and a sample")
+	}
+
+	pattern, err := model.ReadWorld(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("reading pattern: %w", err)
+	}
+	w, err := model.ReadWorld(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("reading sample: %w", err)
+	}
+	if *rule != "" {
+		if pattern, err = model.WithRule(pattern, *rule); err != nil {
+			return err
+		}
+		if w, err = model.WithRule(w, *rule); err != nil {
+			return err
+		}
+	}
+	query, err := analysis.NewQuery(pattern, *phases)
+	if err != nil {
+		return err
+	}
+	for w.Turn() < *generation {
+		w.Evolve()
+	}
+
+	matches := query.Find(w, *margin)
+	for _, m := range matches {
+		fmt.Printf("%d %d orientation %d phase %d\n", m.X, m.Y, m.Orientation, m.Phase)
+	}
+	fmt.Printf("%d matches of %s in %s at generation %d\n", len(matches), flags.Arg(0), flags.Arg(1), w.Turn())
+	return nil
+}
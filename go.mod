@@ -1,8 +1,13 @@
 module github.com/daniel-munoz/life
 
-go 1.16
+go 1.22
 
 require (
 	atomicgo.dev/cursor v0.2.0
 	atomicgo.dev/keyboard v0.2.9
 )
+
+require (
+	github.com/containerd/console v1.0.3 // indirect
+	golang.org/x/sys v0.0.0-20220319134239-a9b59b0215f8 // indirect
+)
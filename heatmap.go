@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Defaults of the "heatmap" subcommand.
+const (
+	defaultHeatmapGenerations = 1000
+	defaultHeatmapScale       = 4
+)
+
+// runHeatmap implements the "heatmap" subcommand: it runs a sample without a
+// display and draws where its cells were active as a PNG image.
+func runHeatmap(args []string) error {
+	flags := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	generations := flags.Int64("generations", defaultHeatmapGenerations, "number of generations to run")
+	rule := flags.String("rule", "", "run the pattern with `rule`, such as B36/S23")
+	count := flags.String("count", "changes", "what to draw: changes (how often each cell changed) or alive (how long it lived)")
+	scale := flags.Int("scale", defaultHeatmapScale, "size of each cell in pixels")
+	output := flags.String("o", "heatmap.png", "write the image to `file`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life heatmap [-generations n] [-count changes|alive] [-scale n] [-rule rule] [-o file] <sample>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("heatmap needs a sample")
+	}
+	heatCount, err := model.ParseHeatCount(*count)
+	if err != nil {
+		return err
+	}
+
+	w, err := model.ReadWorld(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *rule != "" {
+		if w, err = model.WithRule(w, *rule); err != nil {
+			return err
+		}
+	}
+	if reporter, ok := w.(types.ActivityReporter); ok {
+		reporter.TrackActivity(true)
+	}
+	for i := int64(0); i < *generations; i++ {
+		w.Evolve()
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := model.WriteHeatmapPNG(w, heatCount, *scale, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Heatmap of %d generations written to %s\n", *generations, *output)
+	return nil
+}
@@ -4,12 +4,16 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/control"
+	"github.com/daniel-munoz/life/event"
 	"github.com/daniel-munoz/life/model"
 	"github.com/daniel-munoz/life/types"
 	"github.com/daniel-munoz/life/ui"
@@ -23,21 +27,48 @@ const (
 	defaultViewRight  = 80
 )
 
+// subcommands holds the commands that run instead of the default terminal
+// viewer, by name.
+var subcommands = map[string]func(args []string) error{
+	"serve":   runServe,
+	"share":   runShare,
+	"join":    runJoin,
+	"resume":  runResume,
+	"soup":    runSoup,
+	"census":  runCensus,
+	"find":    runFind,
+	"stats":   runStats,
+	"heatmap": runHeatmap,
+	"bench":   runBench,
+}
+
+// loadBindings reads the key bindings from the user config directory, falling
+// back to the default bindings when the directory cannot be determined.
+func loadBindings() (*event.Bindings, error) {
+	path, err := event.DefaultBindingsPath()
+	if err != nil {
+		return event.DefaultBindings(), nil
+	}
+	return event.LoadBindings(path)
+}
+
 // listSamples lists all available samples in the samples directory
 func listSamples() ([]string, error) {
 	var samples []string
 
-	// Get all .life files from samples directory
-	files, err := filepath.Glob("./samples/*.life")
-	if err != nil {
-		return nil, err
-	}
+	// Get all pattern files from samples directory
+	for _, extension := range model.SampleExtensions {
+		files, err := filepath.Glob("./samples/*" + extension)
+		if err != nil {
+			return nil, err
+		}
 
-	// Extract sample names without extension
-	for _, file := range files {
-		base := filepath.Base(file)
-		sampleName := strings.TrimSuffix(base, ".life")
-		samples = append(samples, sampleName)
+		// Extract sample names without extension
+		for _, file := range files {
+			base := filepath.Base(file)
+			sampleName := strings.TrimSuffix(base, extension)
+			samples = append(samples, sampleName)
+		}
 	}
 
 	if len(samples) == 0 {
@@ -83,13 +114,47 @@ func promptSampleSelection() (string, error) {
 	return samples[num-1], nil
 }
 
+// readReplay loads the recording at path. The generation function reports the
+// generation of the world being replayed.
+func readReplay(path string, generation func() int64) (*event.Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return event.NewReplayer(f, generation)
+}
+
 func main() {
 	var (
 		w          types.World
 		err        error
 		sampleName string
+		game       *ui.Game
+		replayer   *event.Replayer
+		recorder   *event.Recorder
 	)
 
+	if len(os.Args) > 1 {
+		if command, found := subcommands[os.Args[1]]; found {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	recordPath := flag.String("record", "", "record the session's events to `file`")
+	replayPath := flag.String("replay", "", "replay the events recorded in `file` instead of reading the keyboard")
+	controlPath := flag.String("control", "", "accept commands on the Unix socket at `path`")
+	sessionPath := flag.String("session", defaultSessionPath, "save the session to `file` with the save key")
+	autosave := flag.Bool("autosave", false, "save the session on exit")
+	rule := flag.String("rule", "", "run the pattern with `rule`, such as B36/S23, B2/S/C3 or WireWorld")
+	highlight := flag.String("highlight", "", "highlight the occurrences of the `pattern`, a sample name or RLE file, in every orientation and phase")
+	engineName := flag.String("engine", string(model.QuadtreeEngine), "run macrocell patterns with the `engine`: quadtree or map")
+	flag.Parse()
+
 	// check if reading from a pipe, which does not work now
 	inStat, _ := os.Stdin.Stat()
 	if (inStat.Mode() & os.ModeCharDevice) != os.ModeCharDevice {
@@ -97,9 +162,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(os.Args) > 1 {
+	if *replayPath != "" {
+		replayer, err = readReplay(*replayPath, func() int64 { return game.World().Turn() })
+		if err != nil {
+			fmt.Printf("Error reading recording: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if flag.NArg() > 0 {
 		// Use command line argument if provided
-		sampleName = os.Args[1]
+		sampleName = flag.Arg(0)
+	} else if replayer != nil && replayer.Label() != "" {
+		// Replay against the sample the recording was made with
+		sampleName = replayer.Label()
 	} else {
 		// Otherwise prompt user to select a sample
 		sampleName, err = promptSampleSelection()
@@ -109,12 +185,84 @@ func main() {
 		}
 	}
 
+	engine, err := model.ParseEngine(*engineName)
+	if err != nil {
+		fmt.Printf("Error selecting engine: %s\n", err.Error())
+		os.Exit(1)
+	}
+
 	fmt.Printf("Loading sample: %s\n", sampleName)
-	w, err = model.ReadWorld(sampleName)
+	w, err = model.ReadWorldWithEngine(sampleName, engine)
 	if err != nil {
 		fmt.Printf("Error reading sample: %s\n", err.Error())
 		os.Exit(1)
 	}
+	if *rule != "" {
+		w, err = model.WithRule(w, *rule)
+		if err != nil {
+			fmt.Printf("Error setting rule: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	bindings, err := loadBindings()
+	if err != nil {
+		fmt.Printf("Error reading key bindings: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	game = ui.NewGame(w, defaultViewTop, defaultViewLeft, defaultViewBottom, defaultViewRight)
+	game.SetSessionPath(*sessionPath)
+	if *highlight != "" {
+		pattern, err := model.ReadWorld(*highlight)
+		if err == nil && *rule != "" {
+			pattern, err = model.WithRule(pattern, *rule)
+		}
+		if err != nil {
+			fmt.Printf("Error reading pattern: %s\n", err.Error())
+			os.Exit(1)
+		}
+		query, err := analysis.NewQuery(pattern, true)
+		if err != nil {
+			fmt.Printf("Error reading pattern: %s\n", err.Error())
+			os.Exit(1)
+		}
+		game.SetQuery(query)
+	}
+
+	var listener event.Listener = event.NewListenerWithBindings(bindings)
+	if replayer != nil {
+		listener = replayer
+	}
+	if *controlPath != "" {
+		server, err := control.Listen(*controlPath, game)
+		if err != nil {
+			fmt.Printf("Error opening control socket: %s\n", err.Error())
+			os.Exit(1)
+		}
+		listener = event.Merge(listener, server)
+	}
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			fmt.Printf("Error creating recording: %s\n", err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		recorder = event.NewRecorder(listener, f, func() int64 { return game.World().Turn() }, sampleName)
+		listener = recorder
+	}
+
+	ui.Show(game, listener, bindings)
 
-	ui.Show(w, defaultViewTop, defaultViewLeft, defaultViewBottom, defaultViewRight)
+	if recorder != nil && recorder.Err() != nil {
+		fmt.Printf("Error writing recording: %s\n", recorder.Err().Error())
+	}
+	if *autosave {
+		if err := game.SaveSession(*sessionPath); err != nil {
+			fmt.Printf("Error saving session: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Session saved to %s, continue with: life resume %s\n", *sessionPath, *sessionPath)
+	}
 }
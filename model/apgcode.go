@@ -0,0 +1,159 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// wechslerDigits are the characters of the extended Wechsler format: a digit
+// stands for a column of a strip, with a bit for each of its five cells, and
+// the number of zero columns after "y", less 4.
+const wechslerDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// Extended Wechsler format limits.
+const (
+	// wechslerStrip is the height of the strips a pattern is cut into.
+	wechslerStrip = 5
+	// maxZeroRun is the longest run of zero columns written as one "y".
+	maxZeroRun = 4 + len(wechslerDigits) - 1
+)
+
+// EncodeWechsler returns the cells in the extended Wechsler format of
+// apgcodes, from the top left corner of their bounding box. The pattern is cut
+// into strips 5 cells high, separated by "z"; each column of a strip is a
+// character whose value has bit i set for a living cell in row i of the strip,
+// zero columns at the end of a strip are left out, and runs of zero columns
+// are written "w" for two, "x" for three and "y" followed by a character
+// for 4 to 39. A pattern without cells is "0".
+func EncodeWechsler(cells [][2]int64) string {
+	if len(cells) == 0 {
+		return "0"
+	}
+	left, top := cells[0][0], cells[0][1]
+	right, bottom := left, top
+	for _, c := range cells {
+		left, right = min(left, c[0]), max(right, c[0])
+		top, bottom = min(top, c[1]), max(bottom, c[1])
+	}
+	width := right - left + 1
+	strips := make([][]int, (bottom-top)/wechslerStrip+1)
+	for i := range strips {
+		strips[i] = make([]int, width)
+	}
+	for _, c := range cells {
+		row := c[1] - top
+		strips[row/wechslerStrip][c[0]-left] |= 1 << (row % wechslerStrip)
+	}
+
+	buffer := &strings.Builder{}
+	for i, columns := range strips {
+		if i > 0 {
+			buffer.WriteByte('z')
+		}
+		zeros := 0
+		for _, column := range columns {
+			if column == 0 {
+				zeros++
+				continue
+			}
+			writeZeros(buffer, zeros)
+			zeros = 0
+			buffer.WriteByte(wechslerDigits[column])
+		}
+	}
+	return buffer.String()
+}
+
+// writeZeros writes a run of zero columns in the extended Wechsler format.
+func writeZeros(buffer *strings.Builder, zeros int) {
+	for ; zeros >= 4; zeros -= min(zeros, maxZeroRun) {
+		buffer.WriteByte('y')
+		buffer.WriteByte(wechslerDigits[min(zeros, maxZeroRun)-4])
+	}
+	buffer.WriteString([]string{"", "0", "w", "x"}[zeros])
+}
+
+// DecodeApgcode returns the living cells of an apgcode, such as "xq4_153" for
+// the glider, with the top left corner of the strips at (0,0). The prefix
+// before the "_" is the class and size of the object: "xs" and the population
+// of a still life, "xp" and the period of an oscillator, "xq" and the period
+// of a spaceship, or "zz" for other patterns; the rest is the pattern in the
+// extended Wechsler format, as EncodeWechsler writes it.
+func DecodeApgcode(code string) ([][2]int64, error) {
+	prefix, wechsler, found := strings.Cut(code, "_")
+	if !found || !isApgcodePrefix(prefix) {
+		return nil, fmt.Errorf("invalid apgcode %q (want a prefix such as xs4, xp2 or xq4, then _ and the pattern)", code)
+	}
+	if wechsler == "" {
+		return nil, fmt.Errorf("invalid apgcode %q: missing pattern", code)
+	}
+	var (
+		cells [][2]int64
+		x, y  int64
+	)
+	for i := 0; i < len(wechsler); i++ {
+		switch c := wechsler[i]; {
+		case c == 'z':
+			x, y = 0, y+wechslerStrip
+		case c == 'w':
+			x += 2
+		case c == 'x':
+			x += 3
+		case c == 'y':
+			if i+1 == len(wechsler) || strings.IndexByte(wechslerDigits, wechsler[i+1]) < 0 {
+				return nil, fmt.Errorf("invalid apgcode %q: missing run length after y", code)
+			}
+			i++
+			x += 4 + int64(strings.IndexByte(wechslerDigits, wechsler[i]))
+		default:
+			column := strings.IndexByte(wechslerDigits[:1<<wechslerStrip], c)
+			if column < 0 {
+				return nil, fmt.Errorf("invalid apgcode %q: unexpected character %q", code, c)
+			}
+			for row := int64(0); row < wechslerStrip; row++ {
+				if column&(1<<row) != 0 {
+					cells = append(cells, [2]int64{x, y + row})
+				}
+			}
+			x++
+		}
+	}
+	return cells, nil
+}
+
+// isApgcodePrefix reports whether the prefix of an apgcode names a class of
+// object: "xs", "xp" or "xq" followed by a number, or "zz".
+func isApgcodePrefix(prefix string) bool {
+	if prefix == "zz" {
+		return true
+	}
+	if len(prefix) < 3 || (prefix[:2] != "xs" && prefix[:2] != "xp" && prefix[:2] != "xq") {
+		return false
+	}
+	_, err := strconv.ParseUint(prefix[2:], 10, 31)
+	return err == nil
+}
+
+// isApgcode reports whether the name looks like an apgcode rather than the
+// name of a sample.
+func isApgcode(name string) bool {
+	prefix, wechsler, found := strings.Cut(name, "_")
+	return found && isApgcodePrefix(prefix) && wechsler != "" &&
+		strings.Trim(wechsler, wechslerDigits) == ""
+}
+
+// ReadApgcode returns a world following Conway's rule with the cells of the
+// apgcode, as DecodeApgcode places them.
+func ReadApgcode(code string) (types.World, error) {
+	cells, err := DecodeApgcode(code)
+	if err != nil {
+		return nil, err
+	}
+	w := internal.NewWorld()
+	w.AddCells(cells, 0)
+	return w, nil
+}
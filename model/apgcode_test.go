@@ -0,0 +1,98 @@
+package model
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeWechsler(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells [][2]int64
+		want  string
+	}{
+		{name: "empty", want: "0"},
+		{name: "block", cells: [][2]int64{{5, 5}, {6, 5}, {5, 6}, {6, 6}}, want: "33"},
+		{name: "glider", cells: [][2]int64{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {1, 2}}, want: "153"},
+		{name: "two strips", cells: [][2]int64{{0, 0}, {0, 5}}, want: "1z1"},
+		{name: "empty strip", cells: [][2]int64{{0, 0}, {0, 10}}, want: "1zz1"},
+		{name: "two zeros", cells: [][2]int64{{0, 0}, {3, 0}}, want: "1w1"},
+		{name: "three zeros", cells: [][2]int64{{0, 0}, {4, 0}}, want: "1x1"},
+		{name: "four zeros", cells: [][2]int64{{0, 0}, {5, 0}}, want: "1y01"},
+		{name: "39 zeros", cells: [][2]int64{{0, 0}, {40, 0}}, want: "1yz1"},
+		{name: "41 zeros", cells: [][2]int64{{0, 0}, {42, 0}}, want: "1yzw1"},
+		{name: "trailing zeros", cells: [][2]int64{{0, 0}, {3, 5}}, want: "1zx1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeWechsler(tt.cells); got != tt.want {
+				t.Errorf("EncodeWechsler() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeApgcode(t *testing.T) {
+	for _, code := range []string{"xs4_33", "xq4_153", "xp2_7", "zz_1zz1", "xs2_1yz1", "xp3_co9nas0san9oczgoldlo0oldlogz1047210127401"} {
+		t.Run(code, func(t *testing.T) {
+			cells, err := DecodeApgcode(code)
+			if err != nil {
+				t.Fatalf("DecodeApgcode() unexpected error: %v", err)
+			}
+			_, wechsler, _ := strings.Cut(code, "_")
+			if got := EncodeWechsler(cells); got != wechsler {
+				t.Errorf("EncodeWechsler() of the decoded cells = %q, want %q", got, wechsler)
+			}
+		})
+	}
+
+	errors := []struct {
+		code    string
+		wantErr string
+	}{
+		{code: "153", wantErr: `invalid apgcode "153" (want a prefix such as xs4, xp2 or xq4, then _ and the pattern)`},
+		{code: "xr4_153", wantErr: `invalid apgcode "xr4_153" (want a prefix such as xs4, xp2 or xq4, then _ and the pattern)`},
+		{code: "xs_33", wantErr: `invalid apgcode "xs_33" (want a prefix such as xs4, xp2 or xq4, then _ and the pattern)`},
+		{code: "xs4_", wantErr: `invalid apgcode "xs4_": missing pattern`},
+		{code: "xs4_3w", wantErr: ""},
+		{code: "xs4_1y", wantErr: `invalid apgcode "xs4_1y": missing run length after y`},
+		{code: "xs4_3A", wantErr: `invalid apgcode "xs4_3A": unexpected character 'A'`},
+	}
+	for _, tt := range errors {
+		t.Run(tt.code, func(t *testing.T) {
+			_, err := DecodeApgcode(tt.code)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("DecodeApgcode() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadWorld_Apgcode(t *testing.T) {
+	// Apgcodes are read whether or not a samples directory is there
+	if err := os.MkdirAll("samples", 0755); err != nil {
+		t.Fatalf("Failed to create samples directory: %v", err)
+	}
+	defer os.RemoveAll("samples")
+
+	w, err := ReadWorld("xq4_153")
+	if err != nil {
+		t.Fatalf("ReadWorld() unexpected error: %v", err)
+	}
+	want := [][2]int64{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {1, 2}}
+	if got := cellsOf(w); !reflect.DeepEqual(got, sortedCells(want)) {
+		t.Errorf("ReadWorld() cells = %v, want %v", got, sortedCells(want))
+	}
+	if _, err := ReadWorld("xq4_"); err == nil {
+		t.Errorf("ReadWorld() of a missing sample expected error")
+	}
+}
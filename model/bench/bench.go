@@ -0,0 +1,244 @@
+// Package bench runs named workloads, such as a methuselah or a gun, against
+// the world engines and measures how fast they evolve, so that engines can be
+// compared and regressions caught.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Engine is a world implementation to run workloads against.
+type Engine struct {
+	Name string
+	// New creates an empty world following Conway's rule.
+	New func() types.World
+}
+
+// Engines are the world implementations that can run Conway's rule.
+var Engines = []Engine{
+	// The World engine, which counts the neighbors of the cells in a map
+	{Name: "map", New: func() types.World { return internal.NewWorld() }},
+	// The general AutomatonWorld engine, which evaluates the cells next to
+	// the ones that changed
+	{Name: "automaton", New: func() types.World { return internal.NewAutomatonWorld(internal.ConwayRule) }},
+	// The QuadWorld engine, which evolves every distinct node of a quadtree
+	// once
+	{Name: "quadtree", New: func() types.World { return internal.NewQuadWorld(internal.ConwayRule) }},
+}
+
+// Workload is a pattern run for a number of generations.
+type Workload struct {
+	Name        string
+	Description string
+	// Setup puts the starting cells into an empty world.
+	Setup func(w types.World) error
+	// Generations is the number of generations run.
+	Generations int64
+}
+
+// Workloads are the standard workloads.
+var Workloads = []Workload{
+	{
+		Name:        "r-pentomino",
+		Description: "the R-pentomino until it stabilizes, at generation 1103",
+		Setup:       pattern("x = 3, y = 3\nb2o$2o$bo!\n"),
+		Generations: 1103,
+	},
+	{
+		Name:        "gosper-gun",
+		Description: "the Gosper glider gun for 10000 generations",
+		Setup: pattern("x = 36, y = 9\n24bo$22bobo$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o$" +
+			"2o8bo3bob2o4bobo$10bo5bo7bo$11bo3bo$12b2o!\n"),
+		Generations: 10000,
+	},
+	{
+		Name:        "soup-512",
+		Description: "a random 512x512 soup for 100 generations",
+		Setup: func(w types.World) error {
+			return model.Soup{Seed: 1, Width: 512, Height: 512, Density: model.DefaultSoupDensity, Symmetry: model.C1}.Fill(w)
+		},
+		Generations: 100,
+	},
+}
+
+// pattern returns a Setup function copying the living cells of an RLE
+// pattern.
+func pattern(rle string) func(w types.World) error {
+	return func(w types.World) error {
+		p, err := model.ReadRLE(strings.NewReader(rle))
+		if err != nil {
+			return err
+		}
+		p.ForEachCell(func(x, y, turn int64) {
+			w.AddCellIn(x, y, 0)
+		})
+		return nil
+	}
+}
+
+// SelectWorkloads returns the workloads named in the comma separated list, in
+// the order given, or all of them for an empty list.
+func SelectWorkloads(names string) ([]Workload, error) {
+	return selectNamed("workload", Workloads, names, func(w Workload) string { return w.Name })
+}
+
+// SelectEngines returns the engines named in the comma separated list, in the
+// order given, or all of them for an empty list.
+func SelectEngines(names string) ([]Engine, error) {
+	return selectNamed("engine", Engines, names, func(e Engine) string { return e.Name })
+}
+
+// selectNamed returns the items named in the comma separated list, or all of
+// them for an empty list. The kind of item is used in errors.
+func selectNamed[T any](kind string, items []T, names string, name func(T) string) ([]T, error) {
+	if names == "" {
+		return items, nil
+	}
+	var selected []T
+	for _, n := range strings.Split(names, ",") {
+		found := false
+		for _, item := range items {
+			if name(item) == n {
+				selected = append(selected, item)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown %s %q", kind, n)
+		}
+	}
+	return selected, nil
+}
+
+// Result holds the measures of one workload run by one engine.
+type Result struct {
+	Workload    string `json:"workload"`
+	Engine      string `json:"engine"`
+	Generations int64  `json:"generations"`
+	// Population is the number of living cells at the end.
+	Population int64 `json:"population"`
+	// Cells is the number of living cells evolved, summed over the
+	// generations.
+	Cells                int64   `json:"cells"`
+	Seconds              float64 `json:"seconds"`
+	GenerationsPerSecond float64 `json:"generations_per_second"`
+	// NsPerCell is the time taken per living cell and generation.
+	NsPerCell           float64 `json:"ns_per_cell"`
+	AllocsPerGeneration float64 `json:"allocs_per_generation"`
+	BytesPerGeneration  float64 `json:"bytes_per_generation"`
+}
+
+// Run runs the workload once on a new world of the engine and measures it.
+func Run(workload Workload, engine Engine) (Result, error) {
+	w := engine.New()
+	if err := workload.Setup(w); err != nil {
+		return Result{}, fmt.Errorf("%s: %w", workload.Name, err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	cells, elapsed := Evolve(w, workload.Generations)
+	runtime.ReadMemStats(&after)
+
+	generations := float64(workload.Generations)
+	r := Result{
+		Workload:            workload.Name,
+		Engine:              engine.Name,
+		Generations:         workload.Generations,
+		Population:          population(w),
+		Cells:               cells,
+		Seconds:             elapsed.Seconds(),
+		AllocsPerGeneration: float64(after.Mallocs-before.Mallocs) / generations,
+		BytesPerGeneration:  float64(after.TotalAlloc-before.TotalAlloc) / generations,
+	}
+	if elapsed > 0 {
+		r.GenerationsPerSecond = generations / elapsed.Seconds()
+	}
+	if cells > 0 {
+		r.NsPerCell = float64(elapsed.Nanoseconds()) / float64(cells)
+	}
+	return r, nil
+}
+
+// Evolve runs the world for the given number of generations and returns the
+// number of living cells evolved, summed over the generations, and the time
+// the generations took, leaving out the time spent counting the cells.
+func Evolve(w types.World, generations int64) (cells int64, elapsed time.Duration) {
+	for i := int64(0); i < generations; i++ {
+		cells += population(w)
+		start := time.Now()
+		w.Evolve()
+		elapsed += time.Since(start)
+	}
+	return cells, elapsed
+}
+
+// populationCounter is a world that knows how many living cells it has
+// without visiting them, such as a quadtree world.
+type populationCounter interface {
+	Population() int64
+}
+
+// population returns the number of living cells of the world.
+func population(w types.World) int64 {
+	if counter, ok := w.(populationCounter); ok {
+		return counter.Population()
+	}
+	var count int64
+	w.ForEachCell(func(x, y, turn int64) {
+		count++
+	})
+	return count
+}
+
+// Report is the result of a benchmark run, with the machine it ran on.
+type Report struct {
+	GoVersion string   `json:"go_version"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	CPUs      int      `json:"cpus"`
+	Results   []Result `json:"results"`
+}
+
+// NewReport creates an empty report for this machine.
+func NewReport() *Report {
+	return &Report{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+	}
+}
+
+// WriteJSON writes the whole report as JSON.
+func (r *Report) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes one line per result.
+func (r *Report) WriteText(out io.Writer) error {
+	if _, err := fmt.Fprintf(out, "%-12s %-10s %8s %12s %10s %12s %14s\n",
+		"workload", "engine", "gens", "gens/s", "ns/cell", "allocs/gen", "bytes/gen"); err != nil {
+		return err
+	}
+	for _, result := range r.Results {
+		if _, err := fmt.Fprintf(out, "%-12s %-10s %8d %12.1f %10.1f %12.1f %14.1f\n",
+			result.Workload, result.Engine, result.Generations, result.GenerationsPerSecond,
+			result.NsPerCell, result.AllocsPerGeneration, result.BytesPerGeneration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
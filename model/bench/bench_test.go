@@ -0,0 +1,133 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	workloads, err := SelectWorkloads("r-pentomino")
+	if err != nil {
+		t.Fatalf("SelectWorkloads() unexpected error: %v", err)
+	}
+	for _, engine := range Engines {
+		t.Run(engine.Name, func(t *testing.T) {
+			r, err := Run(workloads[0], engine)
+			if err != nil {
+				t.Fatalf("Run() unexpected error: %v", err)
+			}
+			// The R-pentomino leaves 116 cells, counting its six gliders
+			if r.Population != 116 || r.Generations != 1103 || r.Engine != engine.Name {
+				t.Errorf("Run() = %+v, want 116 cells after 1103 generations", r)
+			}
+			if r.Cells <= r.Population || r.GenerationsPerSecond <= 0 || r.NsPerCell <= 0 {
+				t.Errorf("Run() measures = %+v", r)
+			}
+		})
+	}
+}
+
+func TestWorkloads_Setup(t *testing.T) {
+	tests := []struct {
+		workload string
+		low      int64
+		high     int64
+	}{
+		{workload: "r-pentomino", low: 5, high: 5},
+		{workload: "gosper-gun", low: 36, high: 36},
+		// About half of the cells of the soup are alive
+		{workload: "soup-512", low: 512 * 512 * 45 / 100, high: 512 * 512 * 55 / 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.workload, func(t *testing.T) {
+			workloads, err := SelectWorkloads(tt.workload)
+			if err != nil {
+				t.Fatalf("SelectWorkloads() unexpected error: %v", err)
+			}
+			w := Engines[0].New()
+			if err := workloads[0].Setup(w); err != nil {
+				t.Fatalf("Setup() unexpected error: %v", err)
+			}
+			if got := population(w); got < tt.low || got > tt.high {
+				t.Errorf("Setup() population = %d, want %d to %d", got, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	engines, err := SelectEngines("automaton,map")
+	if err != nil {
+		t.Fatalf("SelectEngines() unexpected error: %v", err)
+	}
+	if len(engines) != 2 || engines[0].Name != "automaton" || engines[1].Name != "map" {
+		t.Errorf("SelectEngines() = %v, want automaton and map", engines)
+	}
+	if all, _ := SelectWorkloads(""); len(all) != len(Workloads) {
+		t.Errorf("SelectWorkloads(\"\") = %d workloads, want all %d", len(all), len(Workloads))
+	}
+	if _, err := SelectWorkloads("r-pentomino,acorn"); err == nil || !strings.Contains(err.Error(), "acorn") {
+		t.Errorf("SelectWorkloads() error = %v, want an unknown workload", err)
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	report := NewReport()
+	report.Results = []Result{{Workload: "r-pentomino", Engine: "map", Generations: 1103, GenerationsPerSecond: 2000}}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("WriteText() unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "r-pentomino  map            1103       2000.0") {
+		t.Errorf("WriteText() = %q", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() unexpected error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+	if decoded.GoVersion == "" || len(decoded.Results) != 1 || decoded.Results[0] != report.Results[0] {
+		t.Errorf("WriteJSON() = %s", out.String())
+	}
+}
+
+// BenchmarkWorkloads runs every workload on every engine, reporting the
+// generations per second and the time per living cell and generation next to
+// the usual measures. Unlike those, they leave out the time spent counting the
+// cells.
+func BenchmarkWorkloads(b *testing.B) {
+	for _, workload := range Workloads {
+		for _, engine := range Engines {
+			b.Run(workload.Name+"/"+engine.Name, func(b *testing.B) {
+				b.ReportAllocs()
+				var (
+					cells   int64
+					elapsed time.Duration
+				)
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					w := engine.New()
+					if err := workload.Setup(w); err != nil {
+						b.Fatalf("Setup() unexpected error: %v", err)
+					}
+					b.StartTimer()
+					evolved, took := Evolve(w, workload.Generations)
+					cells += evolved
+					elapsed += took
+				}
+				b.ReportMetric(float64(int64(b.N)*workload.Generations)/elapsed.Seconds(), "gens/s")
+				b.ReportMetric(float64(elapsed.Nanoseconds())/float64(cells), "ns/cell")
+			})
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package census runs many random soups without a display and catalogs the
+// objects they leave behind once they stabilize: still lifes, oscillators and
+// spaceships, each identified by a canonical code that does not depend on its
+// position, orientation or phase.
+package census
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/model/internal"
+)
+
+// Default census settings.
+const (
+	DefaultSoups          = 1000
+	DefaultMaxGenerations = 20000
+	DefaultMaxPeriod      = 30
+)
+
+// Options are the settings of a census.
+type Options struct {
+	// Rule is the Life-like rule the soups follow, empty for Conway's.
+	Rule string
+	// Soups is the number of soups to run.
+	Soups int
+	// Soup holds the size, density and symmetry of every soup. Soup i gets
+	// the seed Soup.Seed+i, so it can be made again alone.
+	Soup model.Soup
+	// Workers is the number of soups run at the same time, the number of CPUs
+	// if 0.
+	Workers int
+	// MaxGenerations is how long a soup may run before it is given up as
+	// unstable.
+	MaxGenerations int64
+	// MaxPeriod is the longest period of the objects looked for, which is also
+	// how long a soup must show a periodic population to count as stable.
+	MaxPeriod int
+}
+
+// DefaultOptions returns the settings of a census of DefaultSoups soups under
+// Conway's rule, starting from a random seed.
+func DefaultOptions() Options {
+	return Options{
+		Soups:          DefaultSoups,
+		Soup:           model.NewSoup(),
+		MaxGenerations: DefaultMaxGenerations,
+		MaxPeriod:      DefaultMaxPeriod,
+	}
+}
+
+// Run runs the census and tallies the objects found. The report is the same
+// whatever the number of workers.
+func Run(options Options) (*Report, error) {
+	rule, err := parseRule(options.Rule)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Soup.Validate(); err != nil {
+		return nil, err
+	}
+	if options.Soups < 1 || options.MaxGenerations < 1 || options.MaxPeriod < 1 {
+		return nil, fmt.Errorf("soups, generations and period must be positive")
+	}
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	seeds := make(chan uint64)
+	results := make(chan soupResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seeds {
+				results <- runSoup(rule, options, seed)
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < options.Soups; i++ {
+			seeds <- options.Soup.Seed + uint64(i)
+		}
+		close(seeds)
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &Report{
+		Rule:  rule.String(),
+		Seed:  options.Soup.Seed,
+		Soups: options.Soups,
+	}
+	tally := make(map[string]*Tally)
+	for result := range results {
+		if !result.stable {
+			report.Unstable = append(report.Unstable, result.seed)
+			continue
+		}
+		report.Stable++
+		for _, o := range result.objects {
+			t, found := tally[o.Code]
+			if !found {
+				t = &Tally{Object: o, Sample: result.seed}
+				tally[o.Code] = t
+			}
+			t.Count++
+			if result.seed < t.Sample {
+				t.Sample = result.seed
+			}
+		}
+	}
+
+	for _, t := range tally {
+		report.Objects = append(report.Objects, *t)
+	}
+	sort.Slice(report.Objects, func(i, j int) bool {
+		a, b := report.Objects[i], report.Objects[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Code < b.Code
+	})
+	sort.Slice(report.Unstable, func(i, j int) bool { return report.Unstable[i] < report.Unstable[j] })
+	return report, nil
+}
+
+// parseRule returns the rule of the rulestring, Conway's if it is empty, which
+// must be a two-state rule on the square grid.
+func parseRule(rulestring string) (*internal.Rule, error) {
+	rule := internal.ConwayRule
+	if rulestring != "" {
+		var err error
+		if rule, err = internal.ParseRule(rulestring); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := internal.NewEngine(rule).(*internal.World); !ok || rule.States() > 2 {
+		return nil, fmt.Errorf("objects can only be classified under a two-state rule on the square grid, not %s", rule)
+	}
+	return rule, nil
+}
+
+// soupResult is the outcome of one soup: the objects it left, or none if it
+// did not stabilize.
+type soupResult struct {
+	seed    uint64
+	stable  bool
+	objects []Object
+}
+
+// runSoup runs the soup with the given seed until it stabilizes and
+// classifies what is left.
+func runSoup(rule *internal.Rule, options Options, seed uint64) soupResult {
+	soup := options.Soup
+	soup.Seed = seed
+	soup.Left, soup.Top = 0, 0
+	w := internal.NewWorldWithRule(rule)
+	soup.Fill(w)
+
+	result := soupResult{seed: seed}
+	var populations []int
+	for w.Turn() < options.MaxGenerations {
+		w.Evolve()
+		populations = append(populations, population(w))
+		if stabilized(populations, options.MaxPeriod) {
+			result.stable = true
+			break
+		}
+	}
+	if !result.stable {
+		return result
+	}
+	for _, o := range analysis.Segment(w, clusterGap) {
+		result.objects = append(result.objects, classify(rule, o.Cells, options.MaxPeriod))
+	}
+	return result
+}
+
+// population returns the number of living cells of the world.
+func population(w *internal.World) int {
+	count := 0
+	w.ForEachCell(func(x, y, turn int64) {
+		count++
+	})
+	return count
+}
+
+// stabilized reports whether the population has repeated with a period of at
+// most maxPeriod for the last 4×maxPeriod generations, which is taken to mean
+// that only still lifes, oscillators and spaceships are left.
+func stabilized(populations []int, maxPeriod int) bool {
+	window := 4 * maxPeriod
+	last := len(populations) - 1
+	for p := 1; p <= maxPeriod; p++ {
+		if last-window-p < 0 {
+			return false
+		}
+		periodic := true
+		for k := 0; k < window && periodic; k++ {
+			periodic = populations[last-k] == populations[last-k-p]
+		}
+		if periodic {
+			return true
+		}
+	}
+	return false
+}
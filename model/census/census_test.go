@@ -0,0 +1,226 @@
+package census
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/model/internal"
+)
+
+// parseCells reads cells drawn as rows of '.' and 'o' separated by '$', with
+// their top left corner at (left, top).
+func parseCells(drawing string, left, top int64) []analysis.Cell {
+	var cells []analysis.Cell
+	for y, row := range strings.Split(drawing, "$") {
+		for x, c := range row {
+			if c == 'o' {
+				cells = append(cells, analysis.Cell{X: left + int64(x), Y: top + int64(y)})
+			}
+		}
+	}
+	return cells
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		drawings   []string
+		class      string
+		period     int
+		population int
+		dx, dy     int64
+		code       string
+	}{
+		{name: "block", drawings: []string{"oo$oo"}, class: StillLife, period: 1, population: 4, code: "xs4_33"},
+		{
+			name:     "beehive in both orientations",
+			drawings: []string{".oo.$o..o$.oo.", ".o.$o.o$o.o$.o."},
+			class:    StillLife, period: 1, population: 6, code: "xs6_696",
+		},
+		{name: "blinker in both phases", drawings: []string{"ooo", "o$o$o"}, class: Oscillator, period: 2, population: 3, code: "xp2_7"},
+		{
+			name: "glider in two phases and directions",
+			drawings: []string{
+				".o.$..o$ooo",
+				"o.o$.oo$.o.",
+				".o.$o..$ooo",
+				"ooo$o..$.o.",
+			},
+			class: Spaceship, period: 4, population: 5, dx: 1, dy: 1, code: "xq4_153",
+		},
+		{
+			name:     "lightweight spaceship",
+			drawings: []string{".o..o$o....$o...o$oooo.", "oooo.$o...o$o....$.o..o"},
+			class:    Spaceship, period: 4, population: 9, dx: 2, dy: 0, code: "xq4_6frc",
+		},
+		{
+			name: "pulsar",
+			drawings: []string{
+				"..ooo...ooo..$.............$o....o.o....o$o....o.o....o$o....o.o....o$..ooo...ooo..$" +
+					".............$..ooo...ooo..$o....o.o....o$o....o.o....o$o....o.o....o$.............$..ooo...ooo..",
+			},
+			class: Oscillator, period: 3, population: 48, code: "xp3_co9nas0san9oczgoldlo0oldlogz1047210127401",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, drawing := range tt.drawings {
+				o := classify(internal.ConwayRule, parseCells(drawing, int64(10*i), -5), DefaultMaxPeriod)
+				if o.Class != tt.class || o.Period != tt.period || o.Population != tt.population || o.Dx != tt.dx || o.Dy != tt.dy {
+					t.Errorf("classify(%s) = %+v, want %s of period %d, population %d moving (%d,%d)",
+						drawing, o, tt.class, tt.period, tt.population, tt.dx, tt.dy)
+				}
+				if o.Code != tt.code {
+					t.Errorf("classify(%s) code = %s, want %s", drawing, o.Code, tt.code)
+				}
+			}
+		})
+	}
+}
+
+func TestClassify_DistinctCodes(t *testing.T) {
+	seen := map[string]string{}
+	for _, drawing := range []string{"oo$oo", ".oo.$o..o$.oo.", "oo.$o.o$.o.", "ooo", ".o.$..o$ooo", "oo.$o.o$.oo"} {
+		code := classify(internal.ConwayRule, parseCells(drawing, 0, 0), DefaultMaxPeriod).Code
+		if other, found := seen[code]; found {
+			t.Errorf("%s and %s share the code %s", drawing, other, code)
+		}
+		seen[code] = drawing
+	}
+}
+
+func TestEncode(t *testing.T) {
+	// Decoded and encoded again, in another orientation and phase
+	for _, code := range []string{"xs4_33", "xs6_696", "xs7_2596", "xp2_7", "xp15_4r4z4r4", "xq4_153", "xq4_6frc",
+		"xp3_co9nas0san9oczgoldlo0oldlogz1047210127401"} {
+		t.Run(code, func(t *testing.T) {
+			w, err := model.ReadApgcode(code)
+			if err != nil {
+				t.Fatalf("ReadApgcode() unexpected error: %v", err)
+			}
+			w.Evolve()
+			if got, err := Encode(w); err != nil || got != code {
+				t.Errorf("Encode() = %s, %v, want %s", got, err, code)
+			}
+		})
+	}
+
+	tests := []struct {
+		name    string
+		rle     string
+		wantErr string
+	}{
+		{name: "empty", rle: "x = 0, y = 0\n!\n", wantErr: "no cells to encode"},
+		{name: "growing", rle: "x = 3, y = 3\nb2o$2o$bo!\n", wantErr: "the cells do not repeat within 30 generations"},
+		{
+			name:    "multi-state",
+			rle:     "x = 1, y = 1, rule = B2/S/C3\nA!\n",
+			wantErr: "objects can only be classified under a two-state rule on the square grid, not B2/S/C3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := model.ReadRLE(strings.NewReader(tt.rle))
+			if err != nil {
+				t.Fatalf("ReadRLE() unexpected error: %v", err)
+			}
+			if _, err := Encode(w); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Encode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStabilized(t *testing.T) {
+	var populations []int
+	for i := 0; i < 50; i++ {
+		populations = append(populations, i)
+	}
+	for i := 0; i < 4*3+3; i++ {
+		populations = append(populations, 10+i%3)
+	}
+	if !stabilized(populations, 3) {
+		t.Error("a population repeating every 3 generations should be stable")
+	}
+	if stabilized(populations[:len(populations)-1], 3) {
+		t.Error("a population repeating for too short a time should not be stable")
+	}
+	if stabilized(populations, 2) {
+		t.Error("a period above the longest one looked for should not be stable")
+	}
+}
+
+func TestRun(t *testing.T) {
+	options := DefaultOptions()
+	options.Soups = 12
+	options.MaxGenerations = 1000
+	options.Soup = model.Soup{Seed: 1000, Width: 8, Height: 8, Density: 0.5, Symmetry: model.C1}
+
+	options.Workers = 1
+	single, err := Run(options)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	options.Workers = 4
+	parallel, err := Run(options)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(single, parallel) {
+		t.Error("the report should not depend on the number of workers")
+	}
+
+	if single.Stable+len(single.Unstable) != 12 || single.Rule != "B3/S23" || single.Seed != 1000 {
+		t.Errorf("Run() = %d stable and %d unstable soups with rule %s and seed %d",
+			single.Stable, len(single.Unstable), single.Rule, single.Seed)
+	}
+	blocks := 0
+	for _, o := range single.Objects {
+		if o.Class == StillLife && o.Population == 4 {
+			blocks = o.Count
+		}
+		if o.Sample < 1000 || o.Sample >= 1012 {
+			t.Errorf("object %s sampled from soup %d, outside the census", o.Code, o.Sample)
+		}
+	}
+	if blocks == 0 {
+		t.Error("12 soups should leave some blocks")
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := single.WriteCSV(buffer); err != nil {
+		t.Fatalf("WriteCSV() unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if lines[0] != "code,class,period,population,dx,dy,count,sample" || len(lines) != len(single.Objects)+1 {
+		t.Errorf("WriteCSV() = %q", buffer.String())
+	}
+
+	buffer.Reset()
+	if err := single.WriteJSON(buffer); err != nil {
+		t.Fatalf("WriteJSON() unexpected error: %v", err)
+	}
+	read := &Report{}
+	if err := json.Unmarshal(buffer.Bytes(), read); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(read.Objects, single.Objects) {
+		t.Error("WriteJSON() should keep every object")
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	for _, rule := range []string{"B2/S/C3", "B2/S34H", "WireWorld", "nonsense"} {
+		options := DefaultOptions()
+		options.Rule = rule
+		if _, err := Run(options); err == nil {
+			t.Errorf("Run() with rule %s should fail", rule)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package census
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Object classes.
+const (
+	StillLife  = "still life"
+	Oscillator = "oscillator"
+	Spaceship  = "spaceship"
+	// Unknown objects did not repeat within the longest period looked for.
+	Unknown = "unknown"
+)
+
+// clusterGap is the number of dead cells allowed between two cells of the same
+// object, as given to analysis.Segment, so that objects made of separate parts,
+// such as the pulsar, are not split.
+const clusterGap = 1
+
+// Object is a kind of object found in the ash of a soup.
+type Object struct {
+	// Code is the apgcode of the object, which is the same whatever its
+	// position, orientation and phase: a prefix for its class and size ("xs4"
+	// for a still life of 4 cells, "xp2" for an oscillator of period 2, "xq4"
+	// for a spaceship of period 4, "zz" for unknown objects) and its shape in
+	// the extended Wechsler format, as in "xq4_153" for the glider.
+	Code   string `json:"code"`
+	Class  string `json:"class"`
+	Period int    `json:"period"`
+	// Population is the smallest population among the phases.
+	Population int `json:"population"`
+	// Dx and Dy are how far a spaceship moves in one period, whatever its
+	// direction: Dx is the larger displacement and Dy the smaller one, so a
+	// glider has 1 and 1 and a lightweight spaceship 2 and 0.
+	Dx int64 `json:"dx,omitempty"`
+	Dy int64 `json:"dy,omitempty"`
+}
+
+// classify runs the cells alone until they repeat, and names the object they
+// form.
+func classify(rule *internal.Rule, cells []analysis.Cell, maxPeriod int) Object {
+	w := internal.NewWorldWithRule(rule)
+	for _, c := range cells {
+		w.AddCellIn(c.X, c.Y, 0)
+	}
+	start, left, top := normalize(cells)
+	phases := []string{start}
+	o := Object{Class: Unknown, Population: len(cells)}
+	for p := 1; p <= maxPeriod; p++ {
+		w.Evolve()
+		var current []analysis.Cell
+		w.ForEachCell(func(x, y, turn int64) {
+			current = append(current, analysis.Cell{X: x, Y: y})
+		})
+		if len(current) == 0 {
+			break
+		}
+		shape, currentLeft, currentTop := normalize(current)
+		if shape == start {
+			dx, dy := abs(currentLeft-left), abs(currentTop-top)
+			o.Period, o.Dx, o.Dy = p, max(dx, dy), min(dx, dy)
+			switch {
+			case o.Dx != 0 || o.Dy != 0:
+				o.Class = Spaceship
+			case p == 1:
+				o.Class = StillLife
+			default:
+				o.Class = Oscillator
+			}
+			break
+		}
+		phases = append(phases, shape)
+		o.Population = min(o.Population, len(current))
+	}
+
+	switch o.Class {
+	case StillLife:
+		o.Code = fmt.Sprintf("xs%d_%s", o.Population, canonical(phases))
+	case Oscillator:
+		o.Code = fmt.Sprintf("xp%d_%s", o.Period, canonical(phases))
+	case Spaceship:
+		o.Code = fmt.Sprintf("xq%d_%s", o.Period, canonical(phases))
+	default:
+		o.Code = fmt.Sprintf("zz_%s", canonical(phases[:1]))
+	}
+	return o
+}
+
+// Encode returns the apgcode of the object made by all the living cells of the
+// world, under the world's rule: the class and size of the object and its
+// shape in the phase and orientation with the first code, as apgsearch names
+// objects. The object must be a still life, an oscillator or a spaceship of
+// period up to DefaultMaxPeriod.
+func Encode(w types.World) (string, error) {
+	rulestring := ""
+	if reporter, ok := w.(types.RuleReporter); ok {
+		rulestring = reporter.Rule()
+	}
+	rule, err := parseRule(rulestring)
+	if err != nil {
+		return "", err
+	}
+	var cells []analysis.Cell
+	w.ForEachCell(func(x, y, turn int64) {
+		cells = append(cells, analysis.Cell{X: x, Y: y})
+	})
+	if len(cells) == 0 {
+		return "", fmt.Errorf("no cells to encode")
+	}
+	o := classify(rule, cells, DefaultMaxPeriod)
+	if o.Class == Unknown {
+		return "", fmt.Errorf("the cells do not repeat within %d generations", DefaultMaxPeriod)
+	}
+	return o.Code, nil
+}
+
+// abs returns the absolute value of v.
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// normalize draws the cells as rows of '.' and 'o' separated by '$', from
+// their top left corner, which is also returned.
+func normalize(cells []analysis.Cell) (shape string, left, top int64) {
+	left, top = cells[0].X, cells[0].Y
+	right, bottom := left, top
+	alive := make(map[analysis.Cell]bool)
+	for _, c := range cells {
+		alive[c] = true
+		left, right = min(left, c.X), max(right, c.X)
+		top, bottom = min(top, c.Y), max(bottom, c.Y)
+	}
+	buffer := &strings.Builder{}
+	for y := top; y <= bottom; y++ {
+		if y > top {
+			buffer.WriteByte('$')
+		}
+		for x := left; x <= right; x++ {
+			if alive[analysis.Cell{X: x, Y: y}] {
+				buffer.WriteByte('o')
+			} else {
+				buffer.WriteByte('.')
+			}
+		}
+	}
+	return buffer.String(), left, top
+}
+
+// canonical returns the extended Wechsler code of the drawings of all the
+// phases in all 8 orientations that comes first, as apgsearch chooses it: the
+// shortest, and among those the first in alphabetical order. Every phase of an
+// object in any orientation gives the same one.
+func canonical(phases []string) string {
+	best := ""
+	for _, phase := range phases {
+		for _, shape := range orientations(phase) {
+			code := model.EncodeWechsler(drawnCells(shape))
+			if best == "" || len(code) < len(best) || len(code) == len(best) && code < best {
+				best = code
+			}
+		}
+	}
+	return best
+}
+
+// drawnCells returns the living cells of a drawing made by normalize.
+func drawnCells(shape string) [][2]int64 {
+	var cells [][2]int64
+	for y, row := range strings.Split(shape, "$") {
+		for x, c := range []byte(row) {
+			if c == 'o' {
+				cells = append(cells, [2]int64{int64(x), int64(y)})
+			}
+		}
+	}
+	return cells
+}
+
+// orientations returns the drawing rotated by each quarter turn, with and
+// without a reflection.
+func orientations(shape string) []string {
+	rows := strings.Split(shape, "$")
+	var all []string
+	for i := 0; i < 4; i++ {
+		all = append(all, strings.Join(rows, "$"), strings.Join(mirror(rows), "$"))
+		rows = rotate(rows)
+	}
+	return all
+}
+
+// rotate turns the rows of a drawing a quarter turn clockwise.
+func rotate(rows []string) []string {
+	rotated := make([]string, len(rows[0]))
+	for x := range rotated {
+		line := make([]byte, len(rows))
+		for y := range rows {
+			line[len(rows)-1-y] = rows[y][x]
+		}
+		rotated[x] = string(line)
+	}
+	return rotated
+}
+
+// mirror reflects the rows of a drawing left to right.
+func mirror(rows []string) []string {
+	mirrored := make([]string, len(rows))
+	for y, row := range rows {
+		line := []byte(row)
+		for i, j := 0, len(line)-1; i < j; i, j = i+1, j-1 {
+			line[i], line[j] = line[j], line[i]
+		}
+		mirrored[y] = string(line)
+	}
+	return mirrored
+}
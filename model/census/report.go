@@ -0,0 +1,60 @@
+package census
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Tally is the number of times an object was found.
+type Tally struct {
+	Object
+	Count int `json:"count"`
+	// Sample is the seed of the first soup the object was found in.
+	Sample uint64 `json:"sample"`
+}
+
+// Report is the result of a census.
+type Report struct {
+	Rule  string `json:"rule"`
+	Seed  uint64 `json:"seed"`
+	Soups int    `json:"soups"`
+	// Stable is the number of soups that stabilized.
+	Stable int `json:"stable"`
+	// Unstable holds the seeds of the soups that did not stabilize in time,
+	// such as those that grow forever.
+	Unstable []uint64 `json:"unstable"`
+	// Objects holds the objects found, the most common first.
+	Objects []Tally `json:"objects"`
+}
+
+// csvHeader names the columns written by WriteCSV.
+var csvHeader = []string{"code", "class", "period", "population", "dx", "dy", "count", "sample"}
+
+// WriteCSV writes one line per object, with a header line.
+func (r *Report) WriteCSV(out io.Writer) error {
+	writer := csv.NewWriter(out)
+	writer.Write(csvHeader)
+	for _, t := range r.Objects {
+		writer.Write([]string{
+			t.Code,
+			t.Class,
+			strconv.Itoa(t.Period),
+			strconv.Itoa(t.Population),
+			strconv.FormatInt(t.Dx, 10),
+			strconv.FormatInt(t.Dy, 10),
+			strconv.Itoa(t.Count),
+			strconv.FormatUint(t.Sample, 10),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the whole report as JSON.
+func (r *Report) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// composeExtension is the extension of composition files.
+const composeExtension = ".compose"
+
+// maxCompositionDepth is how deeply compositions may place other
+// compositions, which stops a composition that places itself.
+const maxCompositionDepth = 8
+
+// Placement puts a pattern into a composed world.
+type Placement struct {
+	// Pattern is a sample name or the path of a pattern file, as given to
+	// ReadWorld.
+	Pattern string
+	// X and Y are where the top left corner of the bounding box of the
+	// pattern goes.
+	X, Y int64
+	// Rotation is 0, 90, 180 or 270 degrees clockwise.
+	Rotation int
+	// Reflect reflects the pattern left to right, before it is rotated.
+	Reflect bool
+	// Phase is the number of generations the pattern is run, alone, before it
+	// is placed.
+	Phase int64
+}
+
+// Composition is a world put together from patterns.
+type Composition struct {
+	// Rule is the rule of the world, or empty to take the rule of the
+	// patterns, which must then all follow the same one.
+	Rule       string
+	Placements []Placement
+}
+
+// ParseComposition reads a composition. Each line places a pattern: its name
+// or path, followed by any of "at x,y", "rotate 90" (or 180 or 270),
+// "reflect" and "phase n". A "rule" line sets the rule of the world, and lines
+// starting with "#" are comments:
+//
+//	# Two gliders heading for a wall
+//	glider
+//	glider at 0,5
+//	wall at 15,0
+func ParseComposition(r io.Reader) (*Composition, error) {
+	c := &Composition{}
+	scanner := newLineScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "rule" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: rule needs one rulestring", lineNumber)
+			}
+			c.Rule = fields[1]
+			continue
+		}
+		p, err := parsePlacement(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		c.Placements = append(c.Placements, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(lineNumber+1, err)
+	}
+	return c, nil
+}
+
+// parsePlacement parses the fields of a placement line.
+func parsePlacement(fields []string) (Placement, error) {
+	p := Placement{Pattern: fields[0]}
+	for i := 1; i < len(fields); i++ {
+		keyword := fields[i]
+		if keyword == "reflect" {
+			p.Reflect = true
+			continue
+		}
+		if i+1 >= len(fields) {
+			return p, fmt.Errorf("%s needs a value", keyword)
+		}
+		i++
+		value := fields[i]
+		switch keyword {
+		case "at":
+			x, y, found := strings.Cut(value, ",")
+			var errX, errY error
+			p.X, errX = strconv.ParseInt(x, 10, 64)
+			p.Y, errY = strconv.ParseInt(y, 10, 64)
+			if !found || errX != nil || errY != nil {
+				return p, fmt.Errorf("invalid position %q (want x,y)", value)
+			}
+		case "rotate":
+			rotation, err := strconv.Atoi(value)
+			if err != nil || rotation%90 != 0 || rotation < 0 || rotation >= 360 {
+				return p, fmt.Errorf("invalid rotation %q (want 90, 180 or 270)", value)
+			}
+			p.Rotation = rotation
+		case "phase":
+			phase, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || phase < 0 {
+				return p, fmt.Errorf("invalid phase %q", value)
+			}
+			p.Phase = phase
+		default:
+			return p, fmt.Errorf("unknown keyword %q", keyword)
+		}
+	}
+	return p, nil
+}
+
+// Build reads the patterns of the composition and places them into a new
+// world. Paths of pattern files that are not absolute are taken from dir;
+// other names are samples, as for ReadWorld.
+func (c *Composition) Build(dir string) (types.World, error) {
+	return c.build(dir, 0)
+}
+
+// build builds the composition, nested at the given depth.
+func (c *Composition) build(dir string, depth int) (types.World, error) {
+	patterns := make([]types.World, len(c.Placements))
+	rule := c.Rule
+	for i, p := range c.Placements {
+		name := p.Pattern
+		if isPatternPath(name) && !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		// The patterns are copied into a world of the rule anyway
+		pattern, err := readWorld(name, depth+1, MapEngine)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", p.Pattern, err)
+		}
+		patternRule := ruleOf(pattern)
+		switch {
+		case c.Rule != "":
+			if pattern, err = WithRule(pattern, c.Rule); err != nil {
+				return nil, err
+			}
+		case rule == "":
+			rule = patternRule
+		case patternRule != rule:
+			return nil, fmt.Errorf("pattern %s follows %s, not %s", p.Pattern, patternRule, rule)
+		}
+		patterns[i] = pattern
+	}
+
+	if rule == "" {
+		rule = internal.ConwayRule.String()
+	}
+	w, err := NewWorldWithRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range c.Placements {
+		if err := place(w, patterns[i], p); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// ruleOf returns the rule of the world, or Conway's when it does not report
+// one.
+func ruleOf(w types.World) string {
+	if reporter, ok := w.(types.RuleReporter); ok {
+		return reporter.Rule()
+	}
+	return internal.ConwayRule.String()
+}
+
+// place runs the pattern to the phase of the placement, then turns it and
+// copies its cells into w. The pattern is turned within the bounding box it
+// had before it was run, so that a spaceship run to a later phase is placed as
+// far along its way as it has gone.
+func place(w, pattern types.World, p Placement) error {
+	var left, top, right, bottom int64
+	first := true
+	forEachState(pattern, func(x, y int64, state int) {
+		if first {
+			left, top, right, bottom = x, y, x, y
+			first = false
+		}
+		left, right = min(left, x), max(right, x)
+		top, bottom = min(top, y), max(bottom, y)
+	})
+	if first {
+		return fmt.Errorf("pattern %s is empty", p.Pattern)
+	}
+	width, height := right-left+1, bottom-top+1
+	for i := int64(0); i < p.Phase; i++ {
+		pattern.Evolve()
+	}
+
+	target, multi := w.(types.MultiState)
+	forEachState(pattern, func(x, y int64, state int) {
+		cx, cy := x-left, y-top
+		if p.Reflect {
+			cx = width - 1 - cx
+		}
+		boxWidth, boxHeight := width, height
+		for r := 0; r < p.Rotation/90; r++ {
+			cx, cy = boxHeight-1-cy, cx
+			boxWidth, boxHeight = boxHeight, boxWidth
+		}
+		if multi && target.States() > 2 {
+			target.SetStateIn(p.X+cx, p.Y+cy, state, 0)
+		} else {
+			w.AddCellIn(p.X+cx, p.Y+cy, 0)
+		}
+	})
+	return nil
+}
+
+// forEachState calls fn with the coordinates and state of every cell of the
+// world that is not dead, which are all alive in worlds with two states.
+func forEachState(w types.World, fn func(x, y int64, state int)) {
+	if multi, ok := w.(types.MultiState); ok && multi.States() > 2 {
+		multi.ForEachState(fn)
+		return
+	}
+	w.ForEachCell(func(x, y, turn int64) {
+		fn(x, y, 1)
+	})
+}
+
+// readCompositionFile reads and builds the composition file at path, nested at
+// the given depth, taking the paths in it from the directory of the file.
+func readCompositionFile(path string, depth int) (types.World, error) {
+	if depth > maxCompositionDepth {
+		return nil, fmt.Errorf("compositions nested more than %d deep", maxCompositionDepth)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := ParseComposition(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c.build(filepath.Dir(path), depth)
+}
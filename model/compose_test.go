@@ -0,0 +1,163 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestParseComposition(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Composition
+		wantErr bool
+	}{
+		{
+			name:  "placements",
+			input: "# A comment\nrule B36/S23\n\nglider\nwall.rle at -3,20 rotate 270 reflect phase 4\n",
+			want: &Composition{
+				Rule: "B36/S23",
+				Placements: []Placement{
+					{Pattern: "glider"},
+					{Pattern: "wall.rle", X: -3, Y: 20, Rotation: 270, Reflect: true, Phase: 4},
+				},
+			},
+		},
+		{name: "bad position", input: "glider at 3\n", wantErr: true},
+		{name: "bad rotation", input: "glider rotate 45\n", wantErr: true},
+		{name: "negative phase", input: "glider phase -1\n", wantErr: true},
+		{name: "missing value", input: "glider at\n", wantErr: true},
+		{name: "unknown keyword", input: "glider flip\n", wantErr: true},
+		{name: "rule without value", input: "rule\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseComposition(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseComposition() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseComposition() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseComposition() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeFile writes the content to the file name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	return path
+}
+
+func TestComposition_Build(t *testing.T) {
+	dir := t.TempDir()
+	// An L tromino: two cells on top, one below on the left
+	writeFile(t, dir, "l.rle", "x = 2, y = 2\n2o$o!\n")
+	// A glider moving down and right
+	writeFile(t, dir, "glider.rle", "x = 3, y = 3\nbo$2bo$3o!\n")
+
+	tests := []struct {
+		name      string
+		placement Placement
+		want      [][2]int64
+	}{
+		{name: "offset", placement: Placement{Pattern: "l.rle", X: 5, Y: -1}, want: [][2]int64{{5, -1}, {6, -1}, {5, 0}}},
+		{name: "rotate 90", placement: Placement{Pattern: "l.rle", Rotation: 90}, want: [][2]int64{{0, 0}, {1, 0}, {1, 1}}},
+		{name: "rotate 180", placement: Placement{Pattern: "l.rle", Rotation: 180}, want: [][2]int64{{1, 0}, {0, 1}, {1, 1}}},
+		{name: "rotate 270", placement: Placement{Pattern: "l.rle", Rotation: 270}, want: [][2]int64{{0, 0}, {0, 1}, {1, 1}}},
+		{name: "reflect", placement: Placement{Pattern: "l.rle", Reflect: true}, want: [][2]int64{{0, 0}, {1, 0}, {1, 1}}},
+		{name: "reflect and rotate", placement: Placement{Pattern: "l.rle", Reflect: true, Rotation: 90}, want: [][2]int64{{1, 0}, {0, 1}, {1, 1}}},
+		// Four generations move the glider one cell down and right
+		{name: "phase", placement: Placement{Pattern: "glider.rle", Phase: 4}, want: [][2]int64{{2, 1}, {3, 2}, {1, 3}, {2, 3}, {3, 3}}},
+		// Turned around, it moves up and left instead
+		{name: "phase rotated", placement: Placement{Pattern: "glider.rle", Rotation: 180, Phase: 4}, want: [][2]int64{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {0, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Composition{Placements: []Placement{tt.placement}}
+			w, err := c.Build(dir)
+			if err != nil {
+				t.Fatalf("Build() unexpected error: %v", err)
+			}
+			if got := cellsOf(w); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() cells = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposition_BuildRule(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "brain.rle", "x = 2, y = 1, rule = B2/S/C3\nAB!\n")
+	writeFile(t, dir, "dot.rle", "x = 1, y = 1\no!\n")
+
+	c := &Composition{Placements: []Placement{{Pattern: "brain.rle"}, {Pattern: "brain.rle", X: 10}}}
+	w, err := c.Build(dir)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	multi, ok := w.(types.MultiState)
+	if !ok || multi.States() != 3 {
+		t.Fatal("Build() should follow the rule of the patterns")
+	}
+	if multi.StateOf(10, 0) != 1 || multi.StateOf(11, 0) != 2 {
+		t.Errorf("states %d and %d, want 1 and 2", multi.StateOf(10, 0), multi.StateOf(11, 0))
+	}
+
+	c.Placements = append(c.Placements, Placement{Pattern: "dot.rle"})
+	if _, err := c.Build(dir); err == nil {
+		t.Error("Build() expected an error for patterns with different rules")
+	}
+	c.Rule = "B36/S23"
+	w, err = c.Build(dir)
+	if err != nil {
+		t.Fatalf("Build() with a rule unexpected error: %v", err)
+	}
+	if rule := w.(types.RuleReporter).Rule(); rule != "B36/S23" {
+		t.Errorf("Build() rule = %s, want B36/S23", rule)
+	}
+}
+
+func TestReadWorld_Composition(t *testing.T) {
+	if err := os.MkdirAll("samples", 0755); err != nil {
+		t.Fatalf("Failed to create samples directory: %v", err)
+	}
+	defer os.RemoveAll("samples")
+	writeFile(t, "samples", "dot.life", "x\n")
+	writeFile(t, "samples", "pair.compose", "dot\ndot at 2,0\n")
+	writeFile(t, "samples", "pairs.compose", "pair.compose\npair.compose at 0,2 rotate 90\n")
+	writeFile(t, "samples", "loop.compose", "loop.compose\n")
+	writeFile(t, "samples", "missing.compose", "nothing\n")
+
+	w, err := ReadWorld("pairs")
+	if err != nil {
+		t.Fatalf("ReadWorld() unexpected error: %v", err)
+	}
+	want := [][2]int64{{0, 0}, {2, 0}, {0, 2}, {0, 4}}
+	if got := cellsOf(w); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadWorld() cells = %v, want %v", got, want)
+	}
+
+	for _, name := range []string{"loop", "missing"} {
+		if _, err := ReadWorld(name); err == nil {
+			t.Errorf("ReadWorld(%q) expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Headers of the Life 1.05 and 1.06 formats.
+const (
+	life105Header = "#Life 1.05"
+	life106Header = "#Life 1.06"
+)
+
+// ReadCells reads a pattern in the plaintext format of .cells files: lines
+// starting with "!" are comments, and the others draw the pattern with "." for
+// dead cells and "O" (or "*") for living ones, from (0,0).
+func ReadCells(r io.Reader) (types.World, error) {
+	var (
+		cells      [][2]int64
+		y          int64
+		lineNumber int
+	)
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		for x, c := range []byte(line) {
+			switch c {
+			case 'O', '*':
+				cells = append(cells, [2]int64{int64(x), y})
+			case '.':
+			default:
+				return nil, fmt.Errorf("line %d: unexpected character %q", lineNumber, c)
+			}
+		}
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(lineNumber+1, err)
+	}
+	w := internal.NewWorld()
+	w.AddCells(cells, 0)
+	return w, nil
+}
+
+// ReadLife105 reads a pattern in the Life 1.05 format. After the "#Life 1.05"
+// header, "#D" lines describe the pattern, "#N" selects Conway's rule and
+// "#R" another one in the S/B notation, such as "#R 23/36". The pattern comes
+// in blocks, each starting with a "#P x y" line giving the position of its top
+// left corner and drawn below it with "." for dead cells and "*" for living
+// ones.
+func ReadLife105(r io.Reader) (types.World, error) {
+	var (
+		cells      [][2]int64
+		left, y    int64
+		lineNumber int
+		header     bool
+		rule       internal.Automaton = internal.ConwayRule
+	)
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if !header {
+			if line == "" {
+				continue
+			}
+			if line != life105Header {
+				return nil, fmt.Errorf("line %d: missing %q header", lineNumber, life105Header)
+			}
+			header = true
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "#P":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: invalid block position %q (want #P x y)", lineNumber, line)
+			}
+			var errX, errY error
+			left, errX = strconv.ParseInt(fields[1], 10, 64)
+			y, errY = strconv.ParseInt(fields[2], 10, 64)
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("line %d: invalid block position %q (want #P x y)", lineNumber, line)
+			}
+			continue
+		case fields[0] == "#N":
+			rule = internal.ConwayRule
+			continue
+		case fields[0] == "#R":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: rule needs one rulestring", lineNumber)
+			}
+			var err error
+			if rule, err = internal.ParseAutomaton(fields[1]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		}
+		for x, c := range []byte(line) {
+			switch c {
+			case '*':
+				cells = append(cells, [2]int64{left + int64(x), y})
+			case '.':
+			default:
+				return nil, fmt.Errorf("line %d: unexpected character %q", lineNumber, c)
+			}
+		}
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(lineNumber+1, err)
+	}
+	w := internal.NewEngine(rule)
+	w.AddCells(cells, 0)
+	return w, nil
+}
+
+// ReadLife106 reads a pattern in the Life 1.06 format: after the "#Life 1.06"
+// header, every line gives the coordinates of a living cell, as "x y".
+func ReadLife106(r io.Reader) (types.World, error) {
+	var (
+		cells      [][2]int64
+		lineNumber int
+		header     bool
+	)
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if !header {
+			if line == "" {
+				continue
+			}
+			if line != life106Header {
+				return nil, fmt.Errorf("line %d: missing %q header", lineNumber, life106Header)
+			}
+			header = true
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: invalid cell %q (want x y)", lineNumber, line)
+		}
+		x, errX := strconv.ParseInt(fields[0], 10, 64)
+		y, errY := strconv.ParseInt(fields[1], 10, 64)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("line %d: invalid cell %q (want x y)", lineNumber, line)
+		}
+		cells = append(cells, [2]int64{x, y})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(lineNumber+1, err)
+	}
+	w := internal.NewWorld()
+	w.AddCells(cells, 0)
+	return w, nil
+}
+
+// ReadPattern reads a pattern in any of the formats it can tell from its
+// first line that is not empty: Life 1.05, Life 1.06 and macrocell by their
+// headers, .cells files by their comments, and RLE by its header or comments.
+// Anything else is read as a drawing, as ReadLife does. Macrocell patterns are
+// read into quadtree worlds.
+func ReadPattern(r io.Reader) (types.World, error) {
+	br := bufio.NewReader(r)
+	// Only the beginning is looked at, as much as the reader holds
+	peeked, _ := br.Peek(br.Size())
+	first, _, _ := bytes.Cut(bytes.TrimLeft(peeked, " \t\r\n"), []byte("\n"))
+	line := string(bytes.TrimSpace(first))
+
+	switch {
+	case strings.HasPrefix(line, life105Header):
+		return ReadLife105(br)
+	case strings.HasPrefix(line, life106Header):
+		return ReadLife106(br)
+	case strings.HasPrefix(line, macrocellHeader):
+		return ReadMacrocell(br, QuadtreeEngine)
+	case strings.HasPrefix(line, "!"):
+		return ReadCells(br)
+	case isRLEHeader(line) || isRLEComment(line):
+		return ReadRLE(br)
+	}
+	return ReadLife(br)
+}
+
+// isRLEHeader reports whether the line is an RLE header, such as "x = 3, y =
+// 3".
+func isRLEHeader(line string) bool {
+	key, _, found := strings.Cut(line, "=")
+	return found && strings.TrimSpace(key) == "x"
+}
+
+// isRLEComment reports whether the line is one of the "#" lines found at the
+// top of RLE files, such as "#N Glider" or "#C A comment".
+func isRLEComment(line string) bool {
+	return len(line) >= 2 && line[0] == '#' && strings.ContainsRune("CcNOPRr", rune(line[1]))
+}
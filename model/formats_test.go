@@ -0,0 +1,156 @@
+package model
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// rPentomino is the R-pentomino next to a block, in every format, by the
+// name of its file.
+var rPentomino = map[string]string{
+	"r.life":    " xx  xx\nxx   xx\n x\n",
+	"r-rle.rle": "#N R-pentomino and block\nx = 7, y = 3\nb2o2b2o$2o3b2o$bo!\n",
+	"r-cells.cells": "!Name: R-pentomino and block\n" +
+		".OO..OO\n" +
+		"OO...OO\n" +
+		".O\n",
+	"r-105.lif": "#Life 1.05\n#D R-pentomino and block\n#N\n" +
+		"#P -1 -1\n.**\n**.\n.*.\n" +
+		"#P 4 -1\n**\n**\n",
+	"r-106.lif": "#Life 1.06\n0 -1\n1 -1\n-1 0\n0 0\n0 1\n4 -1\n5 -1\n4 0\n5 0\n",
+	"r-mc.mc":   "[M2] (life)\n#R B3/S23\n.**..**$**...**$.*$\n4 0 0 0 1\n",
+}
+
+func TestReadPattern_Formats(t *testing.T) {
+	want := statesOf(mustReadPattern(t, rPentomino["r-rle.rle"]))
+	if len(want) != 9 {
+		t.Fatalf("RLE cells = %v, want 9 cells", want)
+	}
+	for file, content := range rPentomino {
+		t.Run(file, func(t *testing.T) {
+			if got := statesOf(mustReadPattern(t, content)); !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadPattern() cells = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// mustReadPattern reads the pattern, failing the test on errors.
+func mustReadPattern(t *testing.T, content string) types.World {
+	t.Helper()
+	w, err := ReadPattern(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ReadPattern() unexpected error: %v", err)
+	}
+	return w
+}
+
+func TestReadWorld_Formats(t *testing.T) {
+	if err := os.MkdirAll("samples", 0755); err != nil {
+		t.Fatalf("Failed to create samples directory: %v", err)
+	}
+	defer os.RemoveAll("samples")
+	want := statesOf(mustReadPattern(t, rPentomino["r-rle.rle"]))
+	for file, content := range rPentomino {
+		name := strings.TrimSuffix(file, filepath.Ext(file))
+		path := writeFile(t, "samples", file, content)
+		// By the name of the sample, then by the path of the file
+		for _, sample := range []string{name, path} {
+			w, err := ReadWorld(sample)
+			if err != nil {
+				t.Fatalf("ReadWorld(%q) unexpected error: %v", sample, err)
+			}
+			if got := statesOf(w); !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadWorld(%q) cells = %v, want %v", sample, got, want)
+			}
+		}
+	}
+
+	// A .cells file read as a drawing would have a cell for every dot
+	path := writeFile(t, "samples", "dots.cells", "..O\n")
+	if w, err := ReadWorld(path); err != nil || len(statesOf(w)) != 1 {
+		t.Errorf("ReadWorld(%q) = %v, %v, want one cell", path, statesOf(w), err)
+	}
+}
+
+func TestReadLife105_Rule(t *testing.T) {
+	w := mustReadPattern(t, "#Life 1.05\n#R 23/36\n#P 0 0\n*\n")
+	if rule := ruleOf(w); rule != "B36/S23" {
+		t.Errorf("rule = %s, want B36/S23", rule)
+	}
+}
+
+func TestReadFormats_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		read    func(content string) error
+		content string
+		wantErr string
+	}{
+		{
+			name:    "cells with a bad character",
+			read:    readWith(ReadCells),
+			content: "!Name: bad\n.O.\nxO\n",
+			wantErr: `line 3: unexpected character 'x'`,
+		},
+		{
+			name:    "1.05 without a header",
+			read:    readWith(ReadLife105),
+			content: "#P 0 0\n*\n",
+			wantErr: `line 1: missing "#Life 1.05" header`,
+		},
+		{
+			name:    "1.05 with a bad block",
+			read:    readWith(ReadLife105),
+			content: "#Life 1.05\n#P 0\n*\n",
+			wantErr: `line 2: invalid block position "#P 0" (want #P x y)`,
+		},
+		{
+			name:    "1.05 with a bad rule",
+			read:    readWith(ReadLife105),
+			content: "#Life 1.05\n#R 9/9\n",
+			wantErr: `line 2: unsupported rule "9/9": invalid neighbor count '9'`,
+		},
+		{
+			name:    "1.05 with a bad character",
+			read:    readWith(ReadLife105),
+			content: "#Life 1.05\n#P 0 0\n*o\n",
+			wantErr: `line 3: unexpected character 'o'`,
+		},
+		{
+			name:    "1.06 without a header",
+			read:    readWith(ReadLife106),
+			content: "0 0\n",
+			wantErr: `line 1: missing "#Life 1.06" header`,
+		},
+		{
+			name:    "1.06 with a bad cell",
+			read:    readWith(ReadLife106),
+			content: "#Life 1.06\n0 0\n1 x\n",
+			wantErr: `line 3: invalid cell "1 x" (want x y)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.read(tt.content); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// readWith returns a function reading content with the reader and returning
+// its error.
+func readWith(read func(r io.Reader) (types.World, error)) func(content string) error {
+	return func(content string) error {
+		_, err := read(strings.NewReader(content))
+		return err
+	}
+}
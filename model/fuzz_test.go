@@ -0,0 +1,299 @@
+package model
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// addSamples seeds the fuzz target with the samples that have the extension.
+func addSamples(f *testing.F, extension string) {
+	f.Helper()
+	paths, err := filepath.Glob(filepath.Join("..", "samples", "*"+extension))
+	if err != nil || len(paths) == 0 {
+		f.Fatalf("no %s samples found: %v", extension, err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatalf("Failed to read %s: %v", path, err)
+		}
+		f.Add(content)
+	}
+}
+
+// checkError fails if err does not say which line of the input it is about.
+func checkError(t *testing.T, err error) {
+	t.Helper()
+	if !strings.HasPrefix(err.Error(), "line ") {
+		t.Errorf("error %q does not give its line", err)
+	}
+}
+
+// statesOf returns the coordinates and state of every non-empty cell of the
+// world, moved so that their bounding box starts at (0,0), in reading order.
+func statesOf(w types.World) [][3]int64 {
+	var cells [][3]int64
+	forEachState(w, func(x, y int64, state int) {
+		cells = append(cells, [3]int64{x, y, int64(state)})
+	})
+	if len(cells) == 0 {
+		return nil
+	}
+	left, top := cells[0][0], cells[0][1]
+	for _, c := range cells {
+		left, top = min(left, c[0]), min(top, c[1])
+	}
+	for i := range cells {
+		cells[i][0] -= left
+		cells[i][1] -= top
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i][1] != cells[j][1] {
+			return cells[i][1] < cells[j][1]
+		}
+		return cells[i][0] < cells[j][0]
+	})
+	return cells
+}
+
+func FuzzReadLife(f *testing.F) {
+	addSamples(f, ".life")
+	f.Add([]byte("x x\r\n\n  x"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		w, err := ReadLife(bytes.NewReader(data))
+		if err != nil {
+			checkError(t, err)
+			return
+		}
+
+		// Every character other than a space is a cell at its column and line
+		want := make(map[[2]int64]bool)
+		lines := strings.Split(string(data), "\n")
+		if len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for y, line := range lines {
+			x := int64(0)
+			for _, c := range strings.TrimSuffix(line, "\r") {
+				if c != ' ' {
+					want[[2]int64{x, int64(y)}] = true
+				}
+				x++
+			}
+		}
+		got := make(map[[2]int64]bool)
+		w.ForEachCell(func(x, y, turn int64) {
+			got[[2]int64{x, y}] = true
+		})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadLife() cells = %v, want %v", got, want)
+		}
+	})
+}
+
+func FuzzReadRLE(f *testing.F) {
+	addSamples(f, ".rle")
+	f.Add([]byte("x = 3, y = 3\nbo$2bo$3o!\n"))
+	f.Add([]byte("x = 2, y = 1, rule = B2/S/C3\nAB!\n"))
+	f.Add([]byte("#N comment\nx = 0, y = 0, rule = R2,C0,M1,S2..3,B3..3,NN\n!\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		w, err := ReadRLE(bytes.NewReader(data))
+		if err != nil {
+			checkError(t, err)
+			return
+		}
+		forEachState(w, func(x, y int64, state int) {
+			if x < 0 || y < 0 {
+				t.Fatalf("ReadRLE() cell at (%d,%d), left of or above the pattern", x, y)
+			}
+		})
+
+		// Written and read again, the pattern keeps its cells and rule
+		var out bytes.Buffer
+		if err := WriteRLE(w, &out); err != nil {
+			t.Fatalf("WriteRLE() unexpected error: %v", err)
+		}
+		again, err := ReadRLE(&out)
+		if err != nil {
+			t.Fatalf("ReadRLE() of written RLE %q unexpected error: %v", out.String(), err)
+		}
+		if ruleOf(again) != ruleOf(w) {
+			t.Errorf("rule %s read back as %s", ruleOf(w), ruleOf(again))
+		}
+		if got, want := statesOf(again), statesOf(w); !reflect.DeepEqual(got, want) {
+			t.Errorf("cells %v read back as %v", want, got)
+		}
+	})
+}
+
+func FuzzParseComposition(f *testing.F) {
+	addSamples(f, composeExtension)
+	f.Add([]byte("rule B36/S23\nwall.rle at -3,20 rotate 270 reflect phase 4\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c, err := ParseComposition(bytes.NewReader(data))
+		if err != nil {
+			checkError(t, err)
+			return
+		}
+		for _, p := range c.Placements {
+			if p.Pattern == "" || p.Rotation%90 != 0 || p.Rotation < 0 || p.Rotation >= 360 || p.Phase < 0 {
+				t.Errorf("ParseComposition() placement = %+v", p)
+			}
+		}
+	})
+}
+
+func FuzzReadCells(f *testing.F) {
+	addSamples(f, ".cells")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		w, err := ReadCells(bytes.NewReader(data))
+		if err != nil {
+			checkError(t, err)
+			return
+		}
+
+		// Every "O" or "*" outside comments is a cell at its column and row
+		want := make(map[[2]int64]bool)
+		y := int64(0)
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if strings.HasPrefix(line, "!") {
+				continue
+			}
+			for x, c := range []byte(line) {
+				if c == 'O' || c == '*' {
+					want[[2]int64{int64(x), y}] = true
+				}
+			}
+			y++
+		}
+		got := make(map[[2]int64]bool)
+		w.ForEachCell(func(x, y, turn int64) {
+			got[[2]int64{x, y}] = true
+		})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadCells() cells = %v, want %v", got, want)
+		}
+	})
+}
+
+func FuzzReadLife106(f *testing.F) {
+	addSamples(f, ".lif")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		w, err := ReadLife106(bytes.NewReader(data))
+		if err != nil {
+			checkError(t, err)
+			return
+		}
+
+		// Every line after the header lists a cell
+		want := make(map[[2]int64]bool)
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			x, errX := strconv.ParseInt(fields[0], 10, 64)
+			y, errY := strconv.ParseInt(fields[1], 10, 64)
+			if errX == nil && errY == nil {
+				want[[2]int64{x, y}] = true
+			}
+		}
+		got := make(map[[2]int64]bool)
+		w.ForEachCell(func(x, y, turn int64) {
+			got[[2]int64{x, y}] = true
+		})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadLife106() cells = %v, want %v", got, want)
+		}
+	})
+}
+
+func FuzzReadMacrocell(f *testing.F) {
+	addSamples(f, macrocellExtension)
+	f.Add([]byte("[M2] (golly 4.2)\n#R B3/S23\n#G 4\n.*$..*$***$\n4 1 0 0 0\n5 0 0 2 2\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		w, err := ReadMacrocell(bytes.NewReader(data), QuadtreeEngine)
+		if err != nil {
+			checkError(t, err)
+			return
+		}
+		// The map engine finds the same cells, when there are few enough
+		if tree, ok := w.(*internal.QuadWorld); ok && tree.Population() > 1<<16 {
+			return
+		}
+		cells, err := ReadMacrocell(bytes.NewReader(data), MapEngine)
+		if err != nil {
+			t.Fatalf("ReadMacrocell() with the map engine unexpected error: %v", err)
+		}
+		if got, want := cellsOf(cells), cellsOf(w); !reflect.DeepEqual(got, want) {
+			t.Fatalf("map engine cells = %v, want %v", got, want)
+		}
+
+		// Written and read again, the pattern keeps its cells, rule and turn
+		var out bytes.Buffer
+		if err := WriteMacrocell(cells, &out); err != nil {
+			if _, multi := cells.(types.MultiState); multi {
+				return
+			}
+			t.Fatalf("WriteMacrocell() unexpected error: %v", err)
+		}
+		again, err := ReadMacrocell(&out, QuadtreeEngine)
+		if err != nil {
+			t.Fatalf("ReadMacrocell() of written macrocell %q unexpected error: %v", out.String(), err)
+		}
+		if ruleOf(again) != ruleOf(w) || again.Turn() != w.Turn() {
+			t.Errorf("rule %s and turn %d read back as %s and %d", ruleOf(w), w.Turn(), ruleOf(again), again.Turn())
+		}
+		if got, want := cellsOf(again), cellsOf(w); !reflect.DeepEqual(got, want) {
+			t.Errorf("cells %v read back as %v", want, got)
+		}
+	})
+}
+
+func FuzzDecodeApgcode(f *testing.F) {
+	for _, code := range []string{"xs4_33", "xq4_153", "xp15_4r4z4r4", "zz_1yzwzz1", "xp3_co9nas0san9oczgoldlo0oldlogz1047210127401"} {
+		f.Add(code)
+	}
+	f.Fuzz(func(t *testing.T, code string) {
+		w, err := ReadApgcode(code)
+		if err != nil {
+			return
+		}
+
+		// Encoded and decoded again, the pattern keeps its cells
+		prefix, _, _ := strings.Cut(code, "_")
+		var cells [][2]int64
+		w.ForEachCell(func(x, y, turn int64) {
+			cells = append(cells, [2]int64{x, y})
+		})
+		again, err := ReadApgcode(prefix + "_" + EncodeWechsler(cells))
+		if err != nil {
+			t.Fatalf("ReadApgcode() of encoded %q unexpected error: %v", code, err)
+		}
+		if got, want := statesOf(again), statesOf(w); !reflect.DeepEqual(got, want) {
+			t.Errorf("cells %v read back as %v", want, got)
+		}
+	})
+}
+
+func FuzzReadPattern(f *testing.F) {
+	for _, extension := range SampleExtensions {
+		if extension != composeExtension {
+			addSamples(f, extension)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if _, err := ReadPattern(bytes.NewReader(data)); err != nil {
+			checkError(t, err)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// update rewrites the golden file from the current engines instead of
+// checking them against it: go test ./model -run Golden -update
+var update = flag.Bool("update", false, "rewrite testdata/golden.txt")
+
+// goldenGenerations are the generations whose cells are recorded for every
+// sample.
+var goldenGenerations = []int64{0, 1, 10, 100, 300}
+
+// goldenPath is the file holding the recorded hashes, one line for each
+// sample and generation: "<sample> <generation> <hash>".
+const goldenPath = "testdata/golden.txt"
+
+// hashOf returns a hash of the coordinates and states of the non-empty cells
+// of the world, which does not depend on the order the world lists them in.
+func hashOf(w types.World) string {
+	var cells []string
+	forEachState(w, func(x, y int64, state int) {
+		cells = append(cells, fmt.Sprintf("%d,%d,%d", x, y, state))
+	})
+	sort.Strings(cells)
+	sum := sha256.Sum256([]byte(strings.Join(cells, "\n")))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// goldenHashes runs every sample and returns the hash of its cells at each
+// golden generation, keyed by "<sample> <generation>".
+func goldenHashes(t *testing.T) (map[string]string, []string) {
+	t.Helper()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+	if err := os.Chdir(filepath.Join(originalWd, "..")); err != nil {
+		t.Fatalf("Failed to change to project directory: %v", err)
+	}
+	files, err := os.ReadDir("samples")
+	if err != nil {
+		t.Fatalf("Failed to list samples: %v", err)
+	}
+
+	hashes := make(map[string]string)
+	var keys []string
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		w, err := ReadWorld(name)
+		if err != nil {
+			t.Fatalf("ReadWorld(%q) unexpected error: %v", name, err)
+		}
+		for _, generation := range goldenGenerations {
+			for w.Turn() < generation {
+				w.Evolve()
+			}
+			key := fmt.Sprintf("%s %d", name, generation)
+			hashes[key] = hashOf(w)
+			keys = append(keys, key)
+		}
+	}
+	return hashes, keys
+}
+
+func TestGolden(t *testing.T) {
+	hashes, keys := goldenHashes(t)
+
+	if *update {
+		var content strings.Builder
+		for _, key := range keys {
+			fmt.Fprintf(&content, "%s %s\n", key, hashes[key])
+		}
+		if err := os.WriteFile(goldenPath, []byte(content.String()), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", goldenPath, err)
+		}
+		return
+	}
+
+	f, err := os.Open(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", goldenPath, err)
+	}
+	defer f.Close()
+	recorded := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			t.Fatalf("invalid line in %s: %q", goldenPath, scanner.Text())
+		}
+		key := fields[0] + " " + fields[1]
+		recorded[key] = true
+		got, found := hashes[key]
+		switch {
+		case !found:
+			t.Errorf("%s: sample in %s not found", key, goldenPath)
+		case got != fields[2]:
+			t.Errorf("%s: hash %s, want %s", key, got, fields[2])
+		}
+	}
+	for _, key := range keys {
+		if !recorded[key] {
+			t.Errorf("%s: no hash recorded, run the test with -update", key)
+		}
+	}
+}
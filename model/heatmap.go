@@ -0,0 +1,126 @@
+package model
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// maxHeatmapPixels is the number of pixels of the largest heatmap image, which
+// is held in memory before it is encoded.
+const maxHeatmapPixels = 1 << 24
+
+// HeatCount picks which activity count a heatmap shows.
+type HeatCount int
+
+// Activity counts.
+const (
+	// ChangeCount is the number of times a cell changed state.
+	ChangeCount HeatCount = iota
+	// AliveCount is the number of generations a cell was alive in.
+	AliveCount
+)
+
+// ParseHeatCount returns the count with the given name, "changes" or "alive".
+func ParseHeatCount(name string) (HeatCount, error) {
+	switch name {
+	case "changes":
+		return ChangeCount, nil
+	case "alive":
+		return AliveCount, nil
+	}
+	return 0, fmt.Errorf("unknown count %q (want changes or alive)", name)
+}
+
+// Pick returns the count it names among the alive and changes counts.
+func (h HeatCount) Pick(alive, changes int64) int64 {
+	if h == AliveCount {
+		return alive
+	}
+	return changes
+}
+
+// HeatLevel scales a count between 0 and 1 against the highest count, on a
+// logarithmic scale so that places seldom active still show.
+func HeatLevel(count, highest int64) float64 {
+	if count <= 0 || highest <= 0 {
+		return 0
+	}
+	return math.Log1p(float64(count)) / math.Log1p(float64(highest))
+}
+
+// heatStops are the colors of the heatmap from the lowest level to the
+// highest, evenly spaced.
+var heatStops = []color.RGBA{
+	{0x00, 0x00, 0x00, 0xff},
+	{0x80, 0x00, 0x00, 0xff},
+	{0xff, 0x00, 0x00, 0xff},
+	{0xff, 0x80, 0x00, 0xff},
+	{0xff, 0xff, 0x00, 0xff},
+	{0xff, 0xff, 0xff, 0xff},
+}
+
+// heatColor returns the color of a level between 0 and 1.
+func heatColor(level float64) color.RGBA {
+	position := level * float64(len(heatStops)-1)
+	i := min(int(position), len(heatStops)-2)
+	f := position - float64(i)
+	from, to := heatStops[i], heatStops[i+1]
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + f*(float64(b)-float64(a))))
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
+
+// WriteHeatmapPNG draws the activity of the world as a PNG image, over the
+// bounding box of the locations that have been active, with each cell a
+// square of scale pixels: black where the count is 0, then red, yellow and
+// white as it grows. Images of more than 2^24 pixels are refused.
+func WriteHeatmapPNG(w types.World, count HeatCount, scale int, out io.Writer) error {
+	reporter, ok := w.(types.ActivityReporter)
+	if !ok {
+		return fmt.Errorf("the world does not count activity")
+	}
+	if scale < 1 {
+		return fmt.Errorf("invalid scale %d", scale)
+	}
+	var left, top, right, bottom, highest int64
+	first := true
+	reporter.ForEachActivity(func(x, y int64, alive, changes int64) {
+		if first {
+			left, top, right, bottom = x, y, x, y
+			first = false
+		}
+		left, right = min(left, x), max(right, x)
+		top, bottom = min(top, y), max(bottom, y)
+		highest = max(highest, count.Pick(alive, changes))
+	})
+	if first {
+		return fmt.Errorf("the world has not been active")
+	}
+
+	// The sizes overflow to negative values for activity spread across the
+	// whole range of coordinates
+	s, width, height := int64(scale), right-left+1, bottom-top+1
+	if width <= 0 || height <= 0 || width > maxHeatmapPixels/s || height > maxHeatmapPixels/(width*s*s) {
+		return fmt.Errorf("heatmap of %d by %d cells at scale %d is larger than %d pixels", uint64(width), uint64(height), scale, maxHeatmapPixels)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(width*s), int(height*s)))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i+3] = 0xff
+	}
+	reporter.ForEachActivity(func(x, y int64, alive, changes int64) {
+		c := heatColor(HeatLevel(count.Pick(alive, changes), highest))
+		for py := (y - top) * s; py < (y-top+1)*s; py++ {
+			for px := (x - left) * s; px < (x-left+1)*s; px++ {
+				img.SetRGBA(int(px), int(py), c)
+			}
+		}
+	})
+	return png.Encode(out, img)
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestParseHeatCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    HeatCount
+		wantErr bool
+	}{
+		{name: "changes", want: ChangeCount},
+		{name: "alive", want: AliveCount},
+		{name: "births", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHeatCount(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseHeatCount(%q) = %v, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct {
+		count, highest int64
+		want           float64
+	}{
+		{0, 10, 0},
+		{10, 10, 1},
+		{3, 15, 0.5},
+		{5, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := HeatLevel(tt.count, tt.highest); got != tt.want {
+			t.Errorf("HeatLevel(%d, %d) = %v, want %v", tt.count, tt.highest, got, tt.want)
+		}
+	}
+}
+
+func TestWriteHeatmapPNG(t *testing.T) {
+	// A blinker, whose center never changes
+	w := NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.AddCellIn(2, 0, 0)
+	w.(types.ActivityReporter).TrackActivity(true)
+	out := &bytes.Buffer{}
+	if err := WriteHeatmapPNG(w, ChangeCount, 2, out); err == nil {
+		t.Error("WriteHeatmapPNG() expected an error before any generation")
+	}
+	w.Evolve()
+	w.Evolve()
+
+	tests := []struct {
+		name   string
+		count  HeatCount
+		center color.RGBA
+		corner color.RGBA
+	}{
+		{name: "changes", count: ChangeCount, center: heatStops[0], corner: heatStops[0]},
+		{name: "alive", count: AliveCount, center: heatStops[len(heatStops)-1], corner: heatStops[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			if err := WriteHeatmapPNG(w, tt.count, 2, out); err != nil {
+				t.Fatalf("WriteHeatmapPNG() unexpected error: %v", err)
+			}
+			img, err := png.Decode(out)
+			if err != nil {
+				t.Fatalf("png.Decode() unexpected error: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != 6 || size.Y != 6 {
+				t.Fatalf("image is %dx%d, want 6x6", size.X, size.Y)
+			}
+			// The blinker spans (0,-1) -> (2,1), so its center is the middle square
+			if got := color.RGBAModel.Convert(img.At(3, 3)); got != tt.center {
+				t.Errorf("center = %v, want %v", got, tt.center)
+			}
+			if got := color.RGBAModel.Convert(img.At(0, 0)); got != tt.corner {
+				t.Errorf("corner = %v, want %v", got, tt.corner)
+			}
+			// The ends changed twice, as much as any cell
+			if got := color.RGBAModel.Convert(img.At(0, 2)); tt.count == ChangeCount && got != heatStops[len(heatStops)-1] {
+				t.Errorf("end = %v, want %v", got, heatStops[len(heatStops)-1])
+			}
+		})
+	}
+}
+
+func TestWriteHeatmapPNG_TooLarge(t *testing.T) {
+	// Two blinkers at the ends of the range of coordinates
+	w := NewWorld()
+	for _, x := range []int64{math.MinInt64 / 2, math.MaxInt64 / 2} {
+		w.AddCellIn(x, 0, 0)
+		w.AddCellIn(x+1, 0, 0)
+		w.AddCellIn(x+2, 0, 0)
+	}
+	w.(types.ActivityReporter).TrackActivity(true)
+	w.Evolve()
+
+	out := &bytes.Buffer{}
+	if err := WriteHeatmapPNG(w, ChangeCount, 1, out); err == nil {
+		t.Error("WriteHeatmapPNG() expected an error for activity spread too far")
+	}
+
+	blinker := NewWorld()
+	blinker.AddCellIn(0, 0, 0)
+	blinker.AddCellIn(1, 0, 0)
+	blinker.AddCellIn(2, 0, 0)
+	blinker.(types.ActivityReporter).TrackActivity(true)
+	blinker.Evolve()
+	if err := WriteHeatmapPNG(blinker, ChangeCount, 1<<12, out); err == nil {
+		t.Error("WriteHeatmapPNG() expected an error for a scale too large")
+	}
+	if err := WriteHeatmapPNG(blinker, ChangeCount, 1<<10, out); err != nil {
+		t.Errorf("WriteHeatmapPNG() unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package internal
+
+// counts is how often a location has been alive and how often it changed.
+type counts struct {
+	alive, changes int64
+}
+
+// activity keeps counts for every location that has been alive or changed,
+// which can be drawn as a heatmap of where a pattern has been. It counts
+// nothing until it is switched on.
+type activity struct {
+	on        bool
+	locations map[index]*counts
+}
+
+// track switches the counting on, or off, forgetting the counts.
+func (a *activity) track(on bool) {
+	a.on = on
+	if !on {
+		a.locations = nil
+	}
+}
+
+// at returns the counts of the location, creating them if needed.
+func (a *activity) at(location index) *counts {
+	if a.locations == nil {
+		a.locations = make(map[index]*counts)
+	}
+	c, found := a.locations[location]
+	if !found {
+		c = &counts{}
+		a.locations[location] = c
+	}
+	return c
+}
+
+// changed counts a change of the cell at the location, if counting.
+func (a *activity) changed(location index) {
+	if a.on {
+		a.at(location).changes++
+	}
+}
+
+// lived counts a generation the cell at the location was alive in, if
+// counting.
+func (a *activity) lived(location index) {
+	if a.on {
+		a.at(location).alive++
+	}
+}
+
+// of returns the counts of the location.
+func (a *activity) of(x, y int64) (alive, changes int64) {
+	if c, found := a.locations[index{x, y}]; found {
+		return c.alive, c.changes
+	}
+	return 0, 0
+}
+
+// forEach calls fn with the counts of every location that has been alive or
+// changed.
+func (a *activity) forEach(fn func(x, y int64, alive, changes int64)) {
+	for location, c := range a.locations {
+		fn(location.x, location.y, c.alive, c.changes)
+	}
+}
@@ -0,0 +1,49 @@
+package internal
+
+import "testing"
+
+func TestWorld_Activity(t *testing.T) {
+	// A blinker, two generations on
+	w := NewWorld()
+	for x := int64(0); x < 3; x++ {
+		w.AddCellIn(x, 0, 0)
+	}
+	w.Evolve()
+	w.TrackActivity(true)
+	w.Evolve()
+	w.Evolve()
+
+	tests := []struct {
+		name         string
+		x, y         int64
+		alive, count int64
+	}{
+		{name: "center", x: 1, y: 0, alive: 2, count: 0},
+		{name: "end", x: 0, y: 0, alive: 1, count: 2},
+		{name: "top", x: 1, y: -1, alive: 1, count: 2},
+		{name: "outside", x: 5, y: 5, alive: 0, count: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alive, changes := w.ActivityOf(tt.x, tt.y)
+			if alive != tt.alive || changes != tt.count {
+				t.Errorf("ActivityOf(%d, %d) = %d, %d, want %d, %d", tt.x, tt.y, alive, changes, tt.alive, tt.count)
+			}
+		})
+	}
+
+	locations := 0
+	w.ForEachActivity(func(x, y int64, alive, changes int64) {
+		locations++
+	})
+	if locations != 5 {
+		t.Errorf("ForEachActivity() visited %d locations, want 5", locations)
+	}
+
+	// Stopping forgets the counts, and nothing is counted until started
+	w.TrackActivity(false)
+	w.Evolve()
+	w.ForEachActivity(func(x, y int64, alive, changes int64) {
+		t.Errorf("ForEachActivity() visited (%d,%d) while not counting", x, y)
+	})
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"fmt"
+)
+
+// antDirections is the number of ant states per color: no ant, or an ant
+// facing north, east, south or west, the order of vonNeumannNeighborhood.
+const antDirections = 5
+
+// antGlyphs are drawn for an ant facing north, east, south and west.
+const antGlyphs = "^>v<"
+
+// Ant is Langton's Ant, generalized to any number of colors. An ant turns
+// according to the color of its cell (R for right, L for left, U for back and
+// N for no turn), moves the cell to the next color and steps forward.
+//
+// The ant is encoded in the cell states so that it runs as an Automaton: the
+// state of a cell is its color times antDirections plus the direction of the
+// ant on it, if any. A cell takes the ant of the von Neumann neighbor whose ant
+// steps into it. When two ants step into the same cell only one remains.
+type Ant struct {
+	turns string
+}
+
+// NewAnt creates an ant with a turn for each color, such as "RL" for
+// Langton's original ant.
+func NewAnt(turns string) (*Ant, error) {
+	if len(turns) < 2 || len(turns)*antDirections > maxStates {
+		return nil, fmt.Errorf("an ant needs between 2 and %d turns", maxStates/antDirections)
+	}
+	for _, t := range turns {
+		if t != 'L' && t != 'R' && t != 'U' && t != 'N' {
+			return nil, fmt.Errorf("invalid turn %q", t)
+		}
+	}
+	return &Ant{turns: turns}, nil
+}
+
+// Name returns "Langtons-Ant", with the turns when they are not "RL".
+func (a *Ant) Name() string {
+	if a.turns == "RL" {
+		return "Langtons-Ant"
+	}
+	return "Langtons-Ant:" + a.turns
+}
+
+// States returns the number of colors times antDirections.
+func (a *Ant) States() int {
+	return len(a.turns) * antDirections
+}
+
+// Neighborhood returns the von Neumann neighborhood, where ants come from.
+func (a *Ant) Neighborhood() []index {
+	return vonNeumannNeighborhood
+}
+
+// Next moves the ant away from a cell, changing its color, and brings in the
+// ant of a neighbor stepping into it.
+func (a *Ant) Next(state int, neighbors []int) int {
+	color, direction := state/antDirections, state%antDirections
+	if direction != 0 {
+		color = (color + 1) % len(a.turns)
+	}
+	for i, n := range neighbors {
+		nColor, nDirection := n/antDirections, n%antDirections
+		// Neighbor i lies in direction i+1, so its ant arrives facing the opposite way
+		if nDirection != 0 && a.turn(nDirection, nColor) == opposite(i+1) {
+			return color*antDirections + opposite(i+1)
+		}
+	}
+	return color * antDirections
+}
+
+// turn returns the direction of an ant facing the given direction after it
+// turns on a cell of the given color.
+func (a *Ant) turn(direction, color int) int {
+	switch a.turns[color] {
+	case 'R':
+		return direction%4 + 1
+	case 'L':
+		return (direction+2)%4 + 1
+	case 'U':
+		return opposite(direction)
+	}
+	return direction
+}
+
+// opposite returns the direction opposite to the given one.
+func opposite(direction int) int {
+	return (direction+1)%4 + 1
+}
+
+// Glyph returns an arrow for an ant, or a glyph for the color of a cell.
+func (a *Ant) Glyph(state int) byte {
+	color, direction := state/antDirections, state%antDirections
+	if direction != 0 {
+		return antGlyphs[direction-1]
+	}
+	return stateGlyphs[(color-1)%len(stateGlyphs)]
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// Automaton defines a cellular automaton: the states of its cells, the
+// neighborhood each cell looks at, and the transition to the next generation.
+// State 0 is the empty background, and an empty cell surrounded by empty cells
+// must stay empty, so only the cells near occupied ones need to be evaluated.
+type Automaton interface {
+	// Name returns the rulestring that selects the automaton.
+	Name() string
+	// States returns the number of cell states, counting the empty state.
+	States() int
+	// Neighborhood returns the offsets of the neighbors of a cell.
+	Neighborhood() []index
+	// Next returns the state of a cell in the next generation, from its
+	// state and the states of its neighbors, in the order of Neighborhood.
+	Next(state int, neighbors []int) int
+	// Glyph returns the character drawn for a non-empty state.
+	Glyph(state int) byte
+}
+
+// Engine is a world that follows an automaton.
+type Engine interface {
+	types.World
+	types.MultiState
+	types.RuleReporter
+	types.ChangeReporter
+	types.StatsRecorder
+	types.ActivityReporter
+	// SetTurn sets the current generation number.
+	SetTurn(turn int64)
+	// AddCells adds living cells at the given coordinates, all born at the
+	// given turn.
+	AddCells(cells [][2]int64, turn int64)
+}
+
+// Neighborhoods used by the built-in automata.
+var (
+	mooreNeighborhood = []index{
+		{-1, -1}, {0, -1}, {1, -1},
+		{-1, 0}, {1, 0},
+		{-1, 1}, {0, 1}, {1, 1},
+	}
+	vonNeumannNeighborhood = []index{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	// The hexagonal grid is stored sheared: of the Moore neighbors, the top
+	// right and bottom left cells are left out
+	hexagonalNeighborhood = []index{
+		{-1, -1}, {0, -1},
+		{-1, 0}, {1, 0},
+		{0, 1}, {1, 1},
+	}
+)
+
+// ParseAutomaton returns the automaton selected by a rulestring: "WireWorld",
+// "Langtons-Ant" with optional turns such as "Langtons-Ant:RLR", a Larger
+// than Life rule accepted by ParseLtL, or a Life-like or Generations rule
+// accepted by ParseRule.
+func ParseAutomaton(rule string) (Automaton, error) {
+	name := strings.ToLower(strings.TrimSpace(rule))
+	switch {
+	case name == "wireworld":
+		return Wireworld{}, nil
+	case name == "langtons-ant":
+		return NewAnt("RL")
+	case strings.HasPrefix(name, "langtons-ant:"):
+		ant, err := NewAnt(strings.ToUpper(name[len("langtons-ant:"):]))
+		if err != nil {
+			return nil, fmt.Errorf("unsupported rule %q: %w", rule, err)
+		}
+		return ant, nil
+	case len(name) > 1 && name[0] == 'r' && name[1] >= '0' && name[1] <= '9':
+		return ParseLtL(rule)
+	}
+	return ParseRule(rule)
+}
+
+// NewEngine creates an empty world following the automaton. Life-like and
+// Generations rules get the World engine, which counts neighbors, or the
+// HexWorld engine for the hexagonal neighborhood, Larger than
+// Life rules get the LtLWorld engine, and the others get the general
+// AutomatonWorld.
+func NewEngine(a Automaton) Engine {
+	switch rule := a.(type) {
+	case *Rule:
+		if rule.shape == hexagonalShape {
+			return NewHexWorld(rule)
+		}
+		return NewWorldWithRule(rule)
+	case *LtLRule:
+		return NewLtLWorld(rule)
+	}
+	return NewAutomatonWorld(a)
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestParseAutomaton(t *testing.T) {
+	tests := []struct {
+		rule       string
+		wantName   string
+		wantStates int
+		wantErr    bool
+	}{
+		{rule: "WireWorld", wantName: "WireWorld", wantStates: 4},
+		{rule: "wireworld", wantName: "WireWorld", wantStates: 4},
+		{rule: "Langtons-Ant", wantName: "Langtons-Ant", wantStates: 10},
+		{rule: "langtons-ant:rlr", wantName: "Langtons-Ant:RLR", wantStates: 15},
+		{rule: "B36/S23", wantName: "B36/S23", wantStates: 2},
+		{rule: "Langtons-Ant:R", wantErr: true},
+		{rule: "Langtons-Ant:RX", wantErr: true},
+		{rule: "Brain", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			a, err := ParseAutomaton(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseAutomaton(%q) = %s, want an error", tt.rule, a.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAutomaton(%q) unexpected error: %v", tt.rule, err)
+			}
+			if a.Name() != tt.wantName || a.States() != tt.wantStates {
+				t.Errorf("ParseAutomaton(%q) = %s with %d states, want %s with %d",
+					tt.rule, a.Name(), a.States(), tt.wantName, tt.wantStates)
+			}
+		})
+	}
+}
+
+func TestWireworld_Wire(t *testing.T) {
+	w := NewAutomatonWorld(Wireworld{})
+	for x := int64(0); x < 10; x++ {
+		w.SetStateIn(x, 0, wireConductor, 0)
+	}
+	w.SetStateIn(1, 0, wireHead, 0)
+	w.SetStateIn(0, 0, wireTail, 0)
+
+	for i := int64(1); i <= 5; i++ {
+		w.Evolve()
+		if w.StateOf(1+i, 0) != wireHead || w.StateOf(i, 0) != wireTail || w.StateOf(i-1, 0) != wireConductor {
+			t.Fatalf("generation %d: electron not at %d: %q", i, 1+i, w.WindowContent(index{0, 0}, index{9, 0}))
+		}
+	}
+	if w.changes != 3 {
+		t.Errorf("changes = %d, want 3", w.changes)
+	}
+	if w.lastChanges[index{6, 0}] != true || w.lastChanges[index{5, 0}] != false || len(w.lastChanges) != 2 {
+		t.Errorf("lastChanges = %v, want the head moving from 5 to 6", w.lastChanges)
+	}
+}
+
+func TestWireworld_Diode(t *testing.T) {
+	// Electrons pass a diode from left to right but not from right to left
+	diode := func(head, tail int64) *AutomatonWorld {
+		w := NewAutomatonWorld(Wireworld{})
+		for x := int64(0); x < 12; x++ {
+			if x != 5 && x != 6 {
+				w.SetStateIn(x, 1, wireConductor, 0)
+			}
+		}
+		for _, c := range [][2]int64{{5, 0}, {6, 0}, {5, 2}, {6, 2}, {5, 1}} {
+			w.SetStateIn(c[0], c[1], wireConductor, 0)
+		}
+		w.SetStateIn(head, 1, wireHead, 0)
+		w.SetStateIn(tail, 1, wireTail, 0)
+		return w
+	}
+
+	forward := diode(1, 0)
+	backward := diode(10, 11)
+	reached := map[string]bool{}
+	for i := 0; i < 12; i++ {
+		forward.Evolve()
+		backward.Evolve()
+		if forward.StateOf(10, 1) == wireHead {
+			reached["forward"] = true
+		}
+		if backward.StateOf(1, 1) == wireHead {
+			reached["backward"] = true
+		}
+	}
+	if !reached["forward"] || reached["backward"] {
+		t.Errorf("electrons reached the other end: %v, want forward only", reached)
+	}
+}
+
+// antOf returns the position and direction of the only ant in the world.
+func antOf(t *testing.T, w *AutomatonWorld) (index, int) {
+	t.Helper()
+	var (
+		location  index
+		direction int
+		ants      int
+	)
+	w.ForEachState(func(x, y int64, state int) {
+		if state%antDirections != 0 {
+			location, direction = index{x, y}, state%antDirections
+			ants++
+		}
+	})
+	if ants != 1 {
+		t.Fatalf("found %d ants, want 1", ants)
+	}
+	return location, direction
+}
+
+func TestAnt_FirstSteps(t *testing.T) {
+	ant, _ := NewAnt("RL")
+	w := NewAutomatonWorld(ant)
+	w.SetStateIn(0, 0, 1, 0) // Facing north on a white cell
+
+	for i := 0; i < 5; i++ {
+		w.Evolve()
+	}
+	location, direction := antOf(t, w)
+	if location != (index{-1, 0}) || direction != 4 {
+		t.Errorf("ant at %v facing %d, want (-1,0) facing west (4)", location, direction)
+	}
+	for _, c := range []index{{1, 0}, {1, 1}, {0, 1}} {
+		if w.StateOf(c.x, c.y) != antDirections {
+			t.Errorf("cell %v has state %d, want black (%d)", c, w.StateOf(c.x, c.y), antDirections)
+		}
+	}
+	if w.StateOf(0, 0) != 0 {
+		t.Errorf("cell (0,0) has state %d, want white again", w.StateOf(0, 0))
+	}
+}
+
+func TestAnt_Highway(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the ant highway in short mode")
+	}
+	ant, _ := NewAnt("RL")
+	w := NewAutomatonWorld(ant)
+	w.SetStateIn(0, 0, 1, 0)
+
+	// The highway starts near step 10,000 and repeats every 104 steps,
+	// moving the ant two cells diagonally
+	for i := 0; i < 11000; i++ {
+		w.Evolve()
+	}
+	before, _ := antOf(t, w)
+	for i := 0; i < 104; i++ {
+		w.Evolve()
+	}
+	after, _ := antOf(t, w)
+	dx, dy := after.x-before.x, after.y-before.y
+	if (dx != 2 && dx != -2) || (dy != 2 && dy != -2) {
+		t.Errorf("ant moved by (%d,%d) in 104 steps, want a diagonal of 2", dx, dy)
+	}
+}
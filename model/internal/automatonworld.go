@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// AutomatonWorld is a world following any Automaton, which suits automata that
+// cannot be reduced to a neighbor count. It keeps the non-empty cells in a map
+// and evaluates the transition of the cells that changed in the previous
+// generation and of the cells next to them: a cell whose neighborhood did not
+// change keeps its state, as it did in the previous generation.
+type AutomatonWorld struct {
+	bounds
+	automaton   Automaton
+	cells       map[index]*Cell
+	active      map[index]bool
+	turn        int64
+	changes     int
+	lastChanges map[index]bool
+	history     history
+	activity    activity
+	start       time.Time
+}
+
+// NewAutomatonWorld creates an empty world following the automaton.
+func NewAutomatonWorld(a Automaton) *AutomatonWorld {
+	return &AutomatonWorld{
+		automaton: a,
+		cells:     make(map[index]*Cell),
+		active:    make(map[index]bool),
+		start:     time.Now(),
+	}
+}
+
+// AddCellIn puts a cell in state 1 at the specified coordinates.
+func (w *AutomatonWorld) AddCellIn(x, y, turn int64) {
+	w.SetStateIn(x, y, 1, turn)
+}
+
+// RemoveCellIn empties the cell at the specified coordinates.
+func (w *AutomatonWorld) RemoveCellIn(x, y int64) {
+	if _, found := w.cells[index{x, y}]; !found {
+		return
+	}
+	delete(w.cells, index{x, y})
+	w.active[index{x, y}] = true
+	w.vacate(index{x, y})
+	w.settle()
+}
+
+// SetStateIn puts a cell in the given state at the specified coordinates, or
+// empties it for state 0.
+func (w *AutomatonWorld) SetStateIn(x, y int64, state int, turn int64) {
+	if state <= 0 {
+		w.RemoveCellIn(x, y)
+		return
+	}
+	if _, found := w.cells[index{x, y}]; !found {
+		w.occupy(index{x, y})
+	}
+	w.cells[index{x, y}] = &Cell{birthTurn: turn, state: state}
+	w.active[index{x, y}] = true
+}
+
+// AddCells puts a cell in state 1 at each of the given coordinates, all born
+// at the given turn, and finds the bounding box once they are all in.
+func (w *AutomatonWorld) AddCells(cells [][2]int64, turn int64) {
+	for _, c := range cells {
+		w.cells[index{c[0], c[1]}] = &Cell{birthTurn: turn, state: 1}
+		w.active[index{c[0], c[1]}] = true
+	}
+	w.recount(w.cells)
+}
+
+// IsAlive returns true if the cell at the specified coordinates is in state 1.
+func (w AutomatonWorld) IsAlive(x, y int64) bool {
+	return w.StateOf(x, y) == 1
+}
+
+// StateOf returns the state of the cell at the specified coordinates.
+func (w AutomatonWorld) StateOf(x, y int64) int {
+	if c, found := w.cells[index{x, y}]; found {
+		return c.state
+	}
+	return 0
+}
+
+// States returns the number of cell states of the automaton.
+func (w AutomatonWorld) States() int {
+	return w.automaton.States()
+}
+
+// Rule returns the rulestring of the automaton.
+func (w AutomatonWorld) Rule() string {
+	return w.automaton.Name()
+}
+
+// Turn returns the current generation number.
+func (w AutomatonWorld) Turn() int64 {
+	return w.turn
+}
+
+// SetTurn sets the current generation number.
+func (w *AutomatonWorld) SetTurn(turn int64) {
+	w.turn = turn
+}
+
+// ForEachCell calls fn with the coordinates and birth turn of every cell in
+// state 1.
+func (w AutomatonWorld) ForEachCell(fn func(x, y, turn int64)) {
+	for location, c := range w.cells {
+		if c.state == 1 {
+			fn(location.x, location.y, c.birthTurn)
+		}
+	}
+}
+
+// ForEachState calls fn with the coordinates and state of every non-empty cell.
+func (w AutomatonWorld) ForEachState(fn func(x, y int64, state int)) {
+	for location, c := range w.cells {
+		fn(location.x, location.y, c.state)
+	}
+}
+
+// LastChanges calls fn for every cell that entered (born is true) or left
+// state 1 in the last generation.
+func (w AutomatonWorld) LastChanges(fn func(x, y int64, born bool)) {
+	for location, born := range w.lastChanges {
+		fn(location.x, location.y, born)
+	}
+}
+
+// Evolve advances the world by one generation, applying the automaton's
+// transition to every cell that could change.
+func (w *AutomatonWorld) Evolve() {
+	neighborhood := w.automaton.Neighborhood()
+	neighbors := make([]int, len(neighborhood))
+	next := make(map[index]int)
+	evaluate := func(location index) {
+		if _, done := next[location]; done {
+			return
+		}
+		for i, offset := range neighborhood {
+			neighbors[i] = w.StateOf(location.x+offset.x, location.y+offset.y)
+		}
+		next[location] = w.automaton.Next(w.StateOf(location.x, location.y), neighbors)
+	}
+	for location := range w.active {
+		evaluate(location)
+		// The cells that have this one as a neighbor
+		for _, offset := range neighborhood {
+			evaluate(index{location.x - offset.x, location.y - offset.y})
+		}
+	}
+
+	w.apply(next)
+}
+
+// apply moves the world to the next generation, where the cells in next take
+// their new states and the others keep theirs, and records its stats and
+// activity.
+func (w *AutomatonWorld) apply(next map[index]int) {
+	w.turn++
+	w.changes = 0
+	w.lastChanges = make(map[index]bool)
+	w.active = make(map[index]bool)
+	for location, state := range next {
+		old := w.StateOf(location.x, location.y)
+		if state == old {
+			continue
+		}
+		w.changes++
+		w.activity.changed(location)
+		w.active[location] = true
+		if state == 1 || old == 1 {
+			w.lastChanges[location] = state == 1
+		}
+		if state == 0 {
+			delete(w.cells, location)
+			w.vacate(location)
+		} else if old == 0 {
+			w.cells[location] = &Cell{birthTurn: w.turn, state: state}
+			w.occupy(location)
+		} else {
+			w.cells[location].state = state
+		}
+	}
+	w.settle()
+
+	s := types.Stats{Turn: w.turn}
+	for location, c := range w.cells {
+		if c.state == 1 {
+			s.Population++
+			w.activity.lived(location)
+		}
+	}
+	for _, born := range w.lastChanges {
+		if born {
+			s.Births++
+		} else {
+			s.Deaths++
+		}
+	}
+	s.Width, s.Height = boxSize(len(w.cells), w.topLeft, w.bottomRight)
+	w.history.record(s)
+}
+
+// History returns the stats of the last generations, oldest first.
+func (w *AutomatonWorld) History() []types.Stats {
+	return w.history.all()
+}
+
+// TrackActivity starts counting, for every location, the generations its
+// cell is alive in and the times it changes, or stops and forgets the counts.
+func (w *AutomatonWorld) TrackActivity(on bool) {
+	w.activity.track(on)
+}
+
+// ActivityOf returns the number of generations the cell at the specified
+// coordinates was in state 1 and the number of times it changed state.
+func (w *AutomatonWorld) ActivityOf(x, y int64) (alive, changes int64) {
+	return w.activity.of(x, y)
+}
+
+// ForEachActivity calls fn with the coordinates and counts of every location
+// that has been in state 1 or changed.
+func (w *AutomatonWorld) ForEachActivity(fn func(x, y int64, alive, changes int64)) {
+	w.activity.forEach(fn)
+}
+
+// WindowContent returns a string representation of the world within the given bounds.
+func (w AutomatonWorld) WindowContent(topLeft, bottomRight types.Index) string {
+	buffer := &strings.Builder{}
+	fmt.Fprintf(buffer, "Turn: %d  Rule: %s  Cells: %d  Limits: (%d,%d) -> (%d, %d) Changes: %d Age: %s    \n",
+		w.turn,
+		w.automaton.Name(),
+		len(w.cells),
+		w.topLeft.x,
+		w.topLeft.y,
+		w.bottomRight.x,
+		w.bottomRight.y,
+		w.changes,
+		time.Since(w.start))
+	for y := topLeft.Y(); y <= bottomRight.Y(); y++ {
+		for x := topLeft.X(); x <= bottomRight.X(); x++ {
+			if state := w.StateOf(x, y); state != 0 {
+				buffer.WriteByte(w.automaton.Glyph(state))
+			} else {
+				buffer.WriteByte(' ')
+			}
+		}
+		fmt.Fprintln(buffer)
+	}
+	return buffer.String()
+}
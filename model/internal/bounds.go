@@ -0,0 +1,81 @@
+package internal
+
+// bounds keeps the bounding box of the cells of a world up to date as cells
+// come and go, without looking at every cell: it counts the cells in every row
+// and column, so the box only has to be searched for again when the last cell
+// of an edge row or column goes, and then only among the rows and columns.
+type bounds struct {
+	rows, columns        map[int64]int
+	topLeft, bottomRight index
+	// stale is set when an edge of the box may have moved in.
+	stale bool
+}
+
+// occupy counts a cell at a location that was empty.
+func (b *bounds) occupy(location index) {
+	if b.rows == nil {
+		b.rows = make(map[int64]int)
+		b.columns = make(map[int64]int)
+	}
+	if len(b.rows) == 0 {
+		b.topLeft, b.bottomRight = location, location
+	} else {
+		b.topLeft = index{min(b.topLeft.x, location.x), min(b.topLeft.y, location.y)}
+		b.bottomRight = index{max(b.bottomRight.x, location.x), max(b.bottomRight.y, location.y)}
+	}
+	b.rows[location.y]++
+	b.columns[location.x]++
+}
+
+// vacate stops counting the cell at a location that is now empty.
+func (b *bounds) vacate(location index) {
+	if b.rows[location.y]--; b.rows[location.y] == 0 {
+		delete(b.rows, location.y)
+		b.stale = b.stale || location.y == b.topLeft.y || location.y == b.bottomRight.y
+	}
+	if b.columns[location.x]--; b.columns[location.x] == 0 {
+		delete(b.columns, location.x)
+		b.stale = b.stale || location.x == b.topLeft.x || location.x == b.bottomRight.x
+	}
+}
+
+// settle moves the edges of the box in after cells on them were vacated. The
+// box of no cells is at (0,0).
+func (b *bounds) settle() {
+	if !b.stale {
+		return
+	}
+	b.stale = false
+	if len(b.rows) == 0 {
+		b.topLeft, b.bottomRight = index{}, index{}
+		return
+	}
+	b.topLeft.y, b.bottomRight.y = extent(b.rows)
+	b.topLeft.x, b.bottomRight.x = extent(b.columns)
+}
+
+// recount counts all the cells again, for cells put into a world without
+// being occupied one by one.
+func (b *bounds) recount(cells map[index]*Cell) {
+	*b = bounds{rows: make(map[int64]int), columns: make(map[int64]int)}
+	for location := range cells {
+		b.rows[location.y]++
+		b.columns[location.x]++
+	}
+	b.stale = true
+	b.settle()
+}
+
+// extent returns the lowest and highest keys of counts, which is not empty.
+func extent(counts map[int64]int) (low, high int64) {
+	first := true
+	for key := range counts {
+		if first {
+			low, high = key, key
+			first = false
+			continue
+		}
+		low, high = min(low, key), max(high, key)
+	}
+	return low, high
+}
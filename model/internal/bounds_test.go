@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// rescan finds the bounding box of the cells by looking at every one of them,
+// as worlds did before they kept their bounds.
+func rescan(cells map[index]*Cell) (topLeft, bottomRight index) {
+	first := true
+	for location := range cells {
+		if first {
+			topLeft, bottomRight = location, location
+			first = false
+			continue
+		}
+		topLeft = index{min(topLeft.x, location.x), min(topLeft.y, location.y)}
+		bottomRight = index{max(bottomRight.x, location.x), max(bottomRight.y, location.y)}
+	}
+	return topLeft, bottomRight
+}
+
+func TestWorld_BordersAfterRemoval(t *testing.T) {
+	tests := []struct {
+		name            string
+		remove          [2]int64
+		wantTopLeft     index
+		wantBottomRight index
+	}{
+		{name: "inside", remove: [2]int64{1, 1}, wantTopLeft: index{0, 0}, wantBottomRight: index{4, 3}},
+		{name: "left edge", remove: [2]int64{0, 0}, wantTopLeft: index{1, 0}, wantBottomRight: index{4, 3}},
+		{name: "corner", remove: [2]int64{4, 3}, wantTopLeft: index{0, 0}, wantBottomRight: index{4, 2}},
+		{name: "shared edge", remove: [2]int64{4, 0}, wantTopLeft: index{0, 0}, wantBottomRight: index{4, 3}},
+		{name: "empty cell", remove: [2]int64{9, 9}, wantTopLeft: index{0, 0}, wantBottomRight: index{4, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld()
+			w.AddCells([][2]int64{{0, 0}, {1, 1}, {3, 2}, {4, 0}, {4, 3}, {1, 0}}, 0)
+			w.RemoveCellIn(tt.remove[0], tt.remove[1])
+			if w.topLeft != tt.wantTopLeft || w.bottomRight != tt.wantBottomRight {
+				t.Errorf("borders = %v -> %v, want %v -> %v", w.topLeft, w.bottomRight, tt.wantTopLeft, tt.wantBottomRight)
+			}
+		})
+	}
+
+	w := NewWorld()
+	w.AddCellIn(5, 5, 0)
+	w.AddCellIn(5, 5, 0)
+	w.RemoveCellIn(5, 5)
+	if w.topLeft != (index{}) || w.bottomRight != (index{}) {
+		t.Errorf("borders of an empty world = %v -> %v, want (0,0) -> (0,0)", w.topLeft, w.bottomRight)
+	}
+}
+
+func TestBounds_Evolve(t *testing.T) {
+	engines := map[string]Engine{
+		"World":          NewWorld(),
+		"AutomatonWorld": NewAutomatonWorld(ConwayRule),
+		"Generations":    NewWorldWithRule(mustParseRule(t, "B2/S/C4")),
+	}
+	for name, w := range engines {
+		t.Run(name, func(t *testing.T) {
+			soup(w, 24, w.States(), 3)
+			for i := 0; i < 200; i++ {
+				w.Evolve()
+				var cells map[index]*Cell
+				var topLeft, bottomRight index
+				switch w := w.(type) {
+				case *World:
+					cells, topLeft, bottomRight = w.cells, w.topLeft, w.bottomRight
+				case *AutomatonWorld:
+					cells, topLeft, bottomRight = w.cells, w.topLeft, w.bottomRight
+				}
+				wantTopLeft, wantBottomRight := rescan(cells)
+				if topLeft != wantTopLeft || bottomRight != wantBottomRight {
+					t.Fatalf("turn %d: borders = %v -> %v, want %v -> %v", w.Turn(), topLeft, bottomRight, wantTopLeft, wantBottomRight)
+				}
+			}
+		})
+	}
+}
+
+// mustParseRule parses a rulestring, failing the test if it is invalid.
+func mustParseRule(t *testing.T, rule string) *Rule {
+	t.Helper()
+	r, err := ParseRule(rule)
+	if err != nil {
+		t.Fatalf("ParseRule(%q) unexpected error: %v", rule, err)
+	}
+	return r
+}
+
+// randomCells returns n distinct random cells in a square with room for them.
+func randomCells(n int) [][2]int64 {
+	random := rand.New(rand.NewSource(1))
+	size := int64(2 * n)
+	seen := make(map[[2]int64]bool)
+	cells := make([][2]int64, 0, n)
+	for len(cells) < n {
+		c := [2]int64{random.Int63n(size), random.Int63n(size)}
+		if !seen[c] {
+			seen[c] = true
+			cells = append(cells, c)
+		}
+	}
+	return cells
+}
+
+// BenchmarkWorld_Load compares loading a pattern cell by cell, in bulk, and
+// cell by cell with the bounding box found again after every cell as it was
+// before worlds kept their bounds.
+func BenchmarkWorld_Load(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		cells := randomCells(n)
+		b.Run(fmt.Sprintf("AddCellIn/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w := NewWorld()
+				for _, c := range cells {
+					w.AddCellIn(c[0], c[1], 0)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("AddCells/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewWorld().AddCells(cells, 0)
+			}
+		})
+		b.Run(fmt.Sprintf("rescan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w := NewWorld()
+				for _, c := range cells {
+					w.cells[index{c[0], c[1]}] = newCell(0)
+					w.topLeft, w.bottomRight = rescan(w.cells)
+				}
+			}
+		})
+	}
+}
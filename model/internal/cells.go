@@ -9,15 +9,6 @@ import (
 	"github.com/daniel-munoz/life/types"
 )
 
-// Game of Life rules constants.
-// A cell is born with exactly 3 neighbors, survives with 2-3 neighbors,
-// and dies otherwise (underpopulation or overpopulation).
-const (
-	birthNeighborCount   = 3 // Cell is born with exactly 3 neighbors
-	minSurvivalNeighbors = 2 // Cell dies with fewer than 2 neighbors
-	maxSurvivalNeighbors = 3 // Cell dies with more than 3 neighbors
-)
-
 // index represents a 2D coordinate in the world grid.
 type index struct {
 	x, y int64
@@ -33,23 +24,29 @@ func (i index) Y() int64 {
 	return i.y
 }
 
-// Cell represents a living cell in the world with its birth turn recorded.
+// Cell represents a cell in the world with its birth turn recorded. Its state
+// is 1 while it is alive; under Generations rules, higher states are dying.
 type Cell struct {
 	birthTurn int64
+	state     int
 }
 
 // World represents the Game of Life universe containing all cells.
 type World struct {
-	cells                map[index]*Cell
-	topLeft, bottomRight index
-	turn                 int64
-	changes              int
-	start                time.Time
+	bounds
+	rule        *Rule
+	cells       map[index]*Cell
+	turn        int64
+	changes     int
+	lastChanges map[index]Change
+	history     history
+	activity    activity
+	start       time.Time
 }
 
 // newCell creates a new cell born at the specified turn.
 func newCell(turn int64) *Cell {
-	return &Cell{birthTurn: turn}
+	return &Cell{birthTurn: turn, state: 1}
 }
 
 // NewIndex creates a new coordinate index.
@@ -57,14 +54,19 @@ func NewIndex(x, y int64) types.Index {
 	return index{x: x, y: y}
 }
 
-// NewWorld creates an empty world ready for cells to be added.
+// NewWorld creates an empty world ready for cells to be added, following
+// Conway's rule.
 func NewWorld() *World {
+	return NewWorldWithRule(ConwayRule)
+}
+
+// NewWorldWithRule creates an empty world following the given rule.
+func NewWorldWithRule(rule *Rule) *World {
 	return &World{
-		cells:       make(map[index]*Cell),
-		topLeft:     index{0, 0},
-		bottomRight: index{0, 0},
-		turn:        0,
-		start:       time.Now(),
+		rule:  rule,
+		cells: make(map[index]*Cell),
+		turn:  0,
+		start: time.Now(),
 	}
 }
 
@@ -73,6 +75,85 @@ func (w World) GetCellIn(x, y int64) *Cell {
 	return w.cells[index{x: x, y: y}]
 }
 
+// IsAlive returns true if there is a living cell at the specified coordinates.
+// Dying cells of Generations rules are not alive.
+func (w World) IsAlive(x, y int64) bool {
+	c := w.GetCellIn(x, y)
+	return c != nil && c.state == 1
+}
+
+// Rule returns the rulestring of the rule followed by the world.
+func (w World) Rule() string {
+	return w.rule.String()
+}
+
+// States returns the number of cell states of the world's rule, counting the
+// dead state.
+func (w World) States() int {
+	return w.rule.states
+}
+
+// StateOf returns the state of the cell at the specified coordinates, 0 if
+// there is none.
+func (w World) StateOf(x, y int64) int {
+	if c := w.GetCellIn(x, y); c != nil {
+		return c.state
+	}
+	return 0
+}
+
+// SetStateIn puts a cell in the given state at the specified coordinates, or
+// removes it for state 0.
+func (w *World) SetStateIn(x, y int64, state int, turn int64) {
+	if state <= 0 {
+		w.RemoveCellIn(x, y)
+		return
+	}
+	w.put(index{x, y}, &Cell{birthTurn: turn, state: state})
+}
+
+// Turn returns the current generation number.
+func (w World) Turn() int64 {
+	return w.turn
+}
+
+// SetTurn sets the current generation number, for worlds restored or replicated
+// from elsewhere.
+func (w *World) SetTurn(turn int64) {
+	w.turn = turn
+}
+
+// ForEachCell calls fn with the coordinates and birth turn of every living cell.
+func (w World) ForEachCell(fn func(x, y, turn int64)) {
+	for location, c := range w.cells {
+		if c.state == 1 {
+			fn(location.x, location.y, c.birthTurn)
+		}
+	}
+}
+
+// ForEachState calls fn with the coordinates and state of every cell that is
+// not dead, including dying cells.
+func (w World) ForEachState(fn func(x, y int64, state int)) {
+	for location, c := range w.cells {
+		fn(location.x, location.y, c.state)
+	}
+}
+
+// liveCount returns the number of living cells.
+func (w World) liveCount() int {
+	if w.rule.states == 2 {
+		return len(w.cells)
+	}
+	count := 0
+	for _, c := range w.cells {
+		if c.state == 1 {
+			count++
+		}
+	}
+	return count
+}
+
 // ChangeType indicates whether a cell is being born or dying.
 type ChangeType int
 
@@ -80,6 +161,7 @@ type ChangeType int
 const (
 	BIRTH ChangeType = iota // A new cell is born
 	DEATH                   // An existing cell dies
+	DECAY                   // An existing cell moves to its next dying state
 )
 
 // Change represents a pending birth or death of a cell.
@@ -88,23 +170,65 @@ type Change struct {
 	reason ChangeType
 }
 
-// ApplyChanges applies all pending births and deaths to the world.
+// ApplyChanges applies all pending births and deaths to the world, counting
+// them in the activity of their locations. Births on living cells replace
+// them, and deaths of empty cells leave them empty.
 func (w *World) ApplyChanges(changes map[index]Change) {
 	for location, c := range changes {
+		w.activity.changed(location)
 		switch c.reason {
 		case BIRTH:
-			w.cells[location] = newCell(c.turn)
+			w.put(location, newCell(c.turn))
 		case DEATH:
-			delete(w.cells, location)
+			if _, found := w.cells[location]; found {
+				delete(w.cells, location)
+				w.vacate(location)
+			}
+		case DECAY:
+			w.cells[location].state++
 		}
 	}
-	w.recalculateBorders()
+	w.settle()
 }
 
 // AddCellIn adds a new cell at the specified coordinates.
 func (w *World) AddCellIn(x, y, turn int64) {
-	w.cells[index{x, y}] = newCell(turn)
-	w.recalculateBorders()
+	w.put(index{x, y}, newCell(turn))
+}
+
+// AddCells adds a new cell at each of the given coordinates, all born at the
+// given turn, and finds the bounding box once they are all in, which is
+// quicker than adding them one by one for large patterns.
+func (w *World) AddCells(cells [][2]int64, turn int64) {
+	for _, c := range cells {
+		w.cells[index{c[0], c[1]}] = newCell(turn)
+	}
+	w.recount(w.cells)
+}
+
+// put puts the cell at the location, replacing the cell there if any.
+func (w *World) put(location index, c *Cell) {
+	if _, found := w.cells[location]; !found {
+		w.occupy(location)
+	}
+	w.cells[location] = c
+}
+
+// RemoveCellIn removes the cell at the specified coordinates, if any.
+func (w *World) RemoveCellIn(x, y int64) {
+	if _, found := w.cells[index{x, y}]; !found {
+		return
+	}
+	delete(w.cells, index{x, y})
+	w.vacate(index{x, y})
+	w.settle()
+}
+
+// LastChanges calls fn for every cell born (born is true) or died in the last generation.
+func (w World) LastChanges(fn func(x, y int64, born bool)) {
+	for location, c := range w.lastChanges {
+		fn(location.x, location.y, c.reason == BIRTH)
+	}
 }
 
 // Print outputs the entire world to stdout.
@@ -122,7 +246,7 @@ func (w World) WindowContent(topLeft, bottomRight types.Index) string {
 	buffer := &strings.Builder{}
 	fmt.Fprintf(buffer, "Turn: %d  Live Cells: %d  Limits: (%d,%d) -> (%d, %d) Changes: %d Age: %s    \n",
 		w.turn,
-		len(w.cells),
+		w.liveCount(),
 		w.topLeft.x,
 		w.topLeft.y,
 		w.bottomRight.x,
@@ -134,9 +258,9 @@ func (w World) WindowContent(topLeft, bottomRight types.Index) string {
 		for x <= bottomRight.X() {
 			c := w.GetCellIn(x, y)
 			if c != nil {
-				fmt.Fprint(buffer, "x")
+				buffer.WriteByte(w.rule.Glyph(c.state))
 			} else {
-				fmt.Fprint(buffer, " ")
+				buffer.WriteByte(' ')
 			}
 			x++
 		}
@@ -147,33 +271,6 @@ func (w World) WindowContent(topLeft, bottomRight types.Index) string {
 	return buffer.String()
 }
 
-// recalculateBorders updates the world's bounding box based on current cells.
-func (w *World) recalculateBorders() {
-	var minX, maxX, minY, maxY int64
-	first := true
-	for location := range w.cells {
-		if first {
-			minX, minY, maxX, maxY = location.x, location.y, location.x, location.y
-			first = false
-			continue
-		}
-		if location.x < minX {
-			minX = location.x
-		}
-		if location.y < minY {
-			minY = location.y
-		}
-		if location.x > maxX {
-			maxX = location.x
-		}
-		if location.y > maxY {
-			maxY = location.y
-		}
-	}
-	w.topLeft = index{x: minX, y: minY}
-	w.bottomRight = index{x: maxX, y: maxY}
-}
-
 // countNeighborsOf counts living neighbors of a cell, using cache for efficiency.
 // The offset is subtracted from the count (1 if the cell itself is alive, 0 otherwise).
 func (w World) countNeighborsOf(location index, cache map[index]int, offset int) int {
@@ -181,40 +278,58 @@ func (w World) countNeighborsOf(location index, cache map[index]int, offset int)
 	if found {
 		return count
 	}
-	x := location.x - 1
-	for x <= location.x+1 {
-		y := location.y - 1
-		for y <= location.y+1 {
-			if w.GetCellIn(x, y) != nil {
-				count++
-			}
-			y++
+	if w.IsAlive(location.x, location.y) {
+		count++
+	}
+	for _, neighbor := range w.rule.Neighborhood() {
+		if w.IsAlive(location.x+neighbor.x, location.y+neighbor.y) {
+			count++
 		}
-		x++
 	}
 	cache[location] = count - offset
 	return count - offset
 }
 
-// analyze determines if a cell should be born or die based on Game of Life rules.
+// configurationOf returns the configuration of the living neighbors of a cell,
+// with bit i set when neighbor i of mooreNeighborhood is alive.
+func (w World) configurationOf(location index) uint8 {
+	var configuration uint8
+	for i, neighbor := range mooreNeighborhood {
+		if w.IsAlive(location.x+neighbor.x, location.y+neighbor.y) {
+			configuration |= 1 << i
+		}
+	}
+	return configuration
+}
+
+// analyze determines if a cell should be born, die or decay based on the
+// world's rule.
 func (w World) analyze(location index, turn int64, cache map[index]int, changes map[index]Change) {
 	_, cellHasChange := changes[location]
 	if cellHasChange {
 		return
 	}
 
-	cell := w.GetCellIn(location.x, location.y)
-	offset := 0
-	if cell != nil {
-		offset = 1
-	}
-	c := w.countNeighborsOf(location, cache, offset)
-
-	if c == birthNeighborCount && cell == nil {
-		changes[location] = Change{turn: turn, reason: BIRTH}
+	state := w.StateOf(location.x, location.y)
+	// Dying cells decay whatever their neighbors, so only the others count them
+	var next int
+	switch {
+	case state > 1:
+		next = w.rule.next(state, 0)
+	case w.rule.transitions != nil:
+		next = w.rule.nextIsotropic(state, w.configurationOf(location))
+	default:
+		next = w.rule.next(state, w.countNeighborsOf(location, cache, state))
 	}
-	if (c < minSurvivalNeighbors || c > maxSurvivalNeighbors) && cell != nil {
+	switch {
+	case next == state:
+		// No change
+	case next == 0:
 		changes[location] = Change{turn: turn, reason: DEATH}
+	case state == 0:
+		changes[location] = Change{turn: turn, reason: BIRTH}
+	default:
+		changes[location] = Change{turn: turn, reason: DECAY}
 	}
 }
 
@@ -240,5 +355,70 @@ func (w *World) Evolve() {
 	}
 	w.turn++
 	w.changes = len(changes)
+	w.lastChanges = changes
+	if w.rule.states > 2 {
+		w.lastChanges = w.aliveChanges(changes)
+	}
 	w.ApplyChanges(changes)
+	if w.activity.on {
+		for location, c := range w.cells {
+			if c.state == 1 {
+				w.activity.lived(location)
+			}
+		}
+	}
+	w.recordStats()
+}
+
+// recordStats adds the stats of the generation just made to the history.
+func (w *World) recordStats() {
+	s := types.Stats{Turn: w.turn, Population: int64(w.liveCount())}
+	for _, c := range w.lastChanges {
+		if c.reason == BIRTH {
+			s.Births++
+		} else {
+			s.Deaths++
+		}
+	}
+	s.Width, s.Height = boxSize(len(w.cells), w.topLeft, w.bottomRight)
+	w.history.record(s)
+}
+
+// History returns the stats of the last generations, oldest first.
+func (w *World) History() []types.Stats {
+	return w.history.all()
+}
+
+// TrackActivity starts counting, for every location, the generations its
+// cell is alive in and the times it changes, or stops and forgets the counts.
+func (w *World) TrackActivity(on bool) {
+	w.activity.track(on)
+}
+
+// ActivityOf returns the number of generations the cell at the specified
+// coordinates was alive in and the number of times it changed.
+func (w *World) ActivityOf(x, y int64) (alive, changes int64) {
+	return w.activity.of(x, y)
+}
+
+// ForEachActivity calls fn with the coordinates and counts of every location
+// that has been alive or changed.
+func (w *World) ForEachActivity(fn func(x, y int64, alive, changes int64)) {
+	w.activity.forEach(fn)
+}
+
+// aliveChanges keeps the changes that bring a cell to life or end its life,
+// before they are applied; a living cell that starts dying is reported as a
+// death, and dying cells that decay further or disappear are left out.
+func (w World) aliveChanges(changes map[index]Change) map[index]Change {
+	alive := make(map[index]Change)
+	for location, c := range changes {
+		switch {
+		case c.reason == BIRTH:
+			alive[location] = c
+		case w.IsAlive(location.x, location.y):
+			alive[location] = Change{turn: c.turn, reason: DEATH}
+		}
+	}
+	return alive
 }
@@ -326,7 +326,31 @@ func TestWorld_ApplyChanges(t *testing.T) {
 	}
 }
 
-func TestWorld_RecalculateBorders(t *testing.T) {
+func TestWorld_ApplyChanges_Redundant(t *testing.T) {
+	w := NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(10, 10, 0)
+
+	// A birth on a living cell counts it once, so its death empties its row
+	w.ApplyChanges(map[index]Change{{10, 10}: {turn: 1, reason: BIRTH}})
+	w.ApplyChanges(map[index]Change{{10, 10}: {turn: 2, reason: DEATH}})
+	if w.topLeft != (index{0, 0}) || w.bottomRight != (index{0, 0}) {
+		t.Errorf("box = %v -> %v, want (0,0) -> (0,0)", w.topLeft, w.bottomRight)
+	}
+
+	// The death of an empty cell leaves the counts alone
+	w.ApplyChanges(map[index]Change{{5, 5}: {turn: 3, reason: DEATH}})
+	if w.rows[5] != 0 || w.columns[5] != 0 {
+		t.Errorf("row and column counts = %d and %d, want 0", w.rows[5], w.columns[5])
+	}
+	w.ApplyChanges(map[index]Change{{5, 5}: {turn: 4, reason: BIRTH}})
+	w.ApplyChanges(map[index]Change{{5, 5}: {turn: 5, reason: DEATH}})
+	if w.topLeft != (index{0, 0}) || w.bottomRight != (index{0, 0}) {
+		t.Errorf("box = %v -> %v, want (0,0) -> (0,0)", w.topLeft, w.bottomRight)
+	}
+}
+
+func TestWorld_Borders(t *testing.T) {
 	tests := []struct {
 		name            string
 		cells           [][2]int64
@@ -363,20 +387,23 @@ func TestWorld_RecalculateBorders(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			w := NewWorld()
 
-			// Add cells
+			// Add cells one by one
 			for _, cell := range tt.cells {
 				w.AddCellIn(cell[0], cell[1], 0)
 			}
 
-			// Force recalculation
-			w.recalculateBorders()
+			// Add them all at once
+			bulk := NewWorld()
+			bulk.AddCells(tt.cells, 0)
 
 			// Verify borders
-			if w.topLeft != tt.wantTopLeft {
-				t.Errorf("topLeft = %v, want %v", w.topLeft, tt.wantTopLeft)
-			}
-			if w.bottomRight != tt.wantBottomRight {
-				t.Errorf("bottomRight = %v, want %v", w.bottomRight, tt.wantBottomRight)
+			for _, w := range []*World{w, bulk} {
+				if w.topLeft != tt.wantTopLeft {
+					t.Errorf("topLeft = %v, want %v", w.topLeft, tt.wantTopLeft)
+				}
+				if w.bottomRight != tt.wantBottomRight {
+					t.Errorf("bottomRight = %v, want %v", w.bottomRight, tt.wantBottomRight)
+				}
 			}
 		})
 	}
@@ -730,3 +757,76 @@ func TestWorld_WindowContent_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestWorld_Turn(t *testing.T) {
+	w := NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.AddCellIn(2, 0, 0)
+
+	if w.Turn() != 0 {
+		t.Errorf("Turn() = %d, want 0", w.Turn())
+	}
+	for i := int64(1); i <= 3; i++ {
+		w.Evolve()
+		if w.Turn() != i {
+			t.Errorf("Turn() after %d generations = %d, want %d", i, w.Turn(), i)
+		}
+	}
+}
+
+func TestWorld_RemoveCellIn(t *testing.T) {
+	w := NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(5, 3, 0)
+
+	w.RemoveCellIn(5, 3)
+	if w.IsAlive(5, 3) {
+		t.Error("RemoveCellIn(5, 3) did not remove the cell")
+	}
+	if !w.IsAlive(0, 0) {
+		t.Error("RemoveCellIn(5, 3) removed another cell")
+	}
+	if w.bottomRight != (index{0, 0}) {
+		t.Errorf("bottomRight = %v, want {0 0}", w.bottomRight)
+	}
+
+	// Removing an empty cell does nothing
+	w.RemoveCellIn(7, 7)
+	if len(w.cells) != 1 {
+		t.Errorf("Got %d cells, want 1", len(w.cells))
+	}
+}
+
+func TestWorld_LastChanges(t *testing.T) {
+	w := NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.AddCellIn(2, 0, 0)
+	w.Evolve()
+
+	born := make(map[index]bool)
+	died := make(map[index]bool)
+	w.LastChanges(func(x, y int64, b bool) {
+		if b {
+			born[index{x, y}] = true
+		} else {
+			died[index{x, y}] = true
+		}
+	})
+	if len(born) != 2 || !born[index{1, -1}] || !born[index{1, 1}] {
+		t.Errorf("born = %v, want {1,-1} and {1,1}", born)
+	}
+	if len(died) != 2 || !died[index{0, 0}] || !died[index{2, 0}] {
+		t.Errorf("died = %v, want {0,0} and {2,0}", died)
+	}
+}
+
+func TestWorld_SetTurn(t *testing.T) {
+	w := NewWorld()
+	w.SetTurn(41)
+	w.Evolve()
+	if w.Turn() != 42 {
+		t.Errorf("Turn() = %d, want 42", w.Turn())
+	}
+}
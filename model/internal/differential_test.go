@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// soupCell is a non-empty cell of a soup.
+type soupCell struct {
+	x, y  int64
+	state int
+}
+
+// soupCells returns the cells of a random square soup of the given size, in
+// states below states.
+func soupCells(size int64, states int, seed int64) []soupCell {
+	random := rand.New(rand.NewSource(seed))
+	var cells []soupCell
+	for y := int64(0); y < size; y++ {
+		for x := int64(0); x < size; x++ {
+			if random.Intn(2) == 0 {
+				cells = append(cells, soupCell{x, y, 1 + random.Intn(states-1)})
+			}
+		}
+	}
+	return cells
+}
+
+// stateWorld is a world whose cells can be set and listed with their states,
+// which is all the engines compared here need.
+type stateWorld interface {
+	types.World
+	types.MultiState
+}
+
+// engineMaker creates an empty engine, named for reports.
+type engineMaker struct {
+	name string
+	new  func() stateWorld
+}
+
+// divergence runs the cells in a new world of every engine for the given
+// number of generations and returns the first generation after which two
+// engines disagree, with the names of those engines, or -1 if they all agree.
+func divergence(engines []engineMaker, cells []soupCell, generations int) (int, string, string) {
+	worlds := make([]stateWorld, len(engines))
+	for i, e := range engines {
+		worlds[i] = e.new()
+		for _, c := range cells {
+			worlds[i].SetStateIn(c.x, c.y, c.state, 0)
+		}
+	}
+	for generation := 1; generation <= generations; generation++ {
+		for _, w := range worlds {
+			w.Evolve()
+		}
+		first := statesOf(worlds[0])
+		for i, w := range worlds[1:] {
+			if statesOf(w) != first {
+				return generation, engines[0].name, engines[i+1].name
+			}
+		}
+	}
+	return -1, "", ""
+}
+
+// statesOf lists the non-empty cells of the world in reading order.
+func statesOf(w stateWorld) string {
+	var cells []soupCell
+	w.ForEachState(func(x, y int64, state int) {
+		cells = append(cells, soupCell{x, y, state})
+	})
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].y != cells[j].y {
+			return cells[i].y < cells[j].y
+		}
+		return cells[i].x < cells[j].x
+	})
+	return fmt.Sprint(cells)
+}
+
+// minimize shrinks cells that make fails true to a smaller set that still
+// does, from which no single cell can be removed. It removes ever smaller
+// chunks of cells for as long as the failure remains, then single cells until
+// none can go.
+func minimize(cells []soupCell, fails func([]soupCell) bool) []soupCell {
+	for chunk := len(cells) / 2; chunk >= 1; {
+		removed := false
+		for start := 0; start < len(cells); {
+			end := min(start+chunk, len(cells))
+			smaller := append(append([]soupCell(nil), cells[:start]...), cells[end:]...)
+			if fails(smaller) {
+				cells = smaller
+				removed = true
+				continue
+			}
+			start = end
+		}
+		if chunk > 1 || !removed {
+			chunk /= 2
+		}
+	}
+	return cells
+}
+
+// reproducer describes the cells of a soup for a failure report.
+func reproducer(cells []soupCell) string {
+	parts := make([]string, len(cells))
+	for i, c := range cells {
+		parts[i] = fmt.Sprintf("(%d,%d)=%d", c.x, c.y, c.state)
+	}
+	return strings.Join(parts, " ")
+}
+
+// checkEngines runs random soups in all the engines and reports, for the first
+// soup they disagree on, the smallest soup found that still makes them
+// disagree.
+func checkEngines(t *testing.T, engines []engineMaker, states int, soups int, generations int) {
+	t.Helper()
+	for seed := int64(1); seed <= int64(soups); seed++ {
+		cells := soupCells(16, states, seed)
+		generation, a, b := divergence(engines, cells, generations)
+		if generation < 0 {
+			continue
+		}
+		small := minimize(cells, func(cells []soupCell) bool {
+			g, _, _ := divergence(engines, cells, generations)
+			return g >= 0
+		})
+		generation, a, b = divergence(engines, small, generations)
+		t.Fatalf("soup %d: %s and %s disagree after generation %d; smallest soup found: %s",
+			seed, a, b, generation, reproducer(small))
+	}
+}
+
+// ruleEngines returns makers of the counting engine of the rule, of the
+// general AutomatonWorld following it and, for rules it can follow, of a
+// QuadWorld.
+func ruleEngines(t *testing.T, rule string) []engineMaker {
+	r := mustParseRule(t, rule)
+	engines := []engineMaker{
+		{name: "engine", new: func() stateWorld { return NewEngine(r) }},
+		{name: "AutomatonWorld", new: func() stateWorld { return NewAutomatonWorld(r) }},
+	}
+	if _, ok := QuadRule(r); ok {
+		engines = append(engines, engineMaker{name: "QuadWorld", new: func() stateWorld { return NewQuadWorld(r) }})
+	}
+	return engines
+}
+
+func TestEngines_Differential(t *testing.T) {
+	conway := append(ruleEngines(t, "B3/S23"), engineMaker{
+		name: "LtLWorld",
+		new: func() stateWorld {
+			r, _ := ParseLtL("R1,C0,M0,S2..3,B3..3")
+			return NewLtLWorld(r)
+		},
+	})
+	tests := []struct {
+		name    string
+		engines []engineMaker
+	}{
+		{name: "Conway", engines: conway},
+		{name: "HighLife", engines: ruleEngines(t, "B36/S23")},
+		{name: "Brian's Brain", engines: ruleEngines(t, "B2/S/C3")},
+		{name: "Star Wars", engines: ruleEngines(t, "B2/S345/C4")},
+		{name: "hexagonal", engines: ruleEngines(t, "B2/S34H")},
+		{name: "von Neumann", engines: ruleEngines(t, "B13/S01V")},
+		{name: "isotropic", engines: ruleEngines(t, "B2-a3/S23-q")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states := tt.engines[0].new().States()
+			checkEngines(t, tt.engines, states, 5, 40)
+		})
+	}
+}
+
+// brokenWorld is a Conway world that wrongly empties (3,3) every generation,
+// standing in for an engine with a bug.
+type brokenWorld struct {
+	*World
+}
+
+// Evolve evolves the world, then empties (3,3).
+func (w brokenWorld) Evolve() {
+	w.World.Evolve()
+	w.RemoveCellIn(3, 3)
+}
+
+func TestMinimize(t *testing.T) {
+	engines := []engineMaker{
+		{name: "World", new: func() stateWorld { return NewWorld() }},
+		{name: "broken", new: func() stateWorld { return brokenWorld{NewWorld()} }},
+	}
+	fails := func(cells []soupCell) bool {
+		g, _, _ := divergence(engines, cells, 5)
+		return g >= 0
+	}
+	// A soup that brings (3,3) to life soon enough
+	var cells []soupCell
+	for seed := int64(1); cells == nil; seed++ {
+		if soup := soupCells(8, 2, seed); fails(soup) {
+			cells = soup
+		}
+	}
+
+	small := minimize(cells, fails)
+	if !fails(small) || len(small) >= len(cells) {
+		t.Fatalf("minimize() = %s, want fewer than %d cells that still fail", reproducer(small), len(cells))
+	}
+	// No single cell can be left out
+	for i := range small {
+		without := append(append([]soupCell(nil), small[:i]...), small[i+1:]...)
+		if fails(without) {
+			t.Errorf("minimize() = %s, which still fails without (%d,%d)", reproducer(small), small[i].x, small[i].y)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// HexWorld is a world following a rule with the hexagonal neighborhood. Cells
+// are stored on a sheared square grid, where the neighbors of (x, y) are the
+// cells of hexagonalNeighborhood, and drawn with offset rows so that the grid
+// looks hexagonal: every cell takes two columns and each row is shifted half a
+// cell to the left of the one above, so (x, y) is drawn at column 2x - y. The
+// cells of even rows fall on even columns and those of odd rows on odd ones.
+type HexWorld struct {
+	*World
+}
+
+// NewHexWorld creates an empty world following the rule, which should have the
+// hexagonal neighborhood.
+func NewHexWorld(rule *Rule) *HexWorld {
+	return &HexWorld{World: NewWorldWithRule(rule)}
+}
+
+// CellAt returns the cell drawn at the given column and row. A column between
+// two cells belongs to the cell on its left.
+func (w HexWorld) CellAt(column, row int64) (x, y int64) {
+	// The shift rounds down, also for negative coordinates
+	return (column + row) >> 1, row
+}
+
+// Position returns the column and row where the cell at (x, y) is drawn.
+func (w HexWorld) Position(x, y int64) (column, row int64) {
+	return 2*x - y, y
+}
+
+// WindowContent returns a string representation of the world within the given
+// bounds, which are columns and rows of the hexagonal drawing.
+func (w HexWorld) WindowContent(topLeft, bottomRight types.Index) string {
+	buffer := &strings.Builder{}
+	fmt.Fprintf(buffer, "Turn: %d  Live Cells: %d  Limits: (%d,%d) -> (%d, %d) Changes: %d Age: %s    \n",
+		w.turn,
+		w.liveCount(),
+		w.topLeft.x,
+		w.topLeft.y,
+		w.bottomRight.x,
+		w.bottomRight.y,
+		w.changes,
+		time.Since(w.start))
+	for row := topLeft.Y(); row <= bottomRight.Y(); row++ {
+		for column := topLeft.X(); column <= bottomRight.X(); column++ {
+			x, y := w.CellAt(column, row)
+			if cellColumn, _ := w.Position(x, y); cellColumn != column {
+				buffer.WriteByte(' ')
+			} else if c := w.GetCellIn(x, y); c != nil {
+				buffer.WriteByte(w.rule.Glyph(c.state))
+			} else {
+				buffer.WriteByte(' ')
+			}
+		}
+		fmt.Fprintln(buffer)
+	}
+	return buffer.String()
+}
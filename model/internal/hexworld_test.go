@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestHexWorld_WindowContent(t *testing.T) {
+	r, err := ParseRule("B2/S34H")
+	if err != nil {
+		t.Fatalf("ParseRule() unexpected error: %v", err)
+	}
+	w := NewHexWorld(r)
+	// A cell and its six neighbors form a hexagon
+	w.AddCellIn(0, 0, 0)
+	for _, n := range hexagonalNeighborhood {
+		w.AddCellIn(n.x, n.y, 0)
+	}
+
+	content := w.WindowContent(index{-3, -1}, index{3, 1})
+	if !containsLines(content, "  x x  ", " x x x ", "  x x  ") {
+		t.Errorf("WindowContent() = %q, want a hexagon", content)
+	}
+}
+
+func TestHexWorld_CellAt(t *testing.T) {
+	w := NewHexWorld(ConwayRule)
+	for y := int64(-3); y <= 3; y++ {
+		for x := int64(-3); x <= 3; x++ {
+			column, row := w.Position(x, y)
+			if gotX, gotY := w.CellAt(column, row); gotX != x || gotY != y {
+				t.Errorf("CellAt(Position(%d, %d)) = (%d, %d)", x, y, gotX, gotY)
+			}
+			if gotX, gotY := w.CellAt(column+1, row); gotX != x || gotY != y {
+				t.Errorf("CellAt() of the column after (%d, %d) = (%d, %d)", x, y, gotX, gotY)
+			}
+		}
+	}
+}
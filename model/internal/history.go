@@ -0,0 +1,40 @@
+package internal
+
+import "github.com/daniel-munoz/life/types"
+
+// HistoryLength is the number of generations whose stats a world keeps.
+const HistoryLength = 1024
+
+// history is a ring buffer of the stats of the last HistoryLength
+// generations.
+type history struct {
+	stats []types.Stats
+	next  int
+}
+
+// record adds the stats of a generation, replacing the oldest ones when the
+// buffer is full.
+func (h *history) record(s types.Stats) {
+	if len(h.stats) < HistoryLength {
+		h.stats = append(h.stats, s)
+		return
+	}
+	h.stats[h.next] = s
+	h.next = (h.next + 1) % HistoryLength
+}
+
+// all returns a copy of the recorded stats, oldest first.
+func (h *history) all() []types.Stats {
+	all := make([]types.Stats, 0, len(h.stats))
+	all = append(all, h.stats[h.next:]...)
+	return append(all, h.stats[:h.next]...)
+}
+
+// boxSize returns the width and height of the bounding box from topLeft to
+// bottomRight, or 0 and 0 when there are no cells.
+func boxSize(cells int, topLeft, bottomRight index) (width, height int64) {
+	if cells == 0 {
+		return 0, 0
+	}
+	return bottomRight.x - topLeft.x + 1, bottomRight.y - topLeft.y + 1
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestHistory(t *testing.T) {
+	h := &history{}
+	if got := h.all(); len(got) != 0 {
+		t.Errorf("all() of an empty history = %v", got)
+	}
+	for turn := int64(1); turn <= HistoryLength+5; turn++ {
+		h.record(types.Stats{Turn: turn})
+	}
+	all := h.all()
+	if len(all) != HistoryLength {
+		t.Fatalf("all() has %d stats, want %d", len(all), HistoryLength)
+	}
+	if all[0].Turn != 6 || all[len(all)-1].Turn != HistoryLength+5 {
+		t.Errorf("all() from turn %d to %d, want 6 to %d", all[0].Turn, all[len(all)-1].Turn, HistoryLength+5)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Turn != all[i-1].Turn+1 {
+			t.Fatalf("all() turn %d follows %d", all[i].Turn, all[i-1].Turn)
+		}
+	}
+}
+
+func TestWorld_History(t *testing.T) {
+	// A blinker, next to a cell that dies at once
+	w := NewWorld()
+	for _, c := range []index{{0, 0}, {1, 0}, {2, 0}, {10, 10}} {
+		w.AddCellIn(c.x, c.y, 0)
+	}
+	w.Evolve()
+	w.Evolve()
+
+	want := []types.Stats{
+		{Turn: 1, Population: 3, Births: 2, Deaths: 3, Width: 1, Height: 3},
+		{Turn: 2, Population: 3, Births: 2, Deaths: 2, Width: 3, Height: 1},
+	}
+	if got := w.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAutomatonWorld_History(t *testing.T) {
+	// An electron running along a wire: the head moves on, the tail becomes
+	// conductor
+	w := NewAutomatonWorld(Wireworld{})
+	w.SetStateIn(0, 0, wireTail, 0)
+	w.SetStateIn(1, 0, wireHead, 0)
+	for x := int64(2); x < 5; x++ {
+		w.SetStateIn(x, 0, wireConductor, 0)
+	}
+	w.Evolve()
+
+	want := []types.Stats{{Turn: 1, Population: 1, Births: 1, Deaths: 1, Width: 5, Height: 1}}
+	if got := w.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,368 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxRadius is the largest neighborhood radius of a Larger than Life rule.
+const maxRadius = 500
+
+// Larger than Life neighborhood types, as written after the N of a rulestring.
+const (
+	ltlMoore      = 'M' // Square of side 2R+1
+	ltlVonNeumann = 'N' // Diamond: |dx|+|dy| <= R
+	ltlCircular   = 'C' // Disc: dx²+dy² <= R²+R, the cells within about R+½
+)
+
+// LtLRule is a Larger than Life rule: births and survivals depend on the number
+// of living cells within radius R, which must fall within a range. With more
+// than two states, cells that do not survive go through dying states, as in
+// Generations rules.
+type LtLRule struct {
+	radius          int
+	states          int
+	middle          bool
+	survival, birth [2]int
+	neighborhood    byte
+	offsets         []index
+}
+
+// ParseLtL parses a rule in the Larger than Life notation, such as
+// "R5,C0,M1,S34..58,B34..45,NM": the radius, the number of states (0 or 2 for
+// none beyond alive and dead), whether the middle cell counts itself, the
+// survival and birth ranges and, optionally, the neighborhood type (NM for
+// Moore, NN for von Neumann, NC for circular).
+func ParseLtL(rule string) (*LtLRule, error) {
+	r := &LtLRule{neighborhood: ltlMoore}
+	seen := make(map[byte]bool)
+	for _, field := range strings.Split(strings.ToUpper(strings.TrimSpace(rule)), ",") {
+		if field == "" || seen[field[0]] {
+			return nil, fmt.Errorf("unsupported rule %q", rule)
+		}
+		seen[field[0]] = true
+		key, value := field[0], field[1:]
+
+		var err error
+		switch key {
+		case 'R':
+			r.radius, err = strconv.Atoi(value)
+			if err == nil && (r.radius < 1 || r.radius > maxRadius) {
+				err = fmt.Errorf("radius must be between 1 and %d", maxRadius)
+			}
+		case 'C':
+			r.states, err = strconv.Atoi(value)
+			if err == nil && (r.states < 0 || r.states > maxStates) {
+				err = fmt.Errorf("states must be between 0 and %d", maxStates)
+			}
+		case 'M':
+			if value != "0" && value != "1" {
+				err = fmt.Errorf("M must be 0 or 1")
+			}
+			r.middle = value == "1"
+		case 'S':
+			r.survival, err = parseRange(value)
+		case 'B':
+			r.birth, err = parseRange(value)
+		case 'N':
+			if value != "M" && value != "N" && value != "C" {
+				err = fmt.Errorf("neighborhood must be NM, NN or NC")
+			}
+			if value != "" {
+				r.neighborhood = value[0]
+			}
+		default:
+			err = fmt.Errorf("unknown field %q", field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unsupported rule %q: %w", rule, err)
+		}
+	}
+	for _, required := range []byte("RCMSB") {
+		if !seen[required] {
+			return nil, fmt.Errorf("unsupported rule %q: missing %c", rule, required)
+		}
+	}
+	if r.states < 2 {
+		r.states = 2
+	}
+	r.offsets = r.neighborOffsets()
+	return r, nil
+}
+
+// parseRange parses a range of neighbor counts such as "34..58".
+func parseRange(value string) ([2]int, error) {
+	i := strings.Index(value, "..")
+	if i < 0 {
+		return [2]int{}, fmt.Errorf("invalid range %q", value)
+	}
+	low, err := strconv.Atoi(value[:i])
+	if err != nil {
+		return [2]int{}, fmt.Errorf("invalid range %q", value)
+	}
+	high, err := strconv.Atoi(value[i+2:])
+	if err != nil || high < low {
+		return [2]int{}, fmt.Errorf("invalid range %q", value)
+	}
+	return [2]int{low, high}, nil
+}
+
+// Name returns the rule in the Larger than Life notation.
+func (r *LtLRule) Name() string {
+	states := r.states
+	if states == 2 {
+		states = 0
+	}
+	middle := 0
+	if r.middle {
+		middle = 1
+	}
+	return fmt.Sprintf("R%d,C%d,M%d,S%d..%d,B%d..%d,N%c",
+		r.radius, states, middle, r.survival[0], r.survival[1], r.birth[0], r.birth[1], r.neighborhood)
+}
+
+// States returns the number of cell states, counting the dead state.
+func (r *LtLRule) States() int {
+	return r.states
+}
+
+// Neighborhood returns the offsets of every cell within the radius, not
+// counting the middle cell.
+func (r *LtLRule) Neighborhood() []index {
+	return r.offsets
+}
+
+// neighborOffsets lists the cells within the radius, row by row.
+func (r *LtLRule) neighborOffsets() []index {
+	var offsets []index
+	radius := int64(r.radius)
+	for dy := -radius; dy <= radius; dy++ {
+		width := r.rowWidth(dy)
+		for dx := -width; dx <= width; dx++ {
+			if dx != 0 || dy != 0 {
+				offsets = append(offsets, index{dx, dy})
+			}
+		}
+	}
+	return offsets
+}
+
+// rowWidth returns how far the neighborhood extends to each side in the row
+// dy rows away from the middle cell.
+func (r *LtLRule) rowWidth(dy int64) int64 {
+	radius := int64(r.radius)
+	if dy < 0 {
+		dy = -dy
+	}
+	switch r.neighborhood {
+	case ltlVonNeumann:
+		return radius - dy
+	case ltlCircular:
+		width := int64(0)
+		for (width+1)*(width+1)+dy*dy <= radius*radius+radius {
+			width++
+		}
+		return width
+	}
+	return radius
+}
+
+// Next returns the state of a cell in the next generation, counting the living
+// cells among its neighbors.
+func (r *LtLRule) Next(state int, neighbors []int) int {
+	count := 0
+	for _, n := range neighbors {
+		if n == 1 {
+			count++
+		}
+	}
+	if r.middle && state == 1 {
+		count++
+	}
+	return r.next(state, count)
+}
+
+// next returns the state of a cell in the next generation, from its current
+// state and the number of living cells in its neighborhood, which includes the
+// cell itself when the rule counts the middle cell.
+func (r *LtLRule) next(state, count int) int {
+	switch {
+	case state == 0:
+		if count >= r.birth[0] && count <= r.birth[1] {
+			return 1
+		}
+		return 0
+	case state == 1 && count >= r.survival[0] && count <= r.survival[1]:
+		return 1
+	case state+1 < r.states:
+		return state + 1
+	default:
+		return 0
+	}
+}
+
+// Glyph returns the character drawn for a living or dying cell.
+func (r *LtLRule) Glyph(state int) byte {
+	return stateGlyph(state, r.states)
+}
+
+// ltlTile is the smallest side of the square tiles an LtLWorld is evaluated in.
+const ltlTile = 64
+
+// LtLWorld is a world following a Larger than Life rule. Its neighbor counts
+// come from summed-area tables of the living cells, so a count over a square
+// takes constant time and a count over a diamond or a disc takes time
+// proportional to the radius, however many cells the neighborhood has. The
+// world is cut into square tiles, each with its own table, so that patterns
+// far apart do not need a table of the empty space between them.
+type LtLWorld struct {
+	*AutomatonWorld
+	rule *LtLRule
+}
+
+// NewLtLWorld creates an empty world following the rule.
+func NewLtLWorld(rule *LtLRule) *LtLWorld {
+	return &LtLWorld{AutomatonWorld: NewAutomatonWorld(rule), rule: rule}
+}
+
+// summedArea is a summed-area table of the living cells of a rectangle of the
+// world. sums[(y+1)*(width+1)+x+1] holds the number of living cells above and
+// to the left of (x, y) inclusive, in coordinates relative to the rectangle.
+type summedArea struct {
+	left, top, width, height int64
+	sums                     []int32
+}
+
+// reset makes the table count the living cells among cells in the rectangle
+// from (left, top) with the given size, reusing its memory.
+func (sa *summedArea) reset(left, top, width, height int64, cells []index) {
+	sa.left, sa.top, sa.width, sa.height = left, top, width, height
+	size := int((width + 1) * (height + 1))
+	if cap(sa.sums) < size {
+		sa.sums = make([]int32, size)
+	}
+	sa.sums = sa.sums[:size]
+	clear(sa.sums)
+	stride := width + 1
+	for _, c := range cells {
+		if c.x >= left && c.x < left+width && c.y >= top && c.y < top+height {
+			sa.sums[(c.y-top+1)*stride+c.x-left+1] = 1
+		}
+	}
+	for y := int64(1); y <= height; y++ {
+		for x := int64(1); x <= width; x++ {
+			i := y*stride + x
+			sa.sums[i] += sa.sums[i-1] + sa.sums[i-stride] - sa.sums[i-stride-1]
+		}
+	}
+}
+
+// count returns the number of living cells in the rectangle from (x1, y1) to
+// (x2, y2) inclusive, clipped to the table.
+func (sa *summedArea) count(x1, y1, x2, y2 int64) int {
+	x1, y1 = clip(x1-sa.left, 0, sa.width), clip(y1-sa.top, 0, sa.height)
+	x2, y2 = clip(x2-sa.left+1, 0, sa.width), clip(y2-sa.top+1, 0, sa.height)
+	if x1 >= x2 || y1 >= y2 {
+		return 0
+	}
+	stride := sa.width + 1
+	return int(sa.sums[y2*stride+x2] - sa.sums[y1*stride+x2] - sa.sums[y2*stride+x1] + sa.sums[y1*stride+x1])
+}
+
+// clip limits v to the range from low to high.
+func clip(v, low, high int64) int64 {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+// floorDiv returns a divided by b, which is positive, rounded down.
+func floorDiv(a, b int64) int64 {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// Evolve advances the world by one generation. In every tile within the radius
+// of a non-empty cell, the cells within the radius of the box of the
+// non-empty cells around the tile are evaluated, with a table of the living
+// cells around them.
+func (w *LtLWorld) Evolve() {
+	radius := int64(w.rule.radius)
+	// Tiles at least as wide as the radius keep the neighborhoods of the
+	// cells of a tile within the tiles around it
+	size := max(ltlTile, radius)
+	tiles := make(map[index][]index)
+	for location := range w.cells {
+		tile := index{floorDiv(location.x, size), floorDiv(location.y, size)}
+		tiles[tile] = append(tiles[tile], location)
+	}
+	active := make(map[index]bool)
+	for tile := range tiles {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dx := int64(-1); dx <= 1; dx++ {
+				active[index{tile.x + dx, tile.y + dy}] = true
+			}
+		}
+	}
+
+	widths := make([]int64, 2*radius+1)
+	for dy := -radius; dy <= radius; dy++ {
+		widths[dy+radius] = w.rule.rowWidth(dy)
+	}
+	next := make(map[index]int)
+	sa := &summedArea{}
+	var living []index
+	for tile := range active {
+		// The non-empty cells of the tiles around this one, and the box of
+		// the cells of the tile within their radius
+		living = living[:0]
+		left, top := tile.x*size+size, tile.y*size+size
+		right, bottom := tile.x*size-1, tile.y*size-1
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dx := int64(-1); dx <= 1; dx++ {
+				for _, location := range tiles[index{tile.x + dx, tile.y + dy}] {
+					left, right = min(left, location.x-radius), max(right, location.x+radius)
+					top, bottom = min(top, location.y-radius), max(bottom, location.y+radius)
+					if w.cells[location].state == 1 {
+						living = append(living, location)
+					}
+				}
+			}
+		}
+		left, top = max(left, tile.x*size), max(top, tile.y*size)
+		right, bottom = min(right, tile.x*size+size-1), min(bottom, tile.y*size+size-1)
+		if left > right || top > bottom {
+			continue
+		}
+		sa.reset(left-radius, top-radius, right-left+1+2*radius, bottom-top+1+2*radius, living)
+
+		for y := top; y <= bottom; y++ {
+			for x := left; x <= right; x++ {
+				var count int
+				if w.rule.neighborhood == ltlMoore {
+					count = sa.count(x-radius, y-radius, x+radius, y+radius)
+				} else {
+					for dy := -radius; dy <= radius; dy++ {
+						span := widths[dy+radius]
+						count += sa.count(x-span, y+dy, x+span, y+dy)
+					}
+				}
+				state := w.StateOf(x, y)
+				if state == 1 && !w.rule.middle {
+					count--
+				}
+				if n := w.rule.next(state, count); n != state {
+					next[index{x, y}] = n
+				}
+			}
+		}
+	}
+	w.apply(next)
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseLtL(t *testing.T) {
+	tests := []struct {
+		rule     string
+		wantName string
+		wantErr  bool
+	}{
+		{rule: "R5,C0,M1,S34..58,B34..45", wantName: "R5,C0,M1,S34..58,B34..45,NM"},
+		{rule: "r2,c3,m0,s3..5,b4..6,nn", wantName: "R2,C3,M0,S3..5,B4..6,NN"},
+		{rule: "R7,C2,M1,S1..9,B2..3,NC", wantName: "R7,C0,M1,S1..9,B2..3,NC"},
+		{rule: "R5,C0,M1,S34..58", wantErr: true},
+		{rule: "R0,C0,M1,S1..2,B1..2", wantErr: true},
+		{rule: "R501,C0,M1,S1..2,B1..2", wantErr: true},
+		{rule: "R5,C0,M2,S1..2,B1..2", wantErr: true},
+		{rule: "R5,C0,M1,S9..2,B1..2", wantErr: true},
+		{rule: "R5,C0,M1,S1..2,B1..2,NX", wantErr: true},
+		{rule: "R5,R5,C0,M1,S1..2,B1..2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseAutomaton(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseAutomaton(%q) = %s, want an error", tt.rule, r.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAutomaton(%q) unexpected error: %v", tt.rule, err)
+			}
+			if r.Name() != tt.wantName {
+				t.Errorf("ParseAutomaton(%q) = %s, want %s", tt.rule, r.Name(), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestLtLRule_Neighborhood(t *testing.T) {
+	tests := []struct {
+		rule string
+		want int
+	}{
+		{rule: "R2,C0,M0,S1..1,B1..1,NM", want: 24},
+		{rule: "R2,C0,M0,S1..1,B1..1,NN", want: 12},
+		{rule: "R2,C0,M0,S1..1,B1..1,NC", want: 20},
+	}
+	for _, tt := range tests {
+		r, err := ParseLtL(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseLtL(%q) unexpected error: %v", tt.rule, err)
+		}
+		if got := len(r.Neighborhood()); got != tt.want {
+			t.Errorf("%s has %d neighbors, want %d", tt.rule, got, tt.want)
+		}
+	}
+}
+
+// soup fills a square of the given size at the origin with random states.
+func soup(w Engine, size int64, states int, seed int64) {
+	random := rand.New(rand.NewSource(seed))
+	for y := int64(0); y < size; y++ {
+		for x := int64(0); x < size; x++ {
+			if random.Intn(2) == 0 {
+				w.SetStateIn(x, y, 1+random.Intn(states-1), 0)
+			}
+		}
+	}
+}
+
+// sameStates compares every non-empty cell of two worlds.
+func sameStates(t *testing.T, a, b Engine) {
+	t.Helper()
+	count := 0
+	a.ForEachState(func(x, y int64, state int) {
+		count++
+		if b.StateOf(x, y) != state {
+			t.Fatalf("cell (%d,%d) has state %d, want %d", x, y, b.StateOf(x, y), state)
+		}
+	})
+	b.ForEachState(func(x, y int64, state int) {
+		count--
+	})
+	if count != 0 {
+		t.Fatalf("worlds have different numbers of cells")
+	}
+}
+
+func TestLtLWorld_MatchesAutomatonWorld(t *testing.T) {
+	for _, rule := range []string{
+		"R5,C0,M1,S34..58,B34..45",
+		"R3,C0,M0,S5..10,B6..9,NN",
+		"R4,C0,M1,S14..30,B15..22,NC",
+		"R2,C4,M1,S6..11,B5..8",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			r, err := ParseLtL(rule)
+			if err != nil {
+				t.Fatalf("ParseLtL() unexpected error: %v", err)
+			}
+			fast, slow := NewLtLWorld(r), NewAutomatonWorld(r)
+			soup(fast, 24, r.States(), 7)
+			soup(slow, 24, r.States(), 7)
+			for i := 0; i < 10; i++ {
+				fast.Evolve()
+				slow.Evolve()
+				sameStates(t, slow, fast)
+			}
+		})
+	}
+}
+
+func TestLtLWorld_FarApart(t *testing.T) {
+	// Soups across tile edges and far apart, which a single table over their
+	// box could not hold
+	r, err := ParseLtL("R5,C3,M1,S34..58,B34..45,NC")
+	if err != nil {
+		t.Fatalf("ParseLtL() unexpected error: %v", err)
+	}
+	fast, slow := NewLtLWorld(r), NewAutomatonWorld(r)
+	for _, corner := range []index{{-12, -12}, {52, 60}, {100000, -100000}} {
+		random := rand.New(rand.NewSource(corner.x))
+		for y := int64(0); y < 24; y++ {
+			for x := int64(0); x < 24; x++ {
+				if random.Intn(2) == 0 {
+					fast.SetStateIn(corner.x+x, corner.y+y, 1, 0)
+					slow.SetStateIn(corner.x+x, corner.y+y, 1, 0)
+				}
+			}
+		}
+	}
+	for i := 0; i < 10; i++ {
+		fast.Evolve()
+		slow.Evolve()
+		sameStates(t, slow, fast)
+	}
+}
+
+func TestLtLWorld_Conway(t *testing.T) {
+	// Radius 1 without the middle cell is a Life-like rule
+	r, err := ParseLtL("R1,C0,M0,S2..3,B3..3")
+	if err != nil {
+		t.Fatalf("ParseLtL() unexpected error: %v", err)
+	}
+	ltl, life := NewLtLWorld(r), NewWorld()
+	for _, c := range []index{{1, 0}, {2, 0}, {0, 1}, {1, 1}, {1, 2}} {
+		ltl.AddCellIn(c.x, c.y, 0)
+		life.AddCellIn(c.x, c.y, 0)
+	}
+	for i := 0; i < 50; i++ {
+		ltl.Evolve()
+		life.Evolve()
+	}
+	sameStates(t, life, ltl)
+}
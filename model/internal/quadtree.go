@@ -0,0 +1,649 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// Levels of the nodes of a quadtree world, the width of a node of level k
+// being 2^k cells.
+const (
+	// LeafLevel is the level of the 8x8 leaves of macrocell files.
+	LeafLevel = 3
+	// MaxRootLevel is the level of the largest root cells are put in, which
+	// can still grow the two levels Evolve needs.
+	MaxRootLevel = maxQuadLevel - 2
+	// maxQuadLevel is the level of the largest node, which keeps the
+	// coordinates of its cells within int64.
+	maxQuadLevel = 63
+	// maxQuadNodes is the number of nodes a quadtree world keeps before it
+	// forgets the ones its cells no longer use.
+	maxQuadNodes = 1 << 22
+)
+
+// quadNode is a square of 2^level by 2^level cells, made of four nodes of the
+// level below, or a single cell at level 0. Nodes are canonical within their
+// world: there is one node for every content, so equal squares are the same
+// node, however many times they appear, and they never change.
+type quadNode struct {
+	nw, ne, sw, se *quadNode
+	level          int
+	population     int64
+	// The edges of the living cells, from the top left corner of the node,
+	// when it has any
+	minX, minY, maxX, maxY int64
+	// next is the center of the node one generation later, once worked out.
+	next *quadNode
+}
+
+// quadKey identifies a node by its children.
+type quadKey struct {
+	nw, ne, sw, se *quadNode
+}
+
+// QuadWorld is a world stored as a quadtree of canonical nodes, which suits
+// huge patterns that repeat themselves, such as breeders and long streams of
+// gliders: repeated parts are stored once, and the next generation of a node
+// is worked out once for all its copies. The root is centered on (0,0), so a
+// root of level k covers the cells from -2^(k-1) to 2^(k-1)-1. It follows
+// two-state rules, and does not remember when its cells were born.
+type QuadWorld struct {
+	rule        *Rule
+	nodes       map[quadKey]*quadNode
+	empty       []*quadNode
+	dead, alive *quadNode
+	root        *quadNode
+	// previous is the center of the root before the last generation, where
+	// the cells it changed are looked for.
+	previous *quadNode
+	turn     int64
+	changes  int64
+	history  history
+	start    time.Time
+}
+
+// QuadRule returns the automaton as a rule a QuadWorld can follow, and false
+// when it cannot: the automaton must be a two-state rule of the Moore or von
+// Neumann neighborhood under which empty cells stay empty.
+func QuadRule(a Automaton) (*Rule, bool) {
+	rule, ok := a.(*Rule)
+	if !ok || rule.states != 2 || rule.shape == hexagonalShape || rule.birth[0] {
+		return nil, false
+	}
+	return rule, true
+}
+
+// NewQuadWorld creates an empty quadtree world following the rule, which must
+// be accepted by QuadRule.
+func NewQuadWorld(rule *Rule) *QuadWorld {
+	w := &QuadWorld{
+		rule:  rule,
+		nodes: make(map[quadKey]*quadNode),
+		dead:  &quadNode{},
+		alive: &quadNode{population: 1},
+		start: time.Now(),
+	}
+	w.empty = []*quadNode{w.dead}
+	w.root = w.emptyNode(LeafLevel)
+	return w
+}
+
+// node returns the canonical node made of the four nodes, which have the same
+// level.
+func (w *QuadWorld) node(nw, ne, sw, se *quadNode) *quadNode {
+	key := quadKey{nw, ne, sw, se}
+	if n, found := w.nodes[key]; found {
+		return n
+	}
+	n := &quadNode{nw: nw, ne: ne, sw: sw, se: se, level: nw.level + 1}
+	half := int64(1) << nw.level
+	first := true
+	for i, child := range []*quadNode{nw, ne, sw, se} {
+		if child.population == 0 {
+			continue
+		}
+		left, top := half*int64(i%2), half*int64(i/2)
+		// Huge nodes full of cells count no more cells than fit
+		if n.population += child.population; n.population < 0 {
+			n.population = math.MaxInt64
+		}
+		if first {
+			n.minX, n.minY = left+child.minX, top+child.minY
+			n.maxX, n.maxY = left+child.maxX, top+child.maxY
+			first = false
+			continue
+		}
+		n.minX, n.minY = min(n.minX, left+child.minX), min(n.minY, top+child.minY)
+		n.maxX, n.maxY = max(n.maxX, left+child.maxX), max(n.maxY, top+child.maxY)
+	}
+	w.nodes[key] = n
+	return n
+}
+
+// emptyNode returns the node of the level without living cells.
+func (w *QuadWorld) emptyNode(level int) *quadNode {
+	for len(w.empty) <= level {
+		e := w.empty[len(w.empty)-1]
+		w.empty = append(w.empty, w.node(e, e, e, e))
+	}
+	return w.empty[level]
+}
+
+// cell returns the node of a single cell.
+func (w *QuadWorld) cell(alive bool) *quadNode {
+	if alive {
+		return w.alive
+	}
+	return w.dead
+}
+
+// center returns the node of half the width in the middle of the node, which
+// is above level 1.
+func (w *QuadWorld) center(n *quadNode) *quadNode {
+	return w.node(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// expand returns a node of the level above with n in its middle.
+func (w *QuadWorld) expand(n *quadNode) *quadNode {
+	e := w.emptyNode(n.level - 1)
+	return w.node(
+		w.node(e, e, e, n.nw),
+		w.node(e, e, n.ne, e),
+		w.node(e, n.sw, e, e),
+		w.node(n.se, e, e, e))
+}
+
+// half returns the half width of the root, which is the distance from its top
+// left corner to (0,0).
+func (w *QuadWorld) half() int64 {
+	return int64(1) << (w.root.level - 1)
+}
+
+// contains reports whether the root covers (x, y).
+func (w *QuadWorld) contains(x, y int64) bool {
+	half := w.half()
+	return x >= -half && x < half && y >= -half && y < half
+}
+
+// step returns the center of the node, which is above level 1, one generation
+// later. Above level 2, the center is put together from the next generation
+// of four overlapping nodes of the level below, each the center of the
+// squares around it, so only the base case looks at cells.
+func (w *QuadWorld) step(n *quadNode) *quadNode {
+	if n.next != nil {
+		return n.next
+	}
+	if n.population == 0 {
+		n.next = w.emptyNode(n.level - 1)
+		return n.next
+	}
+	if n.level == 2 {
+		n.next = w.stepCells(n)
+		return n.next
+	}
+
+	// The nine squares of half the width of n in a 3x3 grid, each a quarter
+	// of the width of n away from the next
+	t00 := w.center(n.nw)
+	t01 := w.node(n.nw.ne.se, n.ne.nw.sw, n.nw.se.ne, n.ne.sw.nw)
+	t02 := w.center(n.ne)
+	t10 := w.node(n.nw.sw.se, n.nw.se.sw, n.sw.nw.ne, n.sw.ne.nw)
+	t11 := w.node(n.nw.se.se, n.ne.sw.sw, n.sw.ne.ne, n.se.nw.nw)
+	t12 := w.node(n.ne.sw.se, n.ne.se.sw, n.se.nw.ne, n.se.ne.nw)
+	t20 := w.center(n.sw)
+	t21 := w.node(n.sw.ne.se, n.se.nw.sw, n.sw.se.ne, n.se.sw.nw)
+	t22 := w.center(n.se)
+
+	n.next = w.node(
+		w.step(w.node(t00, t01, t10, t11)),
+		w.step(w.node(t01, t02, t11, t12)),
+		w.step(w.node(t10, t11, t20, t21)),
+		w.step(w.node(t11, t12, t21, t22)))
+	return n.next
+}
+
+// stepCells returns the 2x2 center of a 4x4 node one generation later,
+// applying the rule to each of its cells.
+func (w *QuadWorld) stepCells(n *quadNode) *quadNode {
+	var grid [4][4]int
+	for y := int64(0); y < 4; y++ {
+		for x := int64(0); x < 4; x++ {
+			if alive(n, x, y) {
+				grid[y][x] = 1
+			}
+		}
+	}
+	neighborhood := w.rule.Neighborhood()
+	neighbors := make([]int, len(neighborhood))
+	next := func(x, y int64) *quadNode {
+		for i, offset := range neighborhood {
+			neighbors[i] = grid[y+offset.y][x+offset.x]
+		}
+		return w.cell(w.rule.Next(grid[y][x], neighbors) == 1)
+	}
+	return w.node(next(1, 1), next(2, 1), next(1, 2), next(2, 2))
+}
+
+// alive reports whether the cell at (x, y) from the top left corner of the
+// node is alive.
+func alive(n *quadNode, x, y int64) bool {
+	for n.level > 0 {
+		if n.population == 0 {
+			return false
+		}
+		half := int64(1) << (n.level - 1)
+		switch {
+		case x < half && y < half:
+			n = n.nw
+		case y < half:
+			n, x = n.ne, x-half
+		case x < half:
+			n, y = n.sw, y-half
+		default:
+			n, x, y = n.se, x-half, y-half
+		}
+	}
+	return n.population == 1
+}
+
+// put returns the node with the cells at the given coordinates, from its top
+// left corner at (left, top), made alive, or dead if alive is false. It
+// reorders cells.
+func (w *QuadWorld) put(n *quadNode, left, top int64, cells [][2]int64, alive bool) *quadNode {
+	if len(cells) == 0 {
+		return n
+	}
+	if n.level == 0 {
+		return w.cell(alive)
+	}
+	half := int64(1) << (n.level - 1)
+	// Sort the cells into the rows of the node, then each row into its columns
+	bottom := partition(cells, func(c [2]int64) bool { return c[1] < top+half })
+	upperRight := partition(cells[:bottom], func(c [2]int64) bool { return c[0] < left+half })
+	lowerRight := bottom + partition(cells[bottom:], func(c [2]int64) bool { return c[0] < left+half })
+	return w.node(
+		w.put(n.nw, left, top, cells[:upperRight], alive),
+		w.put(n.ne, left+half, top, cells[upperRight:bottom], alive),
+		w.put(n.sw, left, top+half, cells[bottom:lowerRight], alive),
+		w.put(n.se, left+half, top+half, cells[lowerRight:], alive))
+}
+
+// partition moves the cells for which first is true before the others, and
+// returns the number of them.
+func partition(cells [][2]int64, first func(c [2]int64) bool) int {
+	i := 0
+	for j := range cells {
+		if first(cells[j]) {
+			cells[i], cells[j] = cells[j], cells[i]
+			i++
+		}
+	}
+	return i
+}
+
+// putCells makes the cells at the given coordinates alive, or dead if alive is
+// false, growing the root until it covers them. Cells further than 2^60 from
+// (0,0), beyond the largest root, are left out.
+func (w *QuadWorld) putCells(cells [][2]int64, alive bool) {
+	kept := make([][2]int64, 0, len(cells))
+	for _, c := range cells {
+		for !w.contains(c[0], c[1]) && w.root.level < MaxRootLevel {
+			w.root = w.expand(w.root)
+		}
+		if w.contains(c[0], c[1]) {
+			kept = append(kept, c)
+		}
+	}
+	half := w.half()
+	w.root = w.put(w.root, -half, -half, kept, alive)
+}
+
+// AddCellIn adds a living cell at the specified coordinates. The turn is not
+// kept.
+func (w *QuadWorld) AddCellIn(x, y, turn int64) {
+	w.putCells([][2]int64{{x, y}}, true)
+}
+
+// AddCells adds living cells at the given coordinates, all at once. The turn
+// is not kept.
+func (w *QuadWorld) AddCells(cells [][2]int64, turn int64) {
+	w.putCells(append([][2]int64(nil), cells...), true)
+}
+
+// RemoveCellIn removes the cell at the specified coordinates, if any.
+func (w *QuadWorld) RemoveCellIn(x, y int64) {
+	if w.IsAlive(x, y) {
+		w.putCells([][2]int64{{x, y}}, false)
+	}
+}
+
+// SetStateIn adds a living cell at the specified coordinates for any state
+// above 0, or removes it for state 0.
+func (w *QuadWorld) SetStateIn(x, y int64, state int, turn int64) {
+	if state > 0 {
+		w.AddCellIn(x, y, turn)
+		return
+	}
+	w.RemoveCellIn(x, y)
+}
+
+// IsAlive returns true if there is a living cell at the specified coordinates.
+func (w *QuadWorld) IsAlive(x, y int64) bool {
+	if !w.contains(x, y) {
+		return false
+	}
+	half := w.half()
+	return alive(w.root, x+half, y+half)
+}
+
+// StateOf returns 1 if there is a living cell at the specified coordinates,
+// and 0 otherwise.
+func (w *QuadWorld) StateOf(x, y int64) int {
+	if w.IsAlive(x, y) {
+		return 1
+	}
+	return 0
+}
+
+// States returns 2, the number of states of the rules of quadtree worlds.
+func (w *QuadWorld) States() int {
+	return 2
+}
+
+// Rule returns the rulestring of the rule followed by the world.
+func (w *QuadWorld) Rule() string {
+	return w.rule.String()
+}
+
+// Turn returns the current generation number.
+func (w *QuadWorld) Turn() int64 {
+	return w.turn
+}
+
+// SetTurn sets the current generation number.
+func (w *QuadWorld) SetTurn(turn int64) {
+	w.turn = turn
+}
+
+// Population returns the number of living cells.
+func (w *QuadWorld) Population() int64 {
+	return w.root.population
+}
+
+// forEachCell calls fn with the coordinates of every living cell of the node,
+// whose top left corner is at (left, top).
+func forEachCell(n *quadNode, left, top int64, fn func(x, y int64)) {
+	if n.population == 0 {
+		return
+	}
+	if n.level == 0 {
+		fn(left, top)
+		return
+	}
+	half := int64(1) << (n.level - 1)
+	forEachCell(n.nw, left, top, fn)
+	forEachCell(n.ne, left+half, top, fn)
+	forEachCell(n.sw, left, top+half, fn)
+	forEachCell(n.se, left+half, top+half, fn)
+}
+
+// ForEachCell calls fn with the coordinates of every living cell, reported
+// born at turn 0.
+func (w *QuadWorld) ForEachCell(fn func(x, y, turn int64)) {
+	half := w.half()
+	forEachCell(w.root, -half, -half, func(x, y int64) {
+		fn(x, y, 0)
+	})
+}
+
+// ForEachState calls fn with the coordinates of every living cell and state 1.
+func (w *QuadWorld) ForEachState(fn func(x, y int64, state int)) {
+	half := w.half()
+	forEachCell(w.root, -half, -half, func(x, y int64) {
+		fn(x, y, 1)
+	})
+}
+
+// Bounds returns the corners of the bounding box of the living cells, or
+// (0,0) and (0,0) when there are none.
+func (w *QuadWorld) Bounds() (topLeft, bottomRight types.Index) {
+	if w.root.population == 0 {
+		return index{}, index{}
+	}
+	half := w.half()
+	return index{w.root.minX - half, w.root.minY - half}, index{w.root.maxX - half, w.root.maxY - half}
+}
+
+// Evolve advances the world by one generation. The root first grows until its
+// cells are within the middle quarter of its width, where the cells it makes
+// in one generation stay within its center, which is then stepped. The root
+// grows no further than the largest node, so cells that move more than 2^61
+// from (0,0) are lost.
+func (w *QuadWorld) Evolve() {
+	for w.root.level < LeafLevel ||
+		w.root.level < maxQuadLevel && w.center(w.center(w.root)).population != w.root.population {
+		w.root = w.expand(w.root)
+	}
+	w.previous = w.center(w.root)
+	w.root = w.step(w.root)
+	w.turn++
+
+	s := types.Stats{Turn: w.turn, Population: w.root.population}
+	w.diff(w.previous, w.root, 0, 0, func(x, y int64, born bool) {
+		if born {
+			s.Births++
+		} else {
+			s.Deaths++
+		}
+	})
+	w.changes = s.Births + s.Deaths
+	if w.root.population > 0 {
+		s.Width, s.Height = w.root.maxX-w.root.minX+1, w.root.maxY-w.root.minY+1
+	}
+	w.history.record(s)
+
+	// Keep the root small, as the grown root is mostly empty
+	for w.root.level > LeafLevel && w.center(w.root).population == w.root.population {
+		w.root = w.center(w.root)
+	}
+	if len(w.nodes) > maxQuadNodes {
+		w.collect()
+	}
+}
+
+// diff calls fn for every cell that differs between the nodes a and b, which
+// have the same level and their top left corners at (left, top), with born set
+// when the cell is alive in b. Equal nodes are the same node, so only the
+// parts that changed are visited.
+func (w *QuadWorld) diff(a, b *quadNode, left, top int64, fn func(x, y int64, born bool)) {
+	if a == b {
+		return
+	}
+	if a.level == 0 {
+		fn(left, top, b.population == 1)
+		return
+	}
+	half := int64(1) << (a.level - 1)
+	w.diff(a.nw, b.nw, left, top, fn)
+	w.diff(a.ne, b.ne, left+half, top, fn)
+	w.diff(a.sw, b.sw, left, top+half, fn)
+	w.diff(a.se, b.se, left+half, top+half, fn)
+}
+
+// LastChanges calls fn for every cell born (born is true) or died in the last
+// generation.
+func (w *QuadWorld) LastChanges(fn func(x, y int64, born bool)) {
+	if w.previous == nil {
+		return
+	}
+	// The root may have shrunk since, but not by more than the previous one
+	root := w.root
+	for root.level < w.previous.level {
+		root = w.expand(root)
+	}
+	half := int64(1) << (root.level - 1)
+	w.diff(w.previous, root, -half, -half, fn)
+}
+
+// collect forgets the nodes that the root does not use, with the generations
+// worked out for all nodes, which may refer to forgotten ones.
+func (w *QuadWorld) collect() {
+	w.nodes = make(map[quadKey]*quadNode)
+	var keep func(n *quadNode)
+	keep = func(n *quadNode) {
+		if n.level == 0 {
+			return
+		}
+		key := quadKey{n.nw, n.ne, n.sw, n.se}
+		if _, found := w.nodes[key]; found {
+			return
+		}
+		n.next = nil
+		w.nodes[key] = n
+		keep(n.nw)
+		keep(n.ne)
+		keep(n.sw)
+		keep(n.se)
+	}
+	for _, e := range w.empty {
+		keep(e)
+	}
+	keep(w.root)
+	if w.previous != nil {
+		keep(w.previous)
+	}
+}
+
+// History returns the stats of the last generations, oldest first.
+func (w *QuadWorld) History() []types.Stats {
+	return w.history.all()
+}
+
+// WindowContent returns a string representation of the world within the given bounds.
+func (w *QuadWorld) WindowContent(topLeft, bottomRight types.Index) string {
+	buffer := &strings.Builder{}
+	limitsTopLeft, limitsBottomRight := w.Bounds()
+	fmt.Fprintf(buffer, "Turn: %d  Live Cells: %d  Limits: (%d,%d) -> (%d, %d) Changes: %d Nodes: %d Age: %s    \n",
+		w.turn,
+		w.root.population,
+		limitsTopLeft.X(),
+		limitsTopLeft.Y(),
+		limitsBottomRight.X(),
+		limitsBottomRight.Y(),
+		w.changes,
+		len(w.nodes),
+		time.Since(w.start))
+	for y := topLeft.Y(); y <= bottomRight.Y(); y++ {
+		for x := topLeft.X(); x <= bottomRight.X(); x++ {
+			if w.IsAlive(x, y) {
+				buffer.WriteByte(w.rule.Glyph(1))
+			} else {
+				buffer.WriteByte(' ')
+			}
+		}
+		fmt.Fprintln(buffer)
+	}
+	return buffer.String()
+}
+
+// MacrocellNode is a node of a quadtree as written in macrocell files, which
+// list the nodes of a tree once each, every node after its children and the
+// root last.
+type MacrocellNode struct {
+	// Level is the level of the node, LeafLevel for the 8x8 leaves.
+	Level int
+	// Children are the numbers of the nw, ne, sw and se children of nodes
+	// above the leaves, counting the nodes of the list from 1, or 0 for empty
+	// children.
+	Children [4]int
+	// Rows are the rows of a leaf, where bit x of row y is set when the cell
+	// at (x, y) from its top left corner is alive.
+	Rows [8]uint8
+}
+
+// SetMacrocell replaces the cells of the world with those of the tree of
+// nodes, which are valid: the children of every node come before it in the
+// list and are one level below it, and no node is above MaxRootLevel. The
+// root, last in the list, is centered on (0,0). The nodes of the tree become
+// nodes of the world as they are, without visiting the cells they stand for.
+func (w *QuadWorld) SetMacrocell(nodes []MacrocellNode) {
+	built := make([]*quadNode, len(nodes)+1)
+	child := func(number, level int) *quadNode {
+		if number == 0 {
+			return w.emptyNode(level)
+		}
+		return built[number]
+	}
+	for i, m := range nodes {
+		if m.Level == LeafLevel {
+			built[i+1] = w.leaf(m.Rows)
+			continue
+		}
+		built[i+1] = w.node(
+			child(m.Children[0], m.Level-1),
+			child(m.Children[1], m.Level-1),
+			child(m.Children[2], m.Level-1),
+			child(m.Children[3], m.Level-1))
+	}
+	w.root = w.emptyNode(LeafLevel)
+	if len(nodes) > 0 {
+		w.root = built[len(nodes)]
+	}
+	w.previous = nil
+}
+
+// leaf returns the 8x8 node of the rows, where bit x of row y is set when the
+// cell at (x, y) is alive.
+func (w *QuadWorld) leaf(rows [8]uint8) *quadNode {
+	var build func(level int, left, top int) *quadNode
+	build = func(level int, left, top int) *quadNode {
+		if level == 0 {
+			return w.cell(rows[top]&(1<<left) != 0)
+		}
+		half := 1 << (level - 1)
+		return w.node(
+			build(level-1, left, top),
+			build(level-1, left+half, top),
+			build(level-1, left, top+half),
+			build(level-1, left+half, top+half))
+	}
+	return build(LeafLevel, 0, 0)
+}
+
+// Macrocell returns the nodes of the tree of the world, as SetMacrocell takes
+// them, with every distinct node listed once. Empty nodes are left out, so a
+// world without cells has none.
+func (w *QuadWorld) Macrocell() []MacrocellNode {
+	root := w.root
+	for root.level < LeafLevel {
+		root = w.expand(root)
+	}
+	var nodes []MacrocellNode
+	numbers := make(map[*quadNode]int)
+	var list func(n *quadNode) int
+	list = func(n *quadNode) int {
+		if n.population == 0 {
+			return 0
+		}
+		if number, found := numbers[n]; found {
+			return number
+		}
+		m := MacrocellNode{Level: n.level}
+		if n.level == LeafLevel {
+			forEachCell(n, 0, 0, func(x, y int64) {
+				m.Rows[y] |= 1 << x
+			})
+		} else {
+			m.Children = [4]int{list(n.nw), list(n.ne), list(n.sw), list(n.se)}
+		}
+		nodes = append(nodes, m)
+		numbers[n] = len(nodes)
+		return len(nodes)
+	}
+	list(root)
+	return nodes
+}
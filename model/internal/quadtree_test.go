@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// gosperGun is the Gosper glider gun, which makes a glider every 30
+// generations.
+var gosperGun = [][2]int64{
+	{24, 0}, {22, 1}, {24, 1}, {12, 2}, {13, 2}, {20, 2}, {21, 2}, {34, 2}, {35, 2},
+	{11, 3}, {15, 3}, {20, 3}, {21, 3}, {34, 3}, {35, 3}, {0, 4}, {1, 4}, {10, 4},
+	{16, 4}, {20, 4}, {21, 4}, {0, 5}, {1, 5}, {10, 5}, {14, 5}, {16, 5}, {17, 5},
+	{22, 5}, {24, 5}, {10, 6}, {16, 6}, {24, 6}, {11, 7}, {15, 7}, {12, 8}, {13, 8},
+}
+
+// changesOf lists the cells reported by LastChanges, in order.
+func changesOf(w types.ChangeReporter) []string {
+	var changes []string
+	w.LastChanges(func(x, y int64, born bool) {
+		changes = append(changes, fmt.Sprintf("(%d,%d) born=%v", x, y, born))
+	})
+	sort.Strings(changes)
+	return changes
+}
+
+func TestQuadRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		want bool
+	}{
+		{rule: "B3/S23", want: true},
+		{rule: "B2-a3/S23-q", want: true},
+		{rule: "B13/S01V", want: true},
+		{rule: "B2/S34H", want: false},
+		{rule: "B2/S/C3", want: false},
+		{rule: "B03/S23", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			if _, got := QuadRule(mustParseRule(t, tt.rule)); got != tt.want {
+				t.Errorf("QuadRule(%s) = %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+	if _, ok := QuadRule(Wireworld{}); ok {
+		t.Errorf("QuadRule(WireWorld) = true, want false")
+	}
+}
+
+func TestQuadWorld_Cells(t *testing.T) {
+	w := NewQuadWorld(ConwayRule)
+	cells := [][2]int64{{0, 0}, {-1, -1}, {3, -4}, {1000, 5}, {-70000, 123456}}
+	w.AddCells(cells, 0)
+	for _, c := range cells {
+		if !w.IsAlive(c[0], c[1]) {
+			t.Errorf("IsAlive(%d, %d) = false, want true", c[0], c[1])
+		}
+	}
+	if w.IsAlive(1, 1) || w.IsAlive(1<<40, 0) {
+		t.Errorf("IsAlive() = true for an empty cell")
+	}
+	if got := w.Population(); got != int64(len(cells)) {
+		t.Errorf("Population() = %d, want %d", got, len(cells))
+	}
+	topLeft, bottomRight := w.Bounds()
+	if topLeft != (index{-70000, -4}) || bottomRight != (index{1000, 123456}) {
+		t.Errorf("Bounds() = %v -> %v, want (-70000,-4) -> (1000,123456)", topLeft, bottomRight)
+	}
+
+	w.RemoveCellIn(-70000, 123456)
+	w.RemoveCellIn(7, 7)
+	w.SetStateIn(3, -4, 0, 0)
+	w.SetStateIn(2, 2, 1, 0)
+	want := map[index]bool{{0, 0}: true, {-1, -1}: true, {1000, 5}: true, {2, 2}: true}
+	got := make(map[index]bool)
+	w.ForEachCell(func(x, y, turn int64) {
+		got[index{x, y}] = true
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ForEachCell() cells = %v, want %v", got, want)
+	}
+	if topLeft, bottomRight = w.Bounds(); topLeft != (index{-1, -1}) || bottomRight != (index{1000, 5}) {
+		t.Errorf("Bounds() = %v -> %v, want (-1,-1) -> (1000,5)", topLeft, bottomRight)
+	}
+}
+
+func TestQuadWorld_MatchesWorld(t *testing.T) {
+	w, q := NewWorld(), NewQuadWorld(ConwayRule)
+	w.AddCells(gosperGun, 0)
+	q.AddCells(gosperGun, 0)
+	for generation := 1; generation <= 300; generation++ {
+		w.Evolve()
+		q.Evolve()
+		if statesOf(q) != statesOf(w) {
+			t.Fatalf("generation %d: cells = %s, want %s", generation, statesOf(q), statesOf(w))
+		}
+		if got, want := changesOf(q), changesOf(w); !reflect.DeepEqual(got, want) {
+			t.Fatalf("generation %d: LastChanges() = %v, want %v", generation, got, want)
+		}
+	}
+	if q.Turn() != 300 {
+		t.Errorf("Turn() = %d, want 300", q.Turn())
+	}
+	got, want := q.History(), w.History()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %v, want %v", got[len(got)-1], want[len(want)-1])
+	}
+}
+
+func TestQuadWorld_LargestRoot(t *testing.T) {
+	// A block at the far corner of the largest root lives on, and a cell
+	// beyond it is left out
+	w := NewQuadWorld(ConwayRule)
+	far := int64(-1 << 60)
+	w.AddCells([][2]int64{{far, far}, {far + 1, far}, {far, far + 1}, {far + 1, far + 1}, {far - 1, 0}}, 0)
+	if w.root.level != MaxRootLevel {
+		t.Fatalf("root level = %d, want %d", w.root.level, MaxRootLevel)
+	}
+	for generation := 0; generation < 2; generation++ {
+		w.Evolve()
+	}
+	want := map[index]bool{{far, far}: true, {far + 1, far}: true, {far, far + 1}: true, {far + 1, far + 1}: true}
+	got := make(map[index]bool)
+	w.ForEachCell(func(x, y, turn int64) {
+		got[index{x, y}] = true
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ForEachCell() cells = %v, want %v", got, want)
+	}
+}
+
+func TestQuadWorld_Macrocell(t *testing.T) {
+	// A block repeated far apart is stored once
+	w := NewQuadWorld(ConwayRule)
+	for _, corner := range [][2]int64{{0, 0}, {1 << 20, 0}, {0, 1 << 20}, {-(1 << 30), 5 << 20}} {
+		w.AddCells([][2]int64{corner, {corner[0] + 1, corner[1]}, {corner[0], corner[1] + 1}, {corner[0] + 1, corner[1] + 1}}, 0)
+	}
+	nodes := w.Macrocell()
+	leaves := 0
+	for _, m := range nodes {
+		if m.Level == LeafLevel {
+			leaves++
+		}
+	}
+	if leaves != 1 {
+		t.Errorf("Macrocell() has %d leaves, want 1", leaves)
+	}
+
+	again := NewQuadWorld(ConwayRule)
+	again.SetMacrocell(nodes)
+	if statesOf(again) != statesOf(w) {
+		t.Errorf("SetMacrocell() cells = %s, want %s", statesOf(again), statesOf(w))
+	}
+	if empty := NewQuadWorld(ConwayRule).Macrocell(); len(empty) != 0 {
+		t.Errorf("Macrocell() of an empty world = %v, want no nodes", empty)
+	}
+}
+
+func BenchmarkQuadWorld_Gun(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		w := NewQuadWorld(ConwayRule)
+		w.AddCells(gosperGun, 0)
+		for generation := 0; generation < 10000; generation++ {
+			w.Evolve()
+		}
+	}
+}
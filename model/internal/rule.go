@@ -0,0 +1,385 @@
+package internal
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// stateGlyphs are drawn for living cells (the first glyph) and for the dying
+// states of Generations rules, from the youngest to the oldest.
+const stateGlyphs = "xo+-:."
+
+// maxStates is the largest number of cell states a Generations rule may have,
+// which is what the RLE letters can represent.
+const maxStates = 256
+
+// Rule decides the births and survivals of cells from their number of living
+// neighbors. With more than two states it is a Generations rule: a living cell
+// that does not survive goes through states 2 to states-1 before dying, one per
+// generation, and those dying cells neither count as neighbors nor let a cell
+// be born in their place.
+//
+// A rule may count a smaller neighborhood than the 8 surrounding cells: the
+// H suffix selects the hexagonal neighborhood and the V suffix the von Neumann
+// neighborhood.
+//
+// A rule in the Hensel notation ("B2-a/S12") is isotropic non-totalistic: it
+// depends on the arrangement of the living neighbors, named by a letter after
+// their count, and not just on the count. Its transitions are looked up in a
+// table indexed by the configuration of the 8 neighbors.
+type Rule struct {
+	birth, survival [9]bool
+	// Letters of the configurations of each count that are born or survive,
+	// empty when every configuration of the count is
+	birthLetters, survivalLetters [9]string
+	transitions                   *[2][256]bool
+	states                        int
+	shape                         byte
+}
+
+// Neighborhood shapes of a Rule, as written at the end of its rulestring.
+const (
+	mooreShape      = 0   // The 8 surrounding cells
+	hexagonalShape  = 'H' // 6 cells of a hexagonal grid drawn as a sheared square grid
+	vonNeumannShape = 'V' // The 4 orthogonal cells
+)
+
+// henselLetters are the letters naming the configurations of each number of
+// neighbors in the Hensel notation, in canonical order.
+var henselLetters = [9]string{"", "ce", "ceaikn", "ceaiknjqry", "ceaiknjqrtwyz", "ceaiknjqry", "ceaikn", "ce", ""}
+
+// henselConfigurations holds a configuration named by each letter for counts 1
+// to 4, with bit i set when neighbor i of mooreNeighborhood is alive. Every
+// rotation and reflection of a configuration has its letter, and the
+// configurations of counts 5 to 7 are the complements of those of 3 to 1.
+var henselConfigurations = [5]map[byte]uint8{
+	1: {'c': 0x01, 'e': 0x02},
+	2: {'c': 0x05, 'e': 0x0a, 'a': 0x03, 'i': 0x18, 'k': 0x11, 'n': 0x24},
+	3: {'c': 0x25, 'e': 0x1a, 'a': 0x0b, 'i': 0x07, 'k': 0x32, 'n': 0x0d, 'j': 0x0e, 'q': 0x26, 'r': 0x19, 'y': 0x31},
+	4: {'c': 0xa5, 'e': 0x5a, 'a': 0x0f, 'i': 0x1d, 'k': 0x33, 'n': 0x27, 'j': 0x3a, 'q': 0x36, 'r': 0x1b,
+		't': 0x35, 'w': 0x39, 'y': 0x2e, 'z': 0x3c},
+}
+
+// henselLetterOf holds the letter of every configuration of the 8 neighbors.
+var henselLetterOf = henselLetterTable()
+
+// henselLetterTable names every configuration after the letter of the
+// configuration it is a rotation or reflection of.
+func henselLetterTable() [256]byte {
+	var table [256]byte
+	for n := 1; n <= 4; n++ {
+		for letter, configuration := range henselConfigurations[n] {
+			for _, c := range symmetries(configuration) {
+				table[c] = letter
+				if n < 4 {
+					table[^c] = letter
+				}
+			}
+		}
+	}
+	return table
+}
+
+// symmetries returns the configuration with its 4 rotations, each of them
+// reflected or not.
+func symmetries(configuration uint8) []uint8 {
+	var all []uint8
+	for t := 0; t < 8; t++ {
+		var c uint8
+		for i, neighbor := range mooreNeighborhood {
+			if configuration&(1<<i) == 0 {
+				continue
+			}
+			x, y := neighbor.x, neighbor.y
+			if t >= 4 {
+				x = -x
+			}
+			for r := 0; r < t%4; r++ {
+				x, y = -y, x
+			}
+			for j, other := range mooreNeighborhood {
+				if other.x == x && other.y == y {
+					c |= 1 << j
+				}
+			}
+		}
+		all = append(all, c)
+	}
+	return all
+}
+
+// ConwayRule is the rule of Conway's Game of Life, B3/S23.
+var ConwayRule = &Rule{
+	birth:    [9]bool{3: true},
+	survival: [9]bool{2: true, 3: true},
+	states:   2,
+}
+
+// ParseRule parses a rulestring in the B/S notation ("B36/S23"), with an
+// optional number of states for Generations rules ("B2/S/C3"), or in the
+// S/B and S/B/C notations ("23/36", "/2/3"). An H or V at the end selects the
+// hexagonal or von Neumann neighborhood ("B2/S34H"). Counts of the Moore
+// neighborhood may be followed by Hensel letters, which select some of their
+// configurations, or by a minus sign and letters, which select all but those
+// ("B3/S2-i34q").
+func ParseRule(rule string) (*Rule, error) {
+	r := &Rule{states: 2}
+	text := strings.ToUpper(strings.TrimSpace(rule))
+	if strings.HasSuffix(text, "H") || strings.HasSuffix(text, "V") {
+		r.shape = text[len(text)-1]
+		text = text[:len(text)-1]
+	}
+	maxCount := rune('0' + len(r.Neighborhood()))
+	parts := strings.Split(text, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("unsupported rule %q", rule)
+	}
+
+	prefixed := strings.HasPrefix(parts[0], "B") || strings.HasPrefix(parts[0], "S")
+	var seen [3]bool
+	for i, part := range parts {
+		kind := i
+		if prefixed {
+			switch {
+			case strings.HasPrefix(part, "S"):
+				kind = 0
+			case strings.HasPrefix(part, "B"):
+				kind = 1
+			case strings.HasPrefix(part, "C"), strings.HasPrefix(part, "G"):
+				kind = 2
+			default:
+				return nil, fmt.Errorf("unsupported rule %q", rule)
+			}
+			part = part[1:]
+		}
+		if seen[kind] {
+			return nil, fmt.Errorf("unsupported rule %q", rule)
+		}
+		seen[kind] = true
+
+		switch kind {
+		case 0, 1:
+			counts, letters := &r.survival, &r.survivalLetters
+			if kind == 1 {
+				counts, letters = &r.birth, &r.birthLetters
+			}
+			if err := r.parseCounts(strings.ToLower(part), counts, letters, maxCount); err != nil {
+				return nil, fmt.Errorf("unsupported rule %q: %w", rule, err)
+			}
+		case 2:
+			states, err := strconv.Atoi(part)
+			if err != nil || states < 2 || states > maxStates {
+				return nil, fmt.Errorf("unsupported rule %q: states must be between 2 and %d", rule, maxStates)
+			}
+			r.states = states
+		}
+	}
+	if !seen[0] || !seen[1] {
+		return nil, fmt.Errorf("unsupported rule %q", rule)
+	}
+	r.buildTransitions()
+	return r, nil
+}
+
+// parseCounts parses the neighbor counts of a birth or survival part, each of
+// them with optional Hensel letters.
+func (r *Rule) parseCounts(part string, counts *[9]bool, letters *[9]string, maxCount rune) error {
+	for i := 0; i < len(part); {
+		c := rune(part[i])
+		if c < '0' || c > maxCount {
+			return fmt.Errorf("invalid neighbor count %q", c)
+		}
+		n := int(c - '0')
+		i++
+		negated := i < len(part) && part[i] == '-'
+		if negated {
+			i++
+		}
+		start := i
+		for i < len(part) && part[i] >= 'a' && part[i] <= 'z' {
+			if !strings.ContainsRune(henselLetters[n], rune(part[i])) {
+				return fmt.Errorf("invalid letter %q for %d neighbors", part[i], n)
+			}
+			i++
+		}
+		given := part[start:i]
+		if given == "" {
+			if negated {
+				return fmt.Errorf("missing letters after %d-", n)
+			}
+			counts[n], letters[n] = true, ""
+			continue
+		}
+		if r.shape != mooreShape {
+			return fmt.Errorf("letters need the Moore neighborhood")
+		}
+
+		// Add the selected letters to those already given for the count
+		selected := ""
+		for _, l := range henselLetters[n] {
+			previous := counts[n] && (letters[n] == "" || strings.ContainsRune(letters[n], l))
+			if previous || strings.ContainsRune(given, l) != negated {
+				selected += string(l)
+			}
+		}
+		counts[n] = selected != ""
+		letters[n] = selected
+		if selected == henselLetters[n] {
+			letters[n] = ""
+		}
+	}
+	return nil
+}
+
+// buildTransitions fills the table of transitions of a rule with Hensel
+// letters; totalistic rules only need the counts.
+func (r *Rule) buildTransitions() {
+	if r.birthLetters == [9]string{} && r.survivalLetters == [9]string{} {
+		return
+	}
+	r.transitions = &[2][256]bool{}
+	for configuration := 0; configuration < 256; configuration++ {
+		n := bits.OnesCount8(uint8(configuration))
+		letter := rune(henselLetterOf[configuration])
+		r.transitions[0][configuration] = r.birth[n] &&
+			(r.birthLetters[n] == "" || strings.ContainsRune(r.birthLetters[n], letter))
+		r.transitions[1][configuration] = r.survival[n] &&
+			(r.survivalLetters[n] == "" || strings.ContainsRune(r.survivalLetters[n], letter))
+	}
+}
+
+// States returns the number of cell states, counting the dead state.
+func (r *Rule) States() int {
+	return r.states
+}
+
+// String returns the rule in the B/S notation, with the number of states for
+// Generations rules. Hensel letters are written in canonical order, after a
+// minus sign when that makes them fewer.
+func (r *Rule) String() string {
+	buffer := &strings.Builder{}
+	buffer.WriteString("B")
+	writeCounts(buffer, r.birth, r.birthLetters)
+	buffer.WriteString("/S")
+	writeCounts(buffer, r.survival, r.survivalLetters)
+	if r.states > 2 {
+		fmt.Fprintf(buffer, "/C%d", r.states)
+	}
+	if r.shape != mooreShape {
+		buffer.WriteByte(r.shape)
+	}
+	return buffer.String()
+}
+
+// writeCounts writes the neighbor counts of a birth or survival part, with
+// their Hensel letters.
+func writeCounts(buffer *strings.Builder, counts [9]bool, letters [9]string) {
+	for n, selected := range counts {
+		if !selected {
+			continue
+		}
+		buffer.WriteString(strconv.Itoa(n))
+		if letters[n] == "" {
+			continue
+		}
+		missing := ""
+		for _, l := range henselLetters[n] {
+			if !strings.ContainsRune(letters[n], l) {
+				missing += string(l)
+			}
+		}
+		if len(missing) < len(letters[n]) {
+			buffer.WriteString("-" + missing)
+		} else {
+			buffer.WriteString(letters[n])
+		}
+	}
+}
+
+// Name returns the rule in the B/S notation, as String does.
+func (r *Rule) Name() string {
+	return r.String()
+}
+
+// Neighborhood returns the cells counted as neighbors: the Moore, hexagonal
+// or von Neumann neighborhood.
+func (r *Rule) Neighborhood() []index {
+	switch r.shape {
+	case hexagonalShape:
+		return hexagonalNeighborhood
+	case vonNeumannShape:
+		return vonNeumannNeighborhood
+	}
+	return mooreNeighborhood
+}
+
+// Next returns the state of a cell in the next generation, counting the living
+// cells among its neighbors, or from their configuration for a rule with
+// Hensel letters.
+func (r *Rule) Next(state int, neighbors []int) int {
+	count := 0
+	var configuration uint8
+	for i, n := range neighbors {
+		if n == 1 {
+			count++
+			configuration |= 1 << i
+		}
+	}
+	if r.transitions != nil {
+		return r.nextIsotropic(state, configuration)
+	}
+	return r.next(state, count)
+}
+
+// Glyph returns the character drawn for a cell state. Dying states share the
+// remaining glyphs evenly when there are more of them than glyphs.
+func (r *Rule) Glyph(state int) byte {
+	return stateGlyph(state, r.states)
+}
+
+// stateGlyph returns the glyph of a state of a rule with the given number of
+// states, where states above 1 are dying.
+func stateGlyph(state, states int) byte {
+	if state <= 1 {
+		return stateGlyphs[0]
+	}
+	dying := len(stateGlyphs) - 1
+	if states-2 <= dying {
+		return stateGlyphs[state-1]
+	}
+	return stateGlyphs[1+(state-2)*dying/(states-2)]
+}
+
+// next returns the state of a cell in the next generation, from its current
+// state and its number of living neighbors.
+func (r *Rule) next(state, neighbors int) int {
+	return r.transition(state, r.birth[neighbors], r.survival[neighbors])
+}
+
+// nextIsotropic returns the state of a cell in the next generation, from its
+// current state and the configuration of its living neighbors, as built by
+// World.configurationOf.
+func (r *Rule) nextIsotropic(state int, configuration uint8) int {
+	return r.transition(state, r.transitions[0][configuration], r.transitions[1][configuration])
+}
+
+// transition returns the state of a cell in the next generation, from its
+// current state and whether a dead cell in its place would be born and a
+// living one would survive.
+func (r *Rule) transition(state int, born, survives bool) int {
+	switch {
+	case state == 0:
+		if born {
+			return 1
+		}
+		return 0
+	case state == 1 && survives:
+		return 1
+	case state+1 < r.states:
+		return state + 1
+	default:
+		return 0
+	}
+}
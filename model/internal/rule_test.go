@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"math/bits"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		want       string
+		wantStates int
+		wantErr    bool
+	}{
+		{name: "conway", rule: "B3/S23", want: "B3/S23", wantStates: 2},
+		{name: "lower case", rule: "b36/s23", want: "B36/S23", wantStates: 2},
+		{name: "survival first", rule: "S23/B3", want: "B3/S23", wantStates: 2},
+		{name: "S/B notation", rule: "23/3", want: "B3/S23", wantStates: 2},
+		{name: "generations", rule: "B2/S/C3", want: "B2/S/C3", wantStates: 3},
+		{name: "generations S/B/C notation", rule: "345/2/4", want: "B2/S345/C4", wantStates: 4},
+		{name: "two states is life-like", rule: "B3/S23/C2", want: "B3/S23", wantStates: 2},
+		{name: "hexagonal", rule: "B2/S34H", want: "B2/S34H", wantStates: 2},
+		{name: "von neumann", rule: "b1/s012v", want: "B1/S012V", wantStates: 2},
+		{name: "hexagonal generations", rule: "345/2/4H", want: "B2/S345/C4H", wantStates: 4},
+		{name: "hensel", rule: "B2-a/S12", want: "B2-a/S12", wantStates: 2},
+		{name: "tlife", rule: "b3/s2-i34q", want: "B3/S2-i34q", wantStates: 2},
+		{name: "hensel canonical", rule: "B2ceikn/S3acijknqry4a", want: "B2-a/S3-e4a", wantStates: 2},
+		{name: "hensel all letters", rule: "B3/S1ce2", want: "B3/S12", wantStates: 2},
+		{name: "hensel merged", rule: "B2a2c/S", want: "B2ca/S", wantStates: 2},
+		{name: "hensel generations", rule: "B2n/S1e/C5", want: "B2n/S1e/C5", wantStates: 5},
+		{name: "empty", rule: "", wantErr: true},
+		{name: "invalid letter", rule: "B2z/S23", wantErr: true},
+		{name: "letter for 0", rule: "B3/S0c", wantErr: true},
+		{name: "minus without letters", rule: "B3-/S23", wantErr: true},
+		{name: "letters with hexagonal", rule: "B2a/S34H", wantErr: true},
+		{name: "count too high for hexagonal", rule: "B7/S2H", wantErr: true},
+		{name: "count too high for von neumann", rule: "B5/S2V", wantErr: true},
+		{name: "neighbor count too high", rule: "B9/S23", wantErr: true},
+		{name: "missing survival", rule: "B3/C3", wantErr: true},
+		{name: "repeated part", rule: "B3/B3", wantErr: true},
+		{name: "too many states", rule: "B2/S/C300", wantErr: true},
+		{name: "one state", rule: "B2/S/C1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRule(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRule(%q) = %s, want an error", tt.rule, r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) unexpected error: %v", tt.rule, err)
+			}
+			if r.String() != tt.want || r.States() != tt.wantStates {
+				t.Errorf("ParseRule(%q) = %s with %d states, want %s with %d", tt.rule, r, r.States(), tt.want, tt.wantStates)
+			}
+		})
+	}
+}
+
+func TestWorld_EvolveGenerations(t *testing.T) {
+	// In Brian's Brain a domino bursts: its cells die while new ones are born
+	// around them
+	rule, err := ParseRule("B2/S/C3")
+	if err != nil {
+		t.Fatalf("ParseRule() unexpected error: %v", err)
+	}
+	w := NewWorldWithRule(rule)
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+
+	w.Evolve()
+	w.Evolve()
+
+	want := map[index]int{
+		{0, -2}: 1, {1, -2}: 1,
+		{0, -1}: 2, {1, -1}: 2,
+		{0, 1}: 2, {1, 1}: 2,
+		{0, 2}: 1, {1, 2}: 1,
+		{-1, 0}: 1, {2, 0}: 1,
+	}
+	for location, state := range want {
+		if got := w.StateOf(location.x, location.y); got != state {
+			t.Errorf("StateOf(%d, %d) = %d, want %d", location.x, location.y, got, state)
+		}
+	}
+	if len(w.cells) != len(want) {
+		t.Errorf("world has %d cells, want %d", len(w.cells), len(want))
+	}
+	if w.IsAlive(0, -1) {
+		t.Error("dying cell reported as alive")
+	}
+
+	born, died := 0, 0
+	w.LastChanges(func(x, y int64, isBorn bool) {
+		if isBorn {
+			born++
+		} else {
+			died++
+		}
+	})
+	if born != 6 || died != 4 {
+		t.Errorf("LastChanges() reported %d births and %d deaths, want 6 and 4", born, died)
+	}
+
+	content := w.WindowContent(index{0, -2}, index{1, 2})
+	if !containsLines(content, "xx", "oo", "  ", "oo", "xx") {
+		t.Errorf("WindowContent() = %q, want living and dying glyphs", content)
+	}
+}
+
+func TestWorld_NeighborhoodShapes(t *testing.T) {
+	for _, rule := range []string{"B2/S34H", "B2/S/C4H", "B1/S012V", "B13/S01V"} {
+		t.Run(rule, func(t *testing.T) {
+			r, err := ParseRule(rule)
+			if err != nil {
+				t.Fatalf("ParseRule() unexpected error: %v", err)
+			}
+			counted, general := NewEngine(r), NewAutomatonWorld(r)
+			soup(counted, 16, r.States(), 3)
+			soup(general, 16, r.States(), 3)
+			for i := 0; i < 10; i++ {
+				counted.Evolve()
+				general.Evolve()
+				sameStates(t, general, counted)
+			}
+		})
+	}
+}
+
+func TestWorld_SingleCellBirths(t *testing.T) {
+	tests := []struct {
+		rule string
+		want int
+	}{
+		{rule: "B1/S", want: 8},
+		{rule: "B1/SH", want: 6},
+		{rule: "B1/SV", want: 4},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRule(%q) unexpected error: %v", tt.rule, err)
+		}
+		w := NewEngine(r)
+		w.AddCellIn(0, 0, 0)
+		w.Evolve()
+		got := 0
+		w.ForEachCell(func(x, y, turn int64) {
+			got++
+		})
+		if got != tt.want {
+			t.Errorf("%s: a single cell gives %d cells, want %d", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestHenselLetters(t *testing.T) {
+	// Every configuration has a letter of its count, and the configurations of
+	// a letter are rotations and reflections of each other
+	for count, letters := range henselLetters {
+		found := map[byte]int{}
+		for c := 0; c < 256; c++ {
+			if bits.OnesCount8(uint8(c)) == count {
+				found[henselLetterOf[c]]++
+			}
+		}
+		if count == 0 || count == 8 {
+			continue
+		}
+		if len(found) != len(letters) {
+			t.Errorf("count %d has letters %v, want %s", count, found, letters)
+		}
+		for _, l := range []byte(letters) {
+			if found[l] == 0 {
+				t.Errorf("count %d has no configuration for letter %c", count, l)
+			}
+		}
+	}
+	for c := 0; c < 256; c++ {
+		for _, s := range symmetries(uint8(c)) {
+			if henselLetterOf[s] != henselLetterOf[c] {
+				t.Errorf("configurations %#x and %#x are symmetric but have letters %c and %c",
+					c, s, henselLetterOf[c], henselLetterOf[s])
+			}
+		}
+	}
+}
+
+func TestWorld_EvolveIsotropic(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		cells  []index
+		period int
+		dx, dy int64
+	}{
+		{
+			name:   "tlife glider",
+			rule:   "B3/S2-i34q",
+			cells:  []index{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}},
+			period: 4, dx: 1, dy: 1,
+		},
+		{
+			name:   "B2-a/S12 three-cell spaceship",
+			rule:   "B2-a/S12",
+			cells:  []index{{0, 0}, {2, 0}, {0, 1}},
+			period: 6, dx: -1, dy: -1,
+		},
+		{
+			// With every letter, the rule is Conway's and so is the glider
+			name:   "conway with letters",
+			rule:   "B3cekainyqjr/S2-n2n3",
+			cells:  []index{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}},
+			period: 4, dx: 1, dy: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRule() unexpected error: %v", err)
+			}
+			w, general := NewWorldWithRule(r), NewAutomatonWorld(r)
+			for _, c := range tt.cells {
+				w.AddCellIn(c.x, c.y, 0)
+				general.AddCellIn(c.x, c.y, 0)
+			}
+			for i := 0; i < 3*tt.period; i++ {
+				w.Evolve()
+				general.Evolve()
+			}
+			sameStates(t, general, w)
+
+			// After three periods the spaceship has moved by three steps
+			moved := NewWorldWithRule(r)
+			for _, c := range tt.cells {
+				moved.AddCellIn(c.x+3*tt.dx, c.y+3*tt.dy, 0)
+			}
+			sameStates(t, moved, w)
+		})
+	}
+}
+
+// containsLines checks the lines of a window content, after its status line.
+func containsLines(content string, lines ...string) bool {
+	want := ""
+	for _, line := range lines {
+		want += line + "\n"
+	}
+	for i, c := range content {
+		if c == '\n' {
+			return content[i+1:] == want
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package internal
+
+// Wireworld states.
+const (
+	wireEmpty     = iota // Nothing
+	wireHead             // Electron head
+	wireTail             // Electron tail
+	wireConductor        // Wire that electrons travel along
+)
+
+// wireGlyphs are drawn for the electron head, tail and conductor.
+const wireGlyphs = "@~#"
+
+// Wireworld is Brian Silverman's automaton for electronic circuits. Electron
+// heads become tails, tails become conductor again, and a conductor becomes
+// a head when one or two of its Moore neighbors are heads.
+type Wireworld struct{}
+
+// Name returns "WireWorld".
+func (Wireworld) Name() string {
+	return "WireWorld"
+}
+
+// States returns 4: empty, head, tail and conductor.
+func (Wireworld) States() int {
+	return 4
+}
+
+// Neighborhood returns the Moore neighborhood.
+func (Wireworld) Neighborhood() []index {
+	return mooreNeighborhood
+}
+
+// Next applies the Wireworld transitions.
+func (Wireworld) Next(state int, neighbors []int) int {
+	switch state {
+	case wireHead:
+		return wireTail
+	case wireTail:
+		return wireConductor
+	case wireConductor:
+		heads := 0
+		for _, n := range neighbors {
+			if n == wireHead {
+				heads++
+			}
+		}
+		if heads == 1 || heads == 2 {
+			return wireHead
+		}
+		return wireConductor
+	}
+	return wireEmpty
+}
+
+// Glyph returns the character drawn for a head, tail or conductor.
+func (Wireworld) Glyph(state int) byte {
+	return wireGlyphs[state-1]
+}
@@ -2,40 +2,139 @@ package model
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/daniel-munoz/life/model/internal"
 	"github.com/daniel-munoz/life/types"
 )
 
-// ReadWorld loads a world pattern from a .life file in the samples directory.
-// Non-space characters in the file represent living cells.
+// SampleExtensions are the extensions of the pattern files in the samples
+// directory, in the order they are looked for.
+var SampleExtensions = []string{".life", ".rle", composeExtension, ".cells", ".lif", macrocellExtension}
+
+// ReadWorld reads the named sample from the samples directory, in the first
+// of SampleExtensions it is found with. A name ending in one of them is read
+// as the path of a pattern file instead, and an apgcode, such as "xq4_153", as
+// the object it encodes.
 func ReadWorld(sampleName string) (types.World, error) {
-	var (
-		x, y    int64
-		scanner *bufio.Scanner
-	)
-	newWorld := internal.NewWorld()
+	return readWorld(sampleName, 0, QuadtreeEngine)
+}
+
+// ReadWorldWithEngine reads a world as ReadWorld does, reading macrocell files
+// with the given engine.
+func ReadWorldWithEngine(sampleName string, engine Engine) (types.World, error) {
+	return readWorld(sampleName, 0, engine)
+}
+
+// readWorld reads a world as ReadWorld does, from a composition nested at the
+// given depth, reading macrocell files with the engine.
+func readWorld(sampleName string, depth int, engine Engine) (types.World, error) {
+	if isApgcode(sampleName) {
+		return ReadApgcode(sampleName)
+	}
+	if isPatternPath(sampleName) {
+		return readPatternFile(sampleName, depth, engine)
+	}
+
+	var firstErr error
+	for _, extension := range SampleExtensions {
+		w, err := readPatternFile(fmt.Sprintf("./samples/%s%s", sampleName, extension), depth, engine)
+		if !os.IsNotExist(err) {
+			return w, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return internal.NewWorld(), firstErr
+}
+
+// isPatternPath reports whether the name is the path of a pattern file rather
+// than the name of a sample.
+func isPatternPath(name string) bool {
+	for _, extension := range SampleExtensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
 
-	filename := fmt.Sprintf("./samples/%s.life", sampleName)
-	f, err := os.Open(filename)
+// readPatternFile reads the pattern file at path in the format given by its
+// extension, as part of a composition nested at the given depth, and macrocell
+// files with the engine: .rle files with ReadRLE, .cells files with ReadCells,
+// .mc files with ReadMacrocell and .compose files as compositions. The format
+// of .life and .lif files is found by ReadPattern.
+func readPatternFile(path string, depth int, engine Engine) (types.World, error) {
+	if strings.HasSuffix(path, composeExtension) {
+		return readCompositionFile(path, depth)
+	}
+	f, err := os.Open(path)
 	if err != nil {
-		return newWorld, err
+		return nil, err
 	}
 	defer f.Close()
+	switch filepath.Ext(path) {
+	case ".rle":
+		return ReadRLE(f)
+	case ".cells":
+		return ReadCells(f)
+	case macrocellExtension:
+		return ReadMacrocell(f, engine)
+	}
+	return ReadPattern(f)
+}
 
-	scanner = bufio.NewScanner(f)
+// ReadLife reads a pattern drawn as text, as in the .life samples: every
+// character other than a space is a living cell, at the column of the
+// character and the row of its line, counting from (0,0).
+func ReadLife(r io.Reader) (types.World, error) {
+	var (
+		x, y  int64
+		cells [][2]int64
+	)
+	scanner := newLineScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 		x = 0
 		for _, c := range line {
 			if c != ' ' {
-				newWorld.AddCellIn(x, y, 0)
+				cells = append(cells, [2]int64{x, y})
 			}
 			x++
 		}
 		y++
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(int(y)+1, err)
+	}
+	newWorld := internal.NewWorld()
+	newWorld.AddCells(cells, 0)
 	return newWorld, nil
 }
+
+// maxLineLength is the length of the longest line pattern files may have.
+// Some RLE files hold a whole pattern on one line, much longer than the lines
+// a bufio.Scanner accepts by default.
+const maxLineLength = 64 << 20
+
+// newLineScanner returns a scanner of the lines of r that accepts lines up to
+// maxLineLength bytes long.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return scanner
+}
+
+// lineError describes an error of the scanner reading the given line.
+func lineError(lineNumber int, err error) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("line %d: longer than %d bytes", lineNumber, maxLineLength)
+	}
+	return fmt.Errorf("line %d: %w", lineNumber, err)
+}
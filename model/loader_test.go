@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/daniel-munoz/life/types"
 )
 
 func TestReadWorld(t *testing.T) {
@@ -152,3 +154,46 @@ func TestReadWorld(t *testing.T) {
 		}
 	})
 }
+
+func TestReadWorld_RLEPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brain.rle")
+	if err := os.WriteFile(path, []byte("x = 2, y = 1, rule = B2/S/C3\n2A!\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	w, err := ReadWorld(path)
+	if err != nil {
+		t.Fatalf("ReadWorld() unexpected error: %v", err)
+	}
+	if rule := w.(types.RuleReporter).Rule(); rule != "B2/S/C3" {
+		t.Errorf("ReadWorld() rule = %s, want B2/S/C3", rule)
+	}
+	if got := cellsOf(w); len(got) != 2 {
+		t.Errorf("ReadWorld() cells = %v, want 2 cells", got)
+	}
+}
+
+func TestReadWorld_RLESample(t *testing.T) {
+	if err := os.MkdirAll("samples", 0755); err != nil {
+		t.Fatalf("Failed to create samples directory: %v", err)
+	}
+	defer os.RemoveAll("samples")
+	rle := "x = 4, y = 1, rule = WireWorld\nBA2C!\n"
+	if err := os.WriteFile(filepath.Join("samples", "wire.rle"), []byte(rle), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	w, err := ReadWorld("wire")
+	if err != nil {
+		t.Fatalf("ReadWorld() unexpected error: %v", err)
+	}
+	multi, ok := w.(types.MultiState)
+	if !ok || multi.States() != 4 {
+		t.Fatalf("ReadWorld() did not return a Wireworld world")
+	}
+	w.Evolve()
+	for x, want := range []int{3, 2, 1, 3} {
+		if got := multi.StateOf(int64(x), 0); got != want {
+			t.Errorf("StateOf(%d, 0) = %d, want %d", x, got, want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// macrocellExtension is the extension of macrocell files.
+const macrocellExtension = ".mc"
+
+// macrocellHeader starts the first line of macrocell files.
+const macrocellHeader = "[M2]"
+
+// maxMacrocellCells is the number of cells of the largest macrocell pattern
+// whose cells are added one by one to a world.
+const maxMacrocellCells = 1 << 24
+
+// ReadMacrocell reads a pattern in Golly's macrocell format, which stores a
+// quadtree with every distinct node written once. After the "[M2]" header,
+// "#R" gives the rule, "#G" the generation, and other "#" lines are comments.
+// Each of the other lines is a node: an 8x8 leaf drawn with "." for dead
+// cells, "*" for living ones and "$" at the end of every row, or a larger node
+// given as its level and the numbers of the lines of its nw, ne, sw and se
+// children, counting nodes from 1 and with 0 for empty children. The last
+// node is the root, centered on (0,0).
+//
+// With the quadtree engine, the nodes become the nodes of a quadtree world
+// without visiting the cells they stand for, as long as the rule is a
+// two-state rule such a world can follow. Otherwise, and with the map engine,
+// every cell is added to a world of the rule.
+func ReadMacrocell(r io.Reader, engine Engine) (types.World, error) {
+	var (
+		nodes      []internal.MacrocellNode
+		turn       int64
+		lineNumber int
+		rootLine   int
+		header     bool
+		rule       internal.Automaton = internal.ConwayRule
+	)
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if !header {
+			if line == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, macrocellHeader) {
+				return nil, fmt.Errorf("line %d: missing %q header", lineNumber, macrocellHeader)
+			}
+			header = true
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "#R":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: rule needs one rulestring", lineNumber)
+			}
+			var err error
+			if rule, err = internal.ParseAutomaton(fields[1]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			continue
+		case fields[0] == "#G":
+			var err error
+			if len(fields) != 2 {
+				err = fmt.Errorf("missing number")
+			} else {
+				turn, err = strconv.ParseInt(fields[1], 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid generation %q", lineNumber, line)
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		}
+
+		var (
+			node internal.MacrocellNode
+			err  error
+		)
+		if line[0] >= '0' && line[0] <= '9' {
+			node, err = parseMacrocellNode(fields, nodes)
+		} else {
+			node, err = parseMacrocellLeaf(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		nodes = append(nodes, node)
+		rootLine = lineNumber
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(lineNumber+1, err)
+	}
+	if !header {
+		return nil, fmt.Errorf("line %d: missing %q header", lineNumber+1, macrocellHeader)
+	}
+
+	if quadRule, ok := internal.QuadRule(rule); ok && engine == QuadtreeEngine {
+		w := internal.NewQuadWorld(quadRule)
+		w.SetMacrocell(nodes)
+		w.SetTurn(turn)
+		return w, nil
+	}
+	var cells [][2]int64
+	if len(nodes) > 0 {
+		populations := macrocellPopulations(nodes)
+		if populations[len(nodes)] > maxMacrocellCells {
+			return nil, fmt.Errorf("line %d: pattern of more than %d cells, too many to add one by one", rootLine, maxMacrocellCells)
+		}
+		half := int64(1) << (nodes[len(nodes)-1].Level - 1)
+		cells = macrocellCells(nodes, populations, len(nodes), -half, -half, cells)
+	}
+	w := internal.NewEngine(rule)
+	w.AddCells(cells, 0)
+	w.SetTurn(turn)
+	return w, nil
+}
+
+// parseMacrocellLeaf parses a leaf line, such as ".*$..*$***$".
+func parseMacrocellLeaf(line string) (internal.MacrocellNode, error) {
+	node := internal.MacrocellNode{Level: internal.LeafLevel}
+	x, y := 0, 0
+	for _, c := range []byte(line) {
+		switch c {
+		case '$':
+			x, y = 0, y+1
+			continue
+		case '.', '*':
+		default:
+			return node, fmt.Errorf("unexpected character %q", c)
+		}
+		if x >= 8 || y >= 8 {
+			return node, fmt.Errorf("leaf %q is larger than 8x8", line)
+		}
+		if c == '*' {
+			node.Rows[y] |= 1 << x
+		}
+		x++
+	}
+	return node, nil
+}
+
+// parseMacrocellNode parses the fields of a node line, such as "4 1 0 0 2",
+// whose children are among the nodes read before it.
+func parseMacrocellNode(fields []string, nodes []internal.MacrocellNode) (internal.MacrocellNode, error) {
+	node := internal.MacrocellNode{}
+	if len(fields) != 5 {
+		return node, fmt.Errorf("invalid node %q (want level nw ne sw se)", strings.Join(fields, " "))
+	}
+	level, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return node, fmt.Errorf("invalid node %q (want level nw ne sw se)", strings.Join(fields, " "))
+	}
+	if level <= internal.LeafLevel || level > internal.MaxRootLevel {
+		if level == 1 {
+			return node, fmt.Errorf("multi-state nodes are not supported")
+		}
+		return node, fmt.Errorf("node level %d out of range %d to %d", level, internal.LeafLevel+1, internal.MaxRootLevel)
+	}
+	node.Level = level
+	for i, field := range fields[1:] {
+		child, err := strconv.Atoi(field)
+		if err != nil || child < 0 || child > len(nodes) {
+			return node, fmt.Errorf("child %q is not a node before this one", field)
+		}
+		if child > 0 && nodes[child-1].Level != level-1 {
+			return node, fmt.Errorf("child %d has level %d, want %d", child, nodes[child-1].Level, level-1)
+		}
+		node.Children[i] = child
+	}
+	return node, nil
+}
+
+// macrocellPopulations returns the number of living cells of every node, by
+// its number counting from 1, or a number above maxMacrocellCells for nodes
+// with more.
+func macrocellPopulations(nodes []internal.MacrocellNode) []int64 {
+	populations := make([]int64, len(nodes)+1)
+	for i, node := range nodes {
+		if node.Level == internal.LeafLevel {
+			for _, row := range node.Rows {
+				populations[i+1] += int64(bits.OnesCount8(row))
+			}
+			continue
+		}
+		for _, child := range node.Children {
+			populations[i+1] = min(populations[i+1]+populations[child], maxMacrocellCells+1)
+		}
+	}
+	return populations
+}
+
+// macrocellCells appends to cells the living cells of the node with the given
+// number, counting from 1, whose top left corner is at (left, top). Nodes
+// without cells, by their populations, are skipped.
+func macrocellCells(nodes []internal.MacrocellNode, populations []int64, number int, left, top int64, cells [][2]int64) [][2]int64 {
+	if populations[number] == 0 {
+		return cells
+	}
+	node := nodes[number-1]
+	if node.Level == internal.LeafLevel {
+		for y, row := range node.Rows {
+			for x := 0; x < 8; x++ {
+				if row&(1<<x) != 0 {
+					cells = append(cells, [2]int64{left + int64(x), top + int64(y)})
+				}
+			}
+		}
+		return cells
+	}
+	half := int64(1) << (node.Level - 1)
+	cells = macrocellCells(nodes, populations, node.Children[0], left, top, cells)
+	cells = macrocellCells(nodes, populations, node.Children[1], left+half, top, cells)
+	cells = macrocellCells(nodes, populations, node.Children[2], left, top+half, cells)
+	return macrocellCells(nodes, populations, node.Children[3], left+half, top+half, cells)
+}
+
+// WriteMacrocell writes the living cells of the world in the macrocell format,
+// with the world's rule and generation. Unlike RLE, the cells keep their
+// coordinates. The tree of a quadtree world is written as it is; the cells of
+// other worlds are put into one first. Worlds with more than two states cannot
+// be written.
+func WriteMacrocell(w types.World, out io.Writer) error {
+	if multi, ok := w.(types.MultiState); ok && multi.States() > 2 {
+		return fmt.Errorf("macrocell files hold two-state patterns, not %d states", multi.States())
+	}
+	tree, ok := w.(*internal.QuadWorld)
+	if !ok {
+		tree = internal.NewQuadWorld(internal.ConwayRule)
+		var cells [][2]int64
+		w.ForEachCell(func(x, y, turn int64) {
+			cells = append(cells, [2]int64{x, y})
+		})
+		tree.AddCells(cells, 0)
+	}
+
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "%s (life)\n#R %s\n", macrocellHeader, ruleOf(w))
+	if w.Turn() != 0 {
+		fmt.Fprintf(bw, "#G %d\n", w.Turn())
+	}
+	for _, node := range tree.Macrocell() {
+		if node.Level > internal.LeafLevel {
+			fmt.Fprintf(bw, "%d %d %d %d %d\n", node.Level,
+				node.Children[0], node.Children[1], node.Children[2], node.Children[3])
+			continue
+		}
+		// Rows end at their last living cell, and the empty rows at the
+		// bottom are left out
+		last := len(node.Rows) - 1
+		for last >= 0 && node.Rows[last] == 0 {
+			last--
+		}
+		for _, row := range node.Rows[:last+1] {
+			for x := 0; row>>x != 0; x++ {
+				if row&(1<<x) != 0 {
+					bw.WriteByte('*')
+				} else {
+					bw.WriteByte('.')
+				}
+			}
+			bw.WriteByte('$')
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/model/internal"
+)
+
+// gliderMacrocell is a glider at generation 4, with a block far from it.
+const gliderMacrocell = "[M2] (golly 4.2)\n" +
+	"#R B3/S23\n" +
+	"#G 4\n" +
+	".*$..*$***$\n" +
+	"**$**$\n" +
+	"4 1 0 0 0\n" +
+	"4 0 0 0 2\n" +
+	"5 3 0 0 0\n" +
+	"5 0 0 0 4\n" +
+	"6 5 0 0 6\n"
+
+func TestReadMacrocell(t *testing.T) {
+	want := [][2]int64{{-31, -32}, {-30, -31}, {-32, -30}, {-31, -30}, {-30, -30}, {24, 24}, {25, 24}, {24, 25}, {25, 25}}
+	tests := []struct {
+		engine Engine
+		want   string
+	}{
+		{engine: QuadtreeEngine, want: "*internal.QuadWorld"},
+		{engine: MapEngine, want: "*internal.World"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.engine), func(t *testing.T) {
+			w, err := ReadMacrocell(strings.NewReader(gliderMacrocell), tt.engine)
+			if err != nil {
+				t.Fatalf("ReadMacrocell() unexpected error: %v", err)
+			}
+			if got := reflect.TypeOf(w).String(); got != tt.want {
+				t.Errorf("ReadMacrocell() world is a %s, want a %s", got, tt.want)
+			}
+			if got := cellsOf(w); !reflect.DeepEqual(got, sortedCells(want)) {
+				t.Errorf("ReadMacrocell() cells = %v, want %v", got, sortedCells(want))
+			}
+			if w.Turn() != 4 {
+				t.Errorf("Turn() = %d, want 4", w.Turn())
+			}
+		})
+	}
+
+	// Rules a quadtree world cannot follow fall back to the map engine
+	w, err := ReadMacrocell(strings.NewReader("[M2]\n#R B2/S/C3\n**$\n"), QuadtreeEngine)
+	if err != nil {
+		t.Fatalf("ReadMacrocell() unexpected error: %v", err)
+	}
+	if rule := ruleOf(w); rule != "B2/S/C3" || len(cellsOf(w)) != 2 {
+		t.Errorf("ReadMacrocell() = %s with %v, want B2/S/C3 with 2 cells", rule, cellsOf(w))
+	}
+}
+
+// sortedCells returns the cells in the order cellsOf lists them.
+func sortedCells(cells [][2]int64) [][2]int64 {
+	w := internal.NewWorld()
+	w.AddCells(cells, 0)
+	return cellsOf(w)
+}
+
+func TestMacrocell_RoundTrip(t *testing.T) {
+	w := mustReadPattern(t, "x = 5, y = 3, rule = B36/S23\nbo$2bo$3o!\n")
+	w.AddCellIn(-1000, 70, 0)
+	w.Evolve()
+	for _, engine := range []Engine{QuadtreeEngine, MapEngine} {
+		var out bytes.Buffer
+		if err := WriteMacrocell(w, &out); err != nil {
+			t.Fatalf("WriteMacrocell() unexpected error: %v", err)
+		}
+		again, err := ReadMacrocell(&out, engine)
+		if err != nil {
+			t.Fatalf("ReadMacrocell() of written macrocell unexpected error: %v", err)
+		}
+		if got, want := cellsOf(again), cellsOf(w); !reflect.DeepEqual(got, want) {
+			t.Errorf("cells %v read back as %v", want, got)
+		}
+		if ruleOf(again) != "B36/S23" || again.Turn() != 1 {
+			t.Errorf("rule and turn read back as %s and %d, want B36/S23 and 1", ruleOf(again), again.Turn())
+		}
+
+		// Written again, the tree is the same
+		var second bytes.Buffer
+		if err := WriteMacrocell(again, &second); err != nil {
+			t.Fatalf("WriteMacrocell() unexpected error: %v", err)
+		}
+		var first bytes.Buffer
+		WriteMacrocell(w, &first)
+		if second.String() != first.String() {
+			t.Errorf("written again as\n%s\nwant\n%s", second.String(), first.String())
+		}
+	}
+
+	multi := mustReadPattern(t, "x = 2, y = 1, rule = B2/S/C3\nAB!\n")
+	if err := WriteMacrocell(multi, &bytes.Buffer{}); err == nil {
+		t.Errorf("WriteMacrocell() of a multi-state world expected error")
+	}
+}
+
+func TestReadMacrocell_GunStream(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+	if err := os.Chdir(filepath.Join(originalWd, "..")); err != nil {
+		t.Fatalf("Failed to change to project directory: %v", err)
+	}
+
+	// The stream is what the gun makes in 1200 generations
+	gun, err := ReadWorld("gun")
+	if err != nil {
+		t.Fatalf("ReadWorld() unexpected error: %v", err)
+	}
+	for gun.Turn() < 1200 {
+		gun.Evolve()
+	}
+	for _, engine := range []Engine{QuadtreeEngine, MapEngine} {
+		stream, err := ReadWorldWithEngine("gun-stream", engine)
+		if err != nil {
+			t.Fatalf("ReadWorldWithEngine() unexpected error: %v", err)
+		}
+		if got, want := cellsOf(stream), cellsOf(gun); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: cells = %v, want %v", engine, got, want)
+		}
+	}
+}
+
+func TestReadMacrocell_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "no header",
+			content: "**$\n",
+			wantErr: `line 1: missing "[M2]" header`,
+		},
+		{
+			name:    "bad character",
+			content: "[M2]\n*o$\n",
+			wantErr: `line 2: unexpected character 'o'`,
+		},
+		{
+			name:    "wide leaf",
+			content: "[M2]\n.........*$\n",
+			wantErr: `line 2: leaf ".........*$" is larger than 8x8`,
+		},
+		{
+			name:    "missing child",
+			content: "[M2]\n*$\n4 1 0 0 2\n",
+			wantErr: `line 3: child "2" is not a node before this one`,
+		},
+		{
+			name:    "child of another level",
+			content: "[M2]\n*$\n4 1 0 0 0\n5 1 0 0 0\n",
+			wantErr: `line 4: child 1 has level 3, want 4`,
+		},
+		{
+			name:    "multi-state node",
+			content: "[M2]\n#R B2/S/C3\n1 0 1 2 0\n",
+			wantErr: `line 3: multi-state nodes are not supported`,
+		},
+		{
+			name:    "huge node",
+			content: "[M2]\n62 0 0 0 0\n",
+			wantErr: `line 2: node level 62 out of range 4 to 61`,
+		},
+		{
+			name:    "short node",
+			content: "[M2]\n4 0 0\n",
+			wantErr: `line 2: invalid node "4 0 0" (want level nw ne sw se)`,
+		},
+		{
+			name:    "bad generation",
+			content: "[M2]\n#G soon\n",
+			wantErr: `line 2: invalid generation "#G soon"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadMacrocell(strings.NewReader(tt.content), QuadtreeEngine)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
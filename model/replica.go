@@ -0,0 +1,38 @@
+package model
+
+import (
+	"github.com/daniel-munoz/life/model/internal"
+)
+
+// Replica is a world that follows a world owned elsewhere, such as on a
+// server, by applying the changes it is sent instead of evolving by itself.
+type Replica struct {
+	*internal.World
+}
+
+// NewReplica creates an empty replica.
+func NewReplica() *Replica {
+	return &Replica{World: internal.NewWorld()}
+}
+
+// Reset replaces the content of the replica with the given cells, each one
+// given as {x, y, birth turn}, at the given generation.
+func (r *Replica) Reset(turn int64, cells [][3]int64) {
+	r.World = internal.NewWorld()
+	for _, c := range cells {
+		r.World.AddCellIn(c[0], c[1], c[2])
+	}
+	r.World.SetTurn(turn)
+}
+
+// Apply moves the replica to the given generation, adding the born cells and
+// removing the dead ones.
+func (r *Replica) Apply(turn int64, born, died [][2]int64) {
+	for _, c := range died {
+		r.World.RemoveCellIn(c[0], c[1])
+	}
+	for _, c := range born {
+		r.World.AddCellIn(c[0], c[1], turn)
+	}
+	r.World.SetTurn(turn)
+}
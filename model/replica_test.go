@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestReplica(t *testing.T) {
+	r := NewReplica()
+	r.Reset(10, [][3]int64{{0, 0, 3}, {1, 0, 3}, {2, 0, 9}})
+	if r.Turn() != 10 {
+		t.Errorf("Turn() after Reset = %d, want 10", r.Turn())
+	}
+	if got := cellsOf(r); len(got) != 3 {
+		t.Errorf("cells after Reset = %v, want 3 cells", got)
+	}
+
+	r.Apply(11, [][2]int64{{1, -1}, {1, 1}}, [][2]int64{{0, 0}, {2, 0}})
+	if r.Turn() != 11 {
+		t.Errorf("Turn() after Apply = %d, want 11", r.Turn())
+	}
+	want := [][2]int64{{1, -1}, {1, 0}, {1, 1}}
+	got := cellsOf(r)
+	if len(got) != len(want) {
+		t.Fatalf("cells after Apply = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cells after Apply = %v, want %v", got, want)
+			break
+		}
+	}
+
+	r.Reset(0, nil)
+	if got := cellsOf(r); len(got) != 0 {
+		t.Errorf("cells after empty Reset = %v, want none", got)
+	}
+}
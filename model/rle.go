@@ -0,0 +1,294 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// rleLineLength is the maximum length of a pattern line in RLE output.
+const rleLineLength = 70
+
+// maxRunLength is the longest run read from RLE input. Real patterns have no
+// longer runs, so a longer one is taken as corrupt input rather than filling
+// memory with cells.
+const maxRunLength = 1 << 16
+
+// maxRLECells is the number of cells of the largest pattern read from RLE
+// input, which keeps runs of the longest length from adding up to more cells
+// than fit in memory.
+const maxRLECells = 1 << 24
+
+// ReadRLE reads a pattern in the RLE format. The top-left corner of the pattern
+// is placed at (0,0). The rule in the header may be any rule accepted by
+// NewWorldWithRule; rules with more than two states use the multi-state
+// letters "A" to "X", with a "p" to "y" prefix for states above 24, and "."
+// for empty cells. Patterns of more than 2^24 living cells are refused.
+func ReadRLE(r io.Reader) (types.World, error) {
+	return readRLE(r, maxRLECells)
+}
+
+// readRLE reads a pattern in the RLE format as ReadRLE does, refusing patterns
+// of more than maxCells living cells.
+func readRLE(r io.Reader, maxCells int64) (types.World, error) {
+	var (
+		x, y       int64
+		count      int64
+		cells      int64
+		lineNumber int
+		header     bool
+		prefix     rune
+	)
+	var newWorld internal.Engine = internal.NewWorld()
+
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !header {
+			header = true
+			if strings.HasPrefix(line, "x") {
+				rule, err := parseRLEHeader(line)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				newWorld = internal.NewEngine(rule)
+				continue
+			}
+		}
+		for _, c := range line {
+			if prefix != 0 && (c < 'A' || c > 'X') {
+				return nil, fmt.Errorf("line %d: unexpected character %q after %q", lineNumber, c, prefix)
+			}
+			state := 0
+			switch {
+			case c >= '0' && c <= '9':
+				if count = count*10 + int64(c-'0'); count > maxRunLength {
+					return nil, fmt.Errorf("line %d: run longer than %d", lineNumber, maxRunLength)
+				}
+				continue
+			case c >= 'p' && c <= 'y' && newWorld.States() > 2:
+				prefix = c
+				continue
+			case c == 'b' || c == '.':
+				x += runLength(count)
+			case c == 'o' || c >= 'A' && c <= 'Z':
+				state = 1
+				if newWorld.States() > 2 {
+					if state = rleState(prefix, c); state >= newWorld.States() || c > 'X' {
+						return nil, fmt.Errorf("line %d: invalid state %d for rule %s", lineNumber, state, newWorld.Rule())
+					}
+				}
+			case c == '$':
+				y += runLength(count)
+				x = 0
+			case c == '!':
+				return newWorld, nil
+			case c == ' ' || c == '\t':
+			default:
+				return nil, fmt.Errorf("line %d: unexpected character %q", lineNumber, c)
+			}
+			if state > 0 {
+				if cells += runLength(count); cells > maxCells {
+					return nil, fmt.Errorf("line %d: pattern of more than %d cells", lineNumber, maxCells)
+				}
+			}
+			for i := int64(0); state > 0 && i < runLength(count); i++ {
+				newWorld.SetStateIn(x, y, state, 0)
+				x++
+			}
+			count, prefix = 0, 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lineError(lineNumber+1, err)
+	}
+	return newWorld, nil
+}
+
+// rleState returns the state written as a letter, with an optional prefix.
+func rleState(prefix, letter rune) int {
+	if letter == 'o' {
+		return 1
+	}
+	state := int(letter-'A') + 1
+	if prefix != 0 {
+		state += 24 * int(prefix-'p'+1)
+	}
+	return state
+}
+
+// rleTag returns the letters for a state in a multi-state pattern.
+func rleTag(state int) string {
+	if state == 0 {
+		return "."
+	}
+	letter := string(rune('A' + (state-1)%24))
+	if state <= 24 {
+		return letter
+	}
+	return string(rune('p'+(state-25)/24)) + letter
+}
+
+// runLength returns the length of a run, where 0 means no count was given.
+func runLength(count int64) int64 {
+	if count == 0 {
+		return 1
+	}
+	return count
+}
+
+// parseRLEHeader validates an RLE header line such as "x = 3, y = 3, rule =
+// B3/S23" and returns its rule, Conway's if there is none. The rule is the last
+// field and runs to the end of the line, since Larger than Life rules contain
+// commas.
+func parseRLEHeader(line string) (internal.Automaton, error) {
+	var rule internal.Automaton = internal.ConwayRule
+	fields := strings.Split(line, ",")
+	for i, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid header field %q", strings.TrimSpace(field))
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch key {
+		case "x", "y":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid pattern size %s = %q", key, value)
+			}
+		case "rule":
+			value = strings.TrimSpace(strings.Join(append([]string{parts[1]}, fields[i+1:]...), ","))
+			var err error
+			if rule, err = internal.ParseAutomaton(value); err != nil {
+				return nil, err
+			}
+			return rule, nil
+		}
+	}
+	return rule, nil
+}
+
+// rleWriter accumulates RLE runs and wraps the output at rleLineLength.
+type rleWriter struct {
+	out  *bufio.Writer
+	line int
+}
+
+// run writes count repetitions of tag, as "<count><tag>" or just "<tag>",
+// split into runs no longer than maxRunLength.
+func (rw *rleWriter) run(count int64, tag string) {
+	for count > maxRunLength {
+		rw.run(maxRunLength, tag)
+		count -= maxRunLength
+	}
+	if count <= 0 {
+		return
+	}
+	item := tag
+	if count > 1 {
+		item = fmt.Sprintf("%d%s", count, tag)
+	}
+	if rw.line+len(item) > rleLineLength {
+		rw.out.WriteByte('\n')
+		rw.line = 0
+	}
+	rw.out.WriteString(item)
+	rw.line += len(item)
+}
+
+// rleCell is a cell of a row being written, with its state.
+type rleCell struct {
+	x     int64
+	state int
+}
+
+// WriteRLE writes the cells of the world in the RLE format, with the world's
+// rule in the header. The pattern is translated so that its bounding box starts
+// at (0,0). Worlds with more than two states are written with the multi-state
+// letters, which include their dying cells.
+func WriteRLE(w types.World, out io.Writer) error {
+	rows := make(map[int64][]rleCell)
+	first := true
+	var minX, minY, maxX, maxY int64
+	add := func(x, y int64, state int) {
+		rows[y] = append(rows[y], rleCell{x: x, state: state})
+		if first {
+			minX, minY, maxX, maxY = x, y, x, y
+			first = false
+			return
+		}
+		if x < minX {
+			minX = x
+		}
+		if y < minY {
+			minY = y
+		}
+		if x > maxX {
+			maxX = x
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+
+	deadTag, tagOf := "b", func(int) string { return "o" }
+	if multi, ok := w.(types.MultiState); ok && multi.States() > 2 {
+		deadTag, tagOf = ".", rleTag
+		multi.ForEachState(add)
+	} else {
+		w.ForEachCell(func(x, y, turn int64) {
+			add(x, y, 1)
+		})
+	}
+	rule := "B3/S23"
+	if reporter, ok := w.(types.RuleReporter); ok {
+		rule = reporter.Rule()
+	}
+
+	width, height := maxX-minX+1, maxY-minY+1
+	if first {
+		width, height = 0, 0
+	}
+
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "x = %d, y = %d, rule = %s\n", width, height, rule)
+	rw := &rleWriter{out: bw}
+
+	ys := make([]int64, 0, len(rows))
+	for y := range rows {
+		ys = append(ys, y)
+	}
+	sort.Slice(ys, func(i, j int) bool { return ys[i] < ys[j] })
+
+	lastY := minY
+	for _, y := range ys {
+		rw.run(y-lastY, "$")
+		lastY = y
+
+		cells := rows[y]
+		sort.Slice(cells, func(i, j int) bool { return cells[i].x < cells[j].x })
+		nextX := minX
+		for i := 0; i < len(cells); {
+			j := i
+			for j+1 < len(cells) && cells[j+1].x == cells[j].x+1 && cells[j+1].state == cells[i].state {
+				j++
+			}
+			rw.run(cells[i].x-nextX, deadTag)
+			rw.run(int64(j-i+1), tagOf(cells[i].state))
+			nextX = cells[j].x + 1
+			i = j + 1
+		}
+	}
+	rw.run(1, "!")
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
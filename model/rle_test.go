@@ -0,0 +1,267 @@
+package model
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestWriteRLE(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells [][2]int64
+		want  string
+	}{
+		{
+			name:  "empty world",
+			cells: nil,
+			want:  "x = 0, y = 0, rule = B3/S23\n!\n",
+		},
+		{
+			name:  "glider",
+			cells: [][2]int64{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}},
+			want:  "x = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n",
+		},
+		{
+			name:  "translated with empty rows",
+			cells: [][2]int64{{-5, -5}, {-3, -5}, {-4, -2}},
+			want:  "x = 3, y = 4, rule = B3/S23\nobo3$bo!\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := internal.NewWorld()
+			for _, c := range tt.cells {
+				w.AddCellIn(c[0], c[1], 0)
+			}
+			out := &bytes.Buffer{}
+			if err := WriteRLE(w, out); err != nil {
+				t.Fatalf("WriteRLE() unexpected error: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("WriteRLE() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRLE_LineLength(t *testing.T) {
+	w := internal.NewWorld()
+	for x := int64(0); x < 200; x += 2 {
+		w.AddCellIn(x, 0, 0)
+	}
+	out := &bytes.Buffer{}
+	if err := WriteRLE(w, out); err != nil {
+		t.Fatalf("WriteRLE() unexpected error: %v", err)
+	}
+	for i, line := range strings.Split(out.String(), "\n") {
+		if len(line) > rleLineLength {
+			t.Errorf("line %d has %d characters, want at most %d", i, len(line), rleLineLength)
+		}
+	}
+}
+
+// cellsOf returns the sorted coordinates of the living cells of a world.
+func cellsOf(w types.World) [][2]int64 {
+	var cells [][2]int64
+	w.ForEachCell(func(x, y, turn int64) {
+		cells = append(cells, [2]int64{x, y})
+	})
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i][1] != cells[j][1] {
+			return cells[i][1] < cells[j][1]
+		}
+		return cells[i][0] < cells[j][0]
+	})
+	return cells
+}
+
+func TestReadRLE(t *testing.T) {
+	tests := []struct {
+		name      string
+		rle       string
+		wantCells [][2]int64
+		wantErr   string
+	}{
+		{
+			name:      "glider with comments",
+			rle:       "#N Glider\n#C A small spaceship\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n",
+			wantCells: [][2]int64{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}},
+		},
+		{
+			name:      "runs across lines and blank rows",
+			rle:       "x = 3, y = 4\nobo\n3$\nbo!",
+			wantCells: [][2]int64{{0, 0}, {2, 0}, {1, 3}},
+		},
+		{
+			name:      "no header",
+			rle:       "2o$2o!",
+			wantCells: [][2]int64{{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+		},
+		{
+			name:      "text after the end",
+			rle:       "x = 1, y = 1\no!ignored",
+			wantCells: [][2]int64{{0, 0}},
+		},
+		{
+			name:      "life-like rule",
+			rle:       "x = 2, y = 1, rule = B36/S23\n2o!",
+			wantCells: [][2]int64{{0, 0}, {1, 0}},
+		},
+		{
+			name:      "generations rule keeps living cells only",
+			rle:       "x = 3, y = 1, rule = B2/S/C3\nA.B!",
+			wantCells: [][2]int64{{0, 0}},
+		},
+		{
+			name:      "larger than life rule with commas",
+			rle:       "x = 2, y = 1, rule = R5,C0,M1,S34..58,B34..45\n2o!",
+			wantCells: [][2]int64{{0, 0}, {1, 0}},
+		},
+		{
+			name:    "unsupported rule",
+			rle:     "x = 1, y = 1, rule = Conway\no!",
+			wantErr: `line 1: unsupported rule "Conway"`,
+		},
+		{
+			name:    "state beyond the rule",
+			rle:     "x = 1, y = 1, rule = B2/S/C3\nC!",
+			wantErr: `line 2: invalid state 3 for rule B2/S/C3`,
+		},
+		{
+			name:    "bad size",
+			rle:     "x = three, y = 1\no!",
+			wantErr: `line 1: invalid pattern size x = "three"`,
+		},
+		{
+			name:    "bad character",
+			rle:     "x = 1, y = 1\n\no?!",
+			wantErr: `line 3: unexpected character '?'`,
+		},
+		{
+			name:    "run too long",
+			rle:     "x = 1, y = 1\n99999999999o!",
+			wantErr: `line 2: run longer than 65536`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := ReadRLE(strings.NewReader(tt.rle))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ReadRLE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadRLE() unexpected error: %v", err)
+			}
+			got := cellsOf(w)
+			if len(got) != len(tt.wantCells) {
+				t.Fatalf("ReadRLE() cells = %v, want %v", got, tt.wantCells)
+			}
+			for i := range got {
+				if got[i] != tt.wantCells[i] {
+					t.Errorf("ReadRLE() cells = %v, want %v", got, tt.wantCells)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReadRLE_TooManyCells(t *testing.T) {
+	if _, err := readRLE(strings.NewReader("x = 6, y = 2\n5o$o!"), 5); err == nil || err.Error() != "line 2: pattern of more than 5 cells" {
+		t.Errorf("readRLE() error = %v, want %q", err, "line 2: pattern of more than 5 cells")
+	}
+	if _, err := readRLE(strings.NewReader("x = 5, y = 1\n5o!"), 5); err != nil {
+		t.Errorf("readRLE() unexpected error: %v", err)
+	}
+}
+
+func TestRLE_RoundTrip(t *testing.T) {
+	w := internal.NewWorld()
+	for _, c := range [][2]int64{{0, 0}, {5, 0}, {6, 0}, {2, 3}, {90, 3}, {1, 7}} {
+		w.AddCellIn(c[0], c[1], 0)
+	}
+	out := &bytes.Buffer{}
+	if err := WriteRLE(w, out); err != nil {
+		t.Fatalf("WriteRLE() unexpected error: %v", err)
+	}
+	read, err := ReadRLE(out)
+	if err != nil {
+		t.Fatalf("ReadRLE() unexpected error: %v", err)
+	}
+	want, got := cellsOf(w), cellsOf(read)
+	if len(got) != len(want) {
+		t.Fatalf("round trip cells = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("round trip cells = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestRLE_MultiState(t *testing.T) {
+	rule, err := internal.ParseRule("B2/S/C30")
+	if err != nil {
+		t.Fatalf("ParseRule() unexpected error: %v", err)
+	}
+	w := internal.NewWorldWithRule(rule)
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.SetStateIn(2, 0, 2, 0)
+	w.SetStateIn(4, 0, 26, 0)
+	w.SetStateIn(0, 1, 29, 0)
+
+	out := &bytes.Buffer{}
+	if err := WriteRLE(w, out); err != nil {
+		t.Fatalf("WriteRLE() unexpected error: %v", err)
+	}
+	want := "x = 5, y = 2, rule = B2/S/C30\n2AB.pB$pE!\n"
+	if out.String() != want {
+		t.Errorf("WriteRLE() = %q, want %q", out.String(), want)
+	}
+
+	read, err := ReadRLE(out)
+	if err != nil {
+		t.Fatalf("ReadRLE() unexpected error: %v", err)
+	}
+	multi := read.(types.MultiState)
+	for _, c := range [][3]int64{{0, 0, 1}, {1, 0, 1}, {2, 0, 2}, {3, 0, 0}, {4, 0, 26}, {0, 1, 29}} {
+		if got := multi.StateOf(c[0], c[1]); got != int(c[2]) {
+			t.Errorf("StateOf(%d, %d) = %d, want %d", c[0], c[1], got, c[2])
+		}
+	}
+}
+
+func TestRLE_LargerThanLife(t *testing.T) {
+	w, err := NewWorldWithRule("R5,C0,M1,S34..58,B34..45")
+	if err != nil {
+		t.Fatalf("NewWorldWithRule() unexpected error: %v", err)
+	}
+	w.AddCellIn(3, 4, 0)
+	out := &bytes.Buffer{}
+	if err := WriteRLE(w, out); err != nil {
+		t.Fatalf("WriteRLE() unexpected error: %v", err)
+	}
+	want := "x = 1, y = 1, rule = R5,C0,M1,S34..58,B34..45,NM\no!\n"
+	if out.String() != want {
+		t.Errorf("WriteRLE() = %q, want %q", out.String(), want)
+	}
+	read, err := ReadRLE(out)
+	if err != nil {
+		t.Fatalf("ReadRLE() unexpected error: %v", err)
+	}
+	if rule := read.(types.RuleReporter).Rule(); rule != "R5,C0,M1,S34..58,B34..45,NM" {
+		t.Errorf("ReadRLE() rule = %s", rule)
+	}
+}
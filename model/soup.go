@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// Symmetry is a symmetry imposed on a soup, named as in apgsearch.
+type Symmetry string
+
+// Soup symmetries.
+const (
+	C1 Symmetry = "C1" // No symmetry
+	D2 Symmetry = "D2" // Mirrored left to right
+	D4 Symmetry = "D4" // Mirrored left to right and top to bottom
+	D8 Symmetry = "D8" // Mirrored both ways and across the diagonals; the soup must be square
+)
+
+// ParseSymmetry returns the symmetry with the given name, in any case.
+func ParseSymmetry(name string) (Symmetry, error) {
+	switch s := Symmetry(strings.ToUpper(name)); s {
+	case C1, D2, D4, D8:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown symmetry %q (want C1, D2, D4 or D8)", name)
+}
+
+// Default soup settings.
+const (
+	DefaultSoupSize    = 16
+	DefaultSoupDensity = 0.5
+)
+
+// Soup describes a random soup: a rectangle of cells, each of them alive with
+// the given probability. The same settings, seed included, always give the
+// same cells.
+type Soup struct {
+	Seed     uint64   `json:"seed"`
+	Left     int64    `json:"left"`
+	Top      int64    `json:"top"`
+	Width    int64    `json:"width"`
+	Height   int64    `json:"height"`
+	Density  float64  `json:"density"`
+	Symmetry Symmetry `json:"symmetry"`
+}
+
+// NewSoup returns a soup with the default size, density and symmetry, at the
+// origin, with a new random seed.
+func NewSoup() Soup {
+	return Soup{
+		Seed:     rand.Uint64(),
+		Width:    DefaultSoupSize,
+		Height:   DefaultSoupSize,
+		Density:  DefaultSoupDensity,
+		Symmetry: C1,
+	}
+}
+
+// Validate checks that the soup can be generated.
+func (s Soup) Validate() error {
+	if s.Width < 1 || s.Height < 1 {
+		return fmt.Errorf("soup size must be positive, got %dx%d", s.Width, s.Height)
+	}
+	if s.Density < 0 || s.Density > 1 {
+		return fmt.Errorf("soup density must be between 0 and 1, got %g", s.Density)
+	}
+	if _, err := ParseSymmetry(string(s.Symmetry)); err != nil {
+		return err
+	}
+	if s.Symmetry == D8 && s.Width != s.Height {
+		return fmt.Errorf("a D8 soup must be square, got %dx%d", s.Width, s.Height)
+	}
+	return nil
+}
+
+// Fill brings the cells of the soup to life in the world, born at its current
+// turn.
+func (s Soup) Fill(w types.World) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	random := rand.New(rand.NewPCG(s.Seed, 0))
+	// Cells are drawn in reading order, once for each set of symmetric cells,
+	// the first time one of them is met
+	alive := make(map[[2]int64]bool)
+	for y := int64(0); y < s.Height; y++ {
+		for x := int64(0); x < s.Width; x++ {
+			representative := s.representative(x, y)
+			if representative == [2]int64{x, y} {
+				alive[representative] = random.Float64() < s.Density
+			}
+		}
+	}
+	for y := int64(0); y < s.Height; y++ {
+		for x := int64(0); x < s.Width; x++ {
+			if alive[s.representative(x, y)] {
+				w.AddCellIn(s.Left+x, s.Top+y, w.Turn())
+			}
+		}
+	}
+	return nil
+}
+
+// representative returns the cell that stands for all the cells symmetric to
+// (x, y), in coordinates relative to the soup. It comes first in reading
+// order among them.
+func (s Soup) representative(x, y int64) [2]int64 {
+	mirrorX, mirrorY := min(x, s.Width-1-x), min(y, s.Height-1-y)
+	switch s.Symmetry {
+	case D2:
+		return [2]int64{mirrorX, y}
+	case D4:
+		return [2]int64{mirrorX, mirrorY}
+	case D8:
+		return [2]int64{max(mirrorX, mirrorY), min(mirrorX, mirrorY)}
+	}
+	return [2]int64{x, y}
+}
+
+// String describes the soup with the flags of the soup command that repeat it.
+func (s Soup) String() string {
+	return fmt.Sprintf("-seed %d -width %d -height %d -density %g -symmetry %s",
+		s.Seed, s.Width, s.Height, s.Density, s.Symmetry)
+}
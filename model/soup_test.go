@@ -0,0 +1,140 @@
+package model
+
+import (
+	"testing"
+)
+
+// soupCells returns the living cells of a world filled with the soup.
+func soupCells(t *testing.T, s Soup) map[[2]int64]bool {
+	t.Helper()
+	w := NewWorld()
+	if err := s.Fill(w); err != nil {
+		t.Fatalf("Fill() unexpected error: %v", err)
+	}
+	cells := make(map[[2]int64]bool)
+	w.ForEachCell(func(x, y, turn int64) {
+		cells[[2]int64{x, y}] = true
+	})
+	return cells
+}
+
+func TestSoup_Reproducible(t *testing.T) {
+	s := Soup{Seed: 42, Width: 20, Height: 10, Density: 0.5, Symmetry: C1}
+	first, second := soupCells(t, s), soupCells(t, s)
+	if len(first) == 0 || len(first) != len(second) {
+		t.Fatalf("the same soup gave %d and %d cells", len(first), len(second))
+	}
+	for c := range first {
+		if !second[c] {
+			t.Fatalf("cell %v missing from the second soup", c)
+		}
+	}
+
+	s.Seed = 43
+	other := soupCells(t, s)
+	same := len(other) == len(first)
+	for c := range other {
+		same = same && first[c]
+	}
+	if same {
+		t.Error("another seed gave the same soup")
+	}
+}
+
+func TestSoup_Density(t *testing.T) {
+	tests := []struct {
+		density  float64
+		min, max int
+	}{
+		{density: 0, min: 0, max: 0},
+		{density: 1, min: 400, max: 400},
+		{density: 0.25, min: 70, max: 130},
+	}
+	for _, tt := range tests {
+		s := Soup{Seed: 7, Left: -10, Top: 5, Width: 20, Height: 20, Density: tt.density, Symmetry: C1}
+		cells := soupCells(t, s)
+		if len(cells) < tt.min || len(cells) > tt.max {
+			t.Errorf("density %g gave %d cells, want between %d and %d", tt.density, len(cells), tt.min, tt.max)
+		}
+		for c := range cells {
+			if c[0] < -10 || c[0] >= 10 || c[1] < 5 || c[1] >= 25 {
+				t.Errorf("density %g gave cell %v outside the soup", tt.density, c)
+			}
+		}
+	}
+}
+
+func TestSoup_Symmetry(t *testing.T) {
+	// Each symmetry maps a cell of a soup at the origin to the cells that
+	// must match it
+	tests := []struct {
+		symmetry      Symmetry
+		width, height int64
+		images        func(x, y, w, h int64) [][2]int64
+	}{
+		{
+			symmetry: D2, width: 15, height: 10,
+			images: func(x, y, w, h int64) [][2]int64 {
+				return [][2]int64{{w - 1 - x, y}}
+			},
+		},
+		{
+			symmetry: D4, width: 12, height: 9,
+			images: func(x, y, w, h int64) [][2]int64 {
+				return [][2]int64{{w - 1 - x, y}, {x, h - 1 - y}, {w - 1 - x, h - 1 - y}}
+			},
+		},
+		{
+			symmetry: D8, width: 11, height: 11,
+			images: func(x, y, w, h int64) [][2]int64 {
+				return [][2]int64{{w - 1 - x, y}, {x, h - 1 - y}, {y, x}, {h - 1 - y, w - 1 - x}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.symmetry), func(t *testing.T) {
+			s := Soup{Seed: 3, Width: tt.width, Height: tt.height, Density: 0.4, Symmetry: tt.symmetry}
+			cells := soupCells(t, s)
+			if len(cells) == 0 {
+				t.Fatal("empty soup")
+			}
+			for y := int64(0); y < tt.height; y++ {
+				for x := int64(0); x < tt.width; x++ {
+					for _, image := range tt.images(x, y, tt.width, tt.height) {
+						if cells[[2]int64{x, y}] != cells[image] {
+							t.Fatalf("cells (%d,%d) and %v differ", x, y, image)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSoup_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		soup Soup
+	}{
+		{name: "empty", soup: Soup{Width: 0, Height: 5, Symmetry: C1}},
+		{name: "density above 1", soup: Soup{Width: 5, Height: 5, Density: 1.5, Symmetry: C1}},
+		{name: "unknown symmetry", soup: Soup{Width: 5, Height: 5, Density: 0.5, Symmetry: "C4"}},
+		{name: "D8 not square", soup: Soup{Width: 5, Height: 6, Density: 0.5, Symmetry: D8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.soup.Fill(NewWorld()); err == nil {
+				t.Errorf("Fill(%+v) should fail", tt.soup)
+			}
+		})
+	}
+}
+
+func TestParseSymmetry(t *testing.T) {
+	if s, err := ParseSymmetry("d4"); err != nil || s != D4 {
+		t.Errorf("ParseSymmetry(\"d4\") = %q, %v, want D4", s, err)
+	}
+	if _, err := ParseSymmetry("C2"); err == nil {
+		t.Error("ParseSymmetry(\"C2\") should fail")
+	}
+}
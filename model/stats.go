@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// statsHeader names the columns written by a StatsWriter.
+var statsHeader = []string{"turn", "population", "births", "deaths", "width", "height"}
+
+// StatsWriter writes the stats of generations as CSV, one line each, after a
+// header line.
+type StatsWriter struct {
+	writer *csv.Writer
+}
+
+// NewStatsWriter creates a StatsWriter and writes the header line.
+func NewStatsWriter(out io.Writer) *StatsWriter {
+	sw := &StatsWriter{writer: csv.NewWriter(out)}
+	sw.writer.Write(statsHeader)
+	return sw
+}
+
+// Write writes the stats of one generation.
+func (sw *StatsWriter) Write(s types.Stats) {
+	sw.writer.Write([]string{
+		strconv.FormatInt(s.Turn, 10),
+		strconv.FormatInt(s.Population, 10),
+		strconv.FormatInt(s.Births, 10),
+		strconv.FormatInt(s.Deaths, 10),
+		strconv.FormatInt(s.Width, 10),
+		strconv.FormatInt(s.Height, 10),
+	})
+}
+
+// Flush writes any buffered lines and returns the first error met, if any.
+func (sw *StatsWriter) Flush() error {
+	sw.writer.Flush()
+	return sw.writer.Error()
+}
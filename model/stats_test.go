@@ -0,0 +1,23 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestStatsWriter(t *testing.T) {
+	out := &strings.Builder{}
+	sw := NewStatsWriter(out)
+	sw.Write(types.Stats{Turn: 1, Population: 3, Births: 2, Deaths: 2, Width: 1, Height: 3})
+	sw.Write(types.Stats{Turn: 2, Population: 3, Births: 2, Deaths: 2, Width: 3, Height: 1})
+	if err := sw.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+
+	want := "turn,population,births,deaths,width,height\n1,3,2,2,1,3\n2,3,2,2,3,1\n"
+	if out.String() != want {
+		t.Errorf("StatsWriter wrote %q, want %q", out.String(), want)
+	}
+}
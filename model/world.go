@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/daniel-munoz/life/model/internal"
+	"github.com/daniel-munoz/life/types"
+)
+
+// NewWorld creates an empty world.
+func NewWorld() types.World {
+	return internal.NewWorld()
+}
+
+// NewWorldWithRule creates an empty world following the rule, given as a
+// rulestring: a Life-like rule such as "B36/S23", a Generations rule such as
+// "B2/S/C3", or one of the built-in automata, "WireWorld" and "Langtons-Ant"
+// (with optional turns, as in "Langtons-Ant:RLR"). Worlds with more than two
+// states implement types.MultiState.
+func NewWorldWithRule(rule string) (types.World, error) {
+	a, err := internal.ParseAutomaton(rule)
+	if err != nil {
+		return nil, err
+	}
+	return internal.NewEngine(a), nil
+}
+
+// WithRule returns a world following the rule, with the living cells and the
+// generation of w.
+func WithRule(w types.World, rule string) (types.World, error) {
+	a, err := internal.ParseAutomaton(rule)
+	if err != nil {
+		return nil, err
+	}
+	ruled := internal.NewEngine(a)
+	w.ForEachCell(func(x, y, turn int64) {
+		ruled.AddCellIn(x, y, turn)
+	})
+	ruled.SetTurn(w.Turn())
+	return ruled, nil
+}
+
+// RestoreWorld creates a world following the rule ("" for Conway's) at the
+// given generation with the given cells, each one given as {x, y, birth turn},
+// so cell ages survive a save and restore.
+func RestoreWorld(rule string, turn int64, cells [][3]int64) (types.World, error) {
+	var a internal.Automaton = internal.ConwayRule
+	if rule != "" {
+		var err error
+		if a, err = internal.ParseAutomaton(rule); err != nil {
+			return nil, err
+		}
+	}
+	w := internal.NewEngine(a)
+	for _, c := range cells {
+		w.AddCellIn(c[0], c[1], c[2])
+	}
+	w.SetTurn(turn)
+	return w, nil
+}
+
+// Engine names the way a world keeps its cells, for the pattern formats that
+// let the reader choose.
+type Engine string
+
+// Engines of the worlds read from macrocell files.
+const (
+	// MapEngine adds the cells one by one to a world of the rule, such as
+	// those read from other formats.
+	MapEngine Engine = "map"
+	// QuadtreeEngine keeps the cells in a quadtree whose repeated parts are
+	// stored and evolved once, which suits huge two-state patterns.
+	QuadtreeEngine Engine = "quadtree"
+)
+
+// ParseEngine returns the engine of the name, "map" or "quadtree".
+func ParseEngine(name string) (Engine, error) {
+	switch engine := Engine(name); engine {
+	case MapEngine, QuadtreeEngine:
+		return engine, nil
+	}
+	return "", fmt.Errorf("unknown engine %q (want map or quadtree)", name)
+}
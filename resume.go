@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/session"
+	"github.com/daniel-munoz/life/ui"
+)
+
+// defaultSessionPath is the session file written by the save key unless told
+// otherwise.
+const defaultSessionPath = "life.session"
+
+// runResume implements the "resume" subcommand: it restores a saved session
+// in the terminal UI. Later saves overwrite the same file.
+func runResume(args []string) error {
+	flags := flag.NewFlagSet("resume", flag.ContinueOnError)
+	autosave := flags.Bool("autosave", false, "save the session again on exit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life resume [-autosave] [file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	path := defaultSessionPath
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+	s, err := session.Load(path)
+	if err != nil {
+		return fmt.Errorf("reading session: %w", err)
+	}
+	bindings, err := loadBindings()
+	if err != nil {
+		return fmt.Errorf("reading key bindings: %w", err)
+	}
+
+	game, err := ui.NewGameFromSession(s)
+	if err != nil {
+		return fmt.Errorf("restoring session: %w", err)
+	}
+	game.SetSessionPath(path)
+	ui.Show(game, event.NewListenerWithBindings(bindings), bindings)
+	if *autosave {
+		return game.SaveSession(path)
+	}
+	return nil
+}
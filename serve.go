@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/web"
+)
+
+// defaultServeAddress is where "life serve" listens unless told otherwise.
+const defaultServeAddress = "localhost:8080"
+
+// runServe implements the "serve" subcommand: it loads a sample and serves it
+// to browsers until interrupted.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", defaultServeAddress, "listen on `address`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life serve [-addr address] [sample]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sampleName := "gliders"
+	if flags.NArg() > 0 {
+		sampleName = flags.Arg(0)
+	}
+	w, err := model.ReadWorld(sampleName)
+	if err != nil {
+		return fmt.Errorf("reading sample: %w", err)
+	}
+
+	server := web.NewServer(w)
+	go server.Run(make(chan struct{}))
+
+	fmt.Printf("Serving %s on http://%s\n", sampleName, *addr)
+	return http.ListenAndServe(*addr, server)
+}
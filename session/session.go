@@ -0,0 +1,160 @@
+// Package session saves and restores the complete state of a game: the world
+// with its generation and cell ages, the view window and the pause state.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Version is the version of the session format written by this program.
+const Version = 1
+
+// View is the view window of a session.
+type View struct {
+	Top    int64 `json:"top"`
+	Left   int64 `json:"left"`
+	Bottom int64 `json:"bottom"`
+	Right  int64 `json:"right"`
+}
+
+// Session is the saved state of a game. It is stored as JSON.
+type Session struct {
+	Version int   `json:"version"`
+	Turn    int64 `json:"turn"`
+	// Rule is the rulestring of the world, empty for Conway's rule.
+	Rule string `json:"rule,omitempty"`
+	// Cells holds every living cell as {x, y, birth turn}.
+	Cells [][3]int64 `json:"cells"`
+	// States holds the cells in states above 1, such as the dying cells of
+	// Generations rules, as {x, y, state}.
+	States [][3]int64 `json:"states,omitempty"`
+	View   View       `json:"view"`
+	Paused bool       `json:"paused"`
+	// Soup holds the settings of the random soup the world started from, if
+	// any, so that it can be generated again.
+	Soup *model.Soup `json:"soup,omitempty"`
+}
+
+// Capture records the world, view window and pause state in a session.
+func Capture(w types.World, view View, paused bool) *Session {
+	s := &Session{
+		Version: Version,
+		Turn:    w.Turn(),
+		Cells:   [][3]int64{},
+		View:    view,
+		Paused:  paused,
+	}
+	w.ForEachCell(func(x, y, turn int64) {
+		s.Cells = append(s.Cells, [3]int64{x, y, turn})
+	})
+	if reporter, ok := w.(types.RuleReporter); ok && reporter.Rule() != "B3/S23" {
+		s.Rule = reporter.Rule()
+	}
+	if multi, ok := w.(types.MultiState); ok && multi.States() > 2 {
+		multi.ForEachState(func(x, y int64, state int) {
+			if state > 1 {
+				s.States = append(s.States, [3]int64{x, y, int64(state)})
+			}
+		})
+	}
+	return s
+}
+
+// World rebuilds the world of the session, at its generation and with the
+// original birth turn of every cell.
+func (s *Session) World() (types.World, error) {
+	w, err := model.RestoreWorld(s.Rule, s.Turn, s.Cells)
+	if err != nil {
+		return nil, err
+	}
+	if multi, ok := w.(types.MultiState); ok {
+		for _, c := range s.States {
+			multi.SetStateIn(c[0], c[1], int(c[2]), s.Turn)
+		}
+	}
+	return w, nil
+}
+
+// Write writes the session as JSON.
+func (s *Session) Write(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(s)
+}
+
+// Read reads a session written by Write.
+func Read(in io.Reader) (*Session, error) {
+	s := &Session{}
+	if err := json.NewDecoder(in).Decode(s); err != nil {
+		return nil, fmt.Errorf("invalid session: %w", err)
+	}
+	if s.Version < 1 || s.Version > Version {
+		return nil, fmt.Errorf("unsupported session version %d", s.Version)
+	}
+	for _, c := range s.Cells {
+		if c[2] > s.Turn {
+			return nil, fmt.Errorf("cell (%d,%d) born at turn %d, after the session turn %d", c[0], c[1], c[2], s.Turn)
+		}
+	}
+	states := int64(2)
+	if s.Rule != "" {
+		w, err := model.NewWorldWithRule(s.Rule)
+		if err != nil {
+			return nil, err
+		}
+		if multi, ok := w.(types.MultiState); ok {
+			states = int64(multi.States())
+		}
+	}
+	for _, c := range s.States {
+		if c[2] < 2 || c[2] >= states {
+			return nil, fmt.Errorf("cell (%d,%d) has invalid state %d", c[0], c[1], c[2])
+		}
+	}
+	if s.Soup != nil {
+		if err := s.Soup.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Save writes the session to the file at path. The file is replaced only once
+// the whole session has been written.
+func (s *Session) Save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the session in the file at path.
+func Load(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
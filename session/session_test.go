@@ -0,0 +1,179 @@
+package session
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestSession_RoundTrip(t *testing.T) {
+	w := model.NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.AddCellIn(2, 0, 0)
+	w.Evolve()
+	w.Evolve()
+	w.AddCellIn(10, 10, 2)
+
+	view := View{Top: -3, Left: -4, Bottom: 20, Right: 30}
+	path := filepath.Join(t.TempDir(), "life.session")
+	if err := Capture(w, view, true).Save(path); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if s.Turn != 2 || s.View != view || !s.Paused {
+		t.Errorf("Load() = turn %d view %+v paused %t, want turn 2 view %+v paused true", s.Turn, s.View, s.Paused, view)
+	}
+
+	restored, err := s.World()
+	if err != nil {
+		t.Fatalf("World() unexpected error: %v", err)
+	}
+	if restored.Turn() != w.Turn() {
+		t.Errorf("restored Turn() = %d, want %d", restored.Turn(), w.Turn())
+	}
+	want := map[[2]int64]int64{}
+	w.ForEachCell(func(x, y, turn int64) {
+		want[[2]int64{x, y}] = turn
+	})
+	count := 0
+	restored.ForEachCell(func(x, y, turn int64) {
+		count++
+		if birth, ok := want[[2]int64{x, y}]; !ok || birth != turn {
+			t.Errorf("restored cell (%d,%d) born at %d, want %v (alive %t)", x, y, turn, birth, ok)
+		}
+	})
+	if count != len(want) {
+		t.Errorf("restored %d cells, want %d", count, len(want))
+	}
+
+	// Both worlds keep evolving the same way
+	w.Evolve()
+	restored.Evolve()
+	for _, c := range [][2]int64{{1, -1}, {1, 0}, {1, 1}, {0, 0}, {10, 10}} {
+		if w.IsAlive(c[0], c[1]) != restored.IsAlive(c[0], c[1]) {
+			t.Errorf("cell (%d,%d) alive %t in the restored world, want %t", c[0], c[1], restored.IsAlive(c[0], c[1]), w.IsAlive(c[0], c[1]))
+		}
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "not json", input: "x = 3", wantErr: "invalid session"},
+		{name: "missing version", input: `{"turn": 1}`, wantErr: "unsupported session version 0"},
+		{name: "future version", input: `{"version": 99}`, wantErr: "unsupported session version 99"},
+		{name: "cell from the future", input: `{"version": 1, "turn": 2, "cells": [[0, 0, 5]]}`, wantErr: "born at turn 5"},
+		{name: "invalid soup", input: `{"version": 1, "soup": {"seed": 1, "width": 0, "height": 4, "density": 0.5, "symmetry": "C1"}}`, wantErr: "soup size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSession_Soup(t *testing.T) {
+	soup := model.Soup{Seed: 12345, Left: -8, Top: -8, Width: 16, Height: 16, Density: 0.4, Symmetry: model.D2}
+	w := model.NewWorld()
+	if err := soup.Fill(w); err != nil {
+		t.Fatalf("Fill() unexpected error: %v", err)
+	}
+	s := Capture(w, View{}, false)
+	s.Soup = &soup
+
+	buffer := &bytes.Buffer{}
+	if err := s.Write(buffer); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	read, err := Read(buffer)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if read.Soup == nil || *read.Soup != soup {
+		t.Fatalf("Read() soup = %+v, want %+v", read.Soup, soup)
+	}
+
+	// The stored settings make the same soup again
+	again := model.NewWorld()
+	if err := read.Soup.Fill(again); err != nil {
+		t.Fatalf("Fill() unexpected error: %v", err)
+	}
+	count := 0
+	w.ForEachCell(func(x, y, turn int64) {
+		count++
+		if !again.IsAlive(x, y) {
+			t.Errorf("cell (%d,%d) missing from the soup made again", x, y)
+		}
+	})
+	again.ForEachCell(func(x, y, turn int64) {
+		count--
+	})
+	if count != 0 {
+		t.Error("the soup made again has a different number of cells")
+	}
+}
+
+func TestSession_Write(t *testing.T) {
+	w := model.NewWorld()
+	buffer := &bytes.Buffer{}
+	if err := Capture(w, View{}, false).Write(buffer); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if !strings.Contains(buffer.String(), `"version": 1`) || !strings.Contains(buffer.String(), `"cells": []`) {
+		t.Errorf("Write() = %s, want a version and an empty cell list", buffer.String())
+	}
+}
+
+func TestSession_Generations(t *testing.T) {
+	w, err := model.NewWorldWithRule("B2/S/C4")
+	if err != nil {
+		t.Fatalf("NewWorldWithRule() unexpected error: %v", err)
+	}
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.Evolve()
+
+	buffer := &bytes.Buffer{}
+	if err := Capture(w, View{}, false).Write(buffer); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	s, err := Read(buffer)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if s.Rule != "B2/S/C4" || len(s.States) != 2 {
+		t.Errorf("Read() = rule %q with %d dying cells, want B2/S/C4 with 2", s.Rule, len(s.States))
+	}
+	restored, err := s.World()
+	if err != nil {
+		t.Fatalf("World() unexpected error: %v", err)
+	}
+	if state := restored.(types.MultiState).StateOf(0, 0); state != 2 {
+		t.Errorf("restored StateOf(0, 0) = %d, want 2", state)
+	}
+
+	if _, err := Read(strings.NewReader(`{"version": 1, "rule": "B2/S/C3", "states": [[0, 0, 3]]}`)); err == nil {
+		t.Error("Read() expected an error for a state beyond the rule")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/shared"
+	"github.com/daniel-munoz/life/ui"
+)
+
+// defaultShareAddress is where "life share" listens and "life join" connects
+// unless told otherwise.
+const defaultShareAddress = "localhost:7070"
+
+// runShare implements the "share" subcommand: it loads a sample and shares it
+// with the terminals that join, until interrupted.
+func runShare(args []string) error {
+	flags := flag.NewFlagSet("share", flag.ContinueOnError)
+	addr := flags.String("addr", defaultShareAddress, "listen on `address`")
+	delay := flags.Duration("delay", 200*time.Millisecond, "time between generations")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life share [-addr address] [-delay duration] [sample]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sampleName := "gliders"
+	if flags.NArg() > 0 {
+		sampleName = flags.Arg(0)
+	}
+	w, err := model.ReadWorld(sampleName)
+	if err != nil {
+		return fmt.Errorf("reading sample: %w", err)
+	}
+
+	server, err := shared.Listen(*addr, w, *delay)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Sharing %s on %s, join with: life join %s\n", sampleName, server.Addr(), server.Addr())
+	server.Run()
+	return nil
+}
+
+// runJoin implements the "join" subcommand: it shows a shared world in the
+// terminal, with its own view window.
+func runJoin(args []string) error {
+	flags := flag.NewFlagSet("join", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life join [address]")
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	addr := defaultShareAddress
+	if flags.NArg() > 0 {
+		addr = flags.Arg(0)
+	}
+	bindings, err := loadBindings()
+	if err != nil {
+		return fmt.Errorf("reading key bindings: %w", err)
+	}
+	client, err := shared.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	game := ui.NewGame(client, defaultViewTop, defaultViewLeft, defaultViewBottom, defaultViewRight)
+	ui.Show(game, client.Listener(event.NewListenerWithBindings(bindings)), bindings)
+	if client.Err() != nil {
+		fmt.Fprintf(os.Stderr, "Disconnected: %s\n", client.Err())
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package shared
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// maxMessageSize is the largest message a client accepts; snapshots of big
+// worlds are long lines.
+const maxMessageSize = 64 * 1024 * 1024
+
+// Client is a world that mirrors the world of a server. It implements
+// types.World so it can be shown by the terminal UI: Evolve applies the changes
+// received since the previous call, and adding or removing cells sends edits to
+// the server, which applies them for everyone.
+type Client struct {
+	conn     net.Conn
+	encoder  *json.Encoder
+	replica  *model.Replica
+	incoming chan message
+	done     chan struct{}
+	stopOnce sync.Once
+	lastSeq  int64
+	vote     bool
+	paused   bool
+	votes    int
+	clients  int
+	err      error
+}
+
+// Dial connects to a server and waits for its snapshot of the world.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	first, err := readMessage(scanner)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if first.Type != snapshotMessage {
+		conn.Close()
+		return nil, fmt.Errorf("expected a snapshot from the server, got %q", first.Type)
+	}
+
+	c := &Client{
+		conn:     conn,
+		encoder:  json.NewEncoder(conn),
+		replica:  model.NewReplica(),
+		incoming: make(chan message, clientQueueSize),
+		done:     make(chan struct{}),
+	}
+	c.apply(first)
+	go func() {
+		defer close(c.incoming)
+		for {
+			m, err := readMessage(scanner)
+			if err != nil {
+				return
+			}
+			select {
+			case c.incoming <- m:
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+// readMessage reads one message from the connection.
+func readMessage(scanner *bufio.Scanner) (message, error) {
+	var m message
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return m, err
+		}
+		return m, fmt.Errorf("connection closed by the server")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+		return m, fmt.Errorf("invalid message from the server: %w", err)
+	}
+	return m, nil
+}
+
+// apply updates the replica with a message from the server.
+func (c *Client) apply(m message) {
+	if m.Type != snapshotMessage && m.Seq != c.lastSeq+1 {
+		c.fail(fmt.Errorf("message %d received after %d", m.Seq, c.lastSeq))
+		return
+	}
+	c.lastSeq = m.Seq
+	switch m.Type {
+	case snapshotMessage:
+		c.replica.Reset(m.Turn, m.Cells)
+	case deltaMessage:
+		c.replica.Apply(m.Turn, m.Born, m.Died)
+	case voteMessage:
+		c.paused, c.votes, c.clients = m.Pause, m.Votes, m.Clients
+	}
+}
+
+// fail records the first error and disconnects.
+func (c *Client) fail(err error) {
+	if c.err == nil {
+		c.err = err
+		c.conn.Close()
+	}
+}
+
+// Err returns the error that disconnected the client, if any.
+func (c *Client) Err() error {
+	return c.err
+}
+
+// Close disconnects from the server.
+func (c *Client) Close() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// send sends a message to the server.
+func (c *Client) send(m message) {
+	if c.err != nil {
+		return
+	}
+	if err := c.encoder.Encode(m); err != nil {
+		c.fail(err)
+	}
+}
+
+// AddCellIn asks the server to bring the cell to life.
+func (c *Client) AddCellIn(x, y, turn int64) {
+	c.send(message{Type: editMessage, X: x, Y: y, Alive: true})
+}
+
+// RemoveCellIn asks the server to kill the cell.
+func (c *Client) RemoveCellIn(x, y int64) {
+	c.send(message{Type: editMessage, X: x, Y: y, Alive: false})
+}
+
+// IsAlive returns true if the cell is alive in the replica.
+func (c *Client) IsAlive(x, y int64) bool {
+	return c.replica.IsAlive(x, y)
+}
+
+// Evolve applies every change received from the server since the last call.
+func (c *Client) Evolve() {
+	for {
+		select {
+		case m, ok := <-c.incoming:
+			if !ok {
+				c.fail(fmt.Errorf("connection to the server lost"))
+				return
+			}
+			c.apply(m)
+		default:
+			return
+		}
+	}
+}
+
+// Turn returns the generation of the replica.
+func (c *Client) Turn() int64 {
+	return c.replica.Turn()
+}
+
+// ForEachCell calls fn for every living cell of the replica.
+func (c *Client) ForEachCell(fn func(x, y, turn int64)) {
+	c.replica.ForEachCell(fn)
+}
+
+// WindowContent returns the content of the replica, with a line about the
+// shared session.
+func (c *Client) WindowContent(topLeft, bottomRight types.Index) string {
+	content := c.replica.WindowContent(topLeft, bottomRight)
+	if c.err != nil {
+		return content + fmt.Sprintf("Disconnected: %s\n", c.err)
+	}
+	state := "running"
+	if c.paused {
+		state = "paused"
+	}
+	return content + fmt.Sprintf("Shared session %s: %d of %d clients vote to pause\n", state, c.votes, c.clients)
+}
+
+// ToggleVote changes the client's vote for pausing the game.
+func (c *Client) ToggleVote() {
+	c.vote = !c.vote
+	c.send(message{Type: voteMessage, Pause: c.vote})
+}
+
+// voteListener turns Pause events into pause votes.
+type voteListener struct {
+	event.Listener
+	client *Client
+}
+
+// Listener wraps a listener so that its Pause events toggle the client's pause
+// vote instead of pausing the local view.
+func (c *Client) Listener(inner event.Listener) event.Listener {
+	return &voteListener{Listener: inner, client: c}
+}
+
+// Check returns the next event of the wrapped listener, handling Pause events.
+func (vl *voteListener) Check() event.Event {
+	e := vl.Listener.Check()
+	if e == event.Pause {
+		vl.client.ToggleVote()
+		return event.None
+	}
+	return e
+}
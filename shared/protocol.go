@@ -0,0 +1,44 @@
+// Package shared lets several terminals watch and edit the same world. A server
+// owns the world, evolves it and broadcasts the changes of every generation to
+// its clients over TCP; each client keeps a replica of the world and shows it
+// with its own view window.
+//
+// The protocol is one JSON message per line. The server sends a "snapshot"
+// message when a client joins, then "delta" messages with the cells born and
+// died in each generation or edit, and "vote" messages when the pause votes
+// change. Clients send "edit" messages to set or clear a cell, and "vote"
+// messages to vote for pausing or running. Every message from the server has a
+// sequence number, and all clients receive the same messages in the same order.
+package shared
+
+// Message types.
+const (
+	snapshotMessage = "snapshot"
+	deltaMessage    = "delta"
+	voteMessage     = "vote"
+	editMessage     = "edit"
+)
+
+// message is the unit of the protocol. Only the fields used by its type are set.
+type message struct {
+	Type string `json:"type"`
+	Seq  int64  `json:"seq,omitempty"`
+	Turn int64  `json:"turn,omitempty"`
+
+	// snapshot: every living cell as {x, y, birth turn}
+	Cells [][3]int64 `json:"cells,omitempty"`
+	// delta: the cells born and died
+	Born [][2]int64 `json:"born,omitempty"`
+	Died [][2]int64 `json:"died,omitempty"`
+
+	// edit: the cell to set or clear
+	X     int64 `json:"x,omitempty"`
+	Y     int64 `json:"y,omitempty"`
+	Alive bool  `json:"alive,omitempty"`
+
+	// vote from a client: whether it wants the game paused; from the server:
+	// whether the game is paused, with the number of votes and clients
+	Pause   bool `json:"pause,omitempty"`
+	Votes   int  `json:"votes,omitempty"`
+	Clients int  `json:"clients,omitempty"`
+}
@@ -0,0 +1,274 @@
+package shared
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/daniel-munoz/life/types"
+)
+
+// clientQueueSize is how many messages can wait for a slow client before it is
+// disconnected. Skipping messages would leave its replica out of sync.
+const clientQueueSize = 1024
+
+// connection is a client connected to the server.
+type connection struct {
+	conn net.Conn
+	out  chan []byte
+	vote bool
+}
+
+// clientMessage is a message received from a connection.
+type clientMessage struct {
+	from *connection
+	msg  message
+}
+
+// Server owns a world and shares it with its clients. All changes to the world,
+// whether from evolution or from client edits, happen in the Run loop, one at a
+// time, so every client sees them in the same order.
+type Server struct {
+	world    types.World
+	listener net.Listener
+	delay    time.Duration
+	clients  map[*connection]bool
+	joins    chan *connection
+	leaves   chan *connection
+	inbox    chan clientMessage
+	done     chan struct{}
+	stopOnce sync.Once
+	seq      int64
+	paused   bool
+}
+
+// Listen creates a server for the world on the TCP address. The world evolves
+// one generation every delay. Clients only learn which cells are born and die,
+// so worlds with more than two states or drawn with a layout are refused.
+func Listen(addr string, w types.World, delay time.Duration) (*Server, error) {
+	if err := checkShareable(w); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		world:    w,
+		listener: l,
+		delay:    delay,
+		clients:  make(map[*connection]bool),
+		joins:    make(chan *connection),
+		leaves:   make(chan *connection),
+		inbox:    make(chan clientMessage),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// checkShareable returns an error if the replicas of the clients cannot show
+// the world.
+func checkShareable(w types.World) error {
+	if multi, ok := w.(types.MultiState); ok && multi.States() > 2 {
+		return fmt.Errorf("cannot share a world with %d cell states", multi.States())
+	}
+	if _, ok := w.(types.Layout); ok {
+		return fmt.Errorf("cannot share a world drawn with a layout, such as a hexagonal one")
+	}
+	return nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops the server and disconnects all clients.
+func (s *Server) Close() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.listener.Close()
+	})
+}
+
+// Run accepts clients and evolves the world until the server is closed.
+func (s *Server) Run() {
+	go s.accept()
+
+	ticker := time.NewTicker(s.delay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			for c := range s.clients {
+				s.drop(c)
+			}
+			return
+		case c := <-s.joins:
+			s.clients[c] = true
+			s.send(c, s.snapshot())
+			s.broadcastVotes()
+		case c := <-s.leaves:
+			if s.clients[c] {
+				s.drop(c)
+				s.broadcastVotes()
+			}
+		case in := <-s.inbox:
+			if s.clients[in.from] {
+				s.handle(in.from, in.msg)
+			}
+		case <-ticker.C:
+			if !s.paused {
+				s.world.Evolve()
+				s.broadcastGeneration()
+			}
+		}
+	}
+}
+
+// accept accepts connections until the listener is closed.
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &connection{conn: conn, out: make(chan []byte, clientQueueSize)}
+		select {
+		case s.joins <- c:
+		case <-s.done:
+			conn.Close()
+			return
+		}
+		go s.write(c)
+		go s.read(c)
+	}
+}
+
+// write sends the queued messages of a connection, until its queue is closed.
+func (s *Server) write(c *connection) {
+	for data := range c.out {
+		if _, err := c.conn.Write(data); err != nil {
+			break
+		}
+	}
+	c.conn.Close()
+}
+
+// read passes the messages of a connection to the Run loop.
+func (s *Server) read(c *connection) {
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		var m message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			break
+		}
+		select {
+		case s.inbox <- clientMessage{from: c, msg: m}:
+		case <-s.done:
+			return
+		}
+	}
+	select {
+	case s.leaves <- c:
+	case <-s.done:
+	}
+}
+
+// handle applies a client message.
+func (s *Server) handle(from *connection, m message) {
+	switch m.Type {
+	case editMessage:
+		s.edit(m.X, m.Y, m.Alive)
+	case voteMessage:
+		from.vote = m.Pause
+		s.broadcastVotes()
+	}
+}
+
+// edit sets or clears a cell and tells every client.
+func (s *Server) edit(x, y int64, alive bool) {
+	if s.world.IsAlive(x, y) == alive {
+		return
+	}
+	delta := message{Type: deltaMessage, Turn: s.world.Turn()}
+	if alive {
+		s.world.AddCellIn(x, y, s.world.Turn())
+		delta.Born = [][2]int64{{x, y}}
+	} else {
+		s.world.RemoveCellIn(x, y)
+		delta.Died = [][2]int64{{x, y}}
+	}
+	s.broadcast(delta)
+}
+
+// broadcastGeneration tells every client about the last generation, as a delta
+// if the world can report its changes or as a snapshot otherwise.
+func (s *Server) broadcastGeneration() {
+	reporter, ok := s.world.(types.ChangeReporter)
+	if !ok {
+		s.broadcast(s.snapshot())
+		return
+	}
+	delta := message{Type: deltaMessage, Turn: s.world.Turn()}
+	reporter.LastChanges(func(x, y int64, born bool) {
+		if born {
+			delta.Born = append(delta.Born, [2]int64{x, y})
+		} else {
+			delta.Died = append(delta.Died, [2]int64{x, y})
+		}
+	})
+	s.broadcast(delta)
+}
+
+// broadcastVotes decides whether the game is paused, which happens when more
+// than half of the clients vote for it, and tells every client.
+func (s *Server) broadcastVotes() {
+	votes := 0
+	for c := range s.clients {
+		if c.vote {
+			votes++
+		}
+	}
+	s.paused = votes*2 > len(s.clients)
+	s.broadcast(message{Type: voteMessage, Pause: s.paused, Votes: votes, Clients: len(s.clients)})
+}
+
+// snapshot builds a message with the whole world.
+func (s *Server) snapshot() message {
+	m := message{Type: snapshotMessage, Seq: s.seq, Turn: s.world.Turn(), Cells: [][3]int64{}}
+	s.world.ForEachCell(func(x, y, turn int64) {
+		m.Cells = append(m.Cells, [3]int64{x, y, turn})
+	})
+	return m
+}
+
+// broadcast numbers a message and queues it for every client.
+func (s *Server) broadcast(m message) {
+	s.seq++
+	m.Seq = s.seq
+	for c := range s.clients {
+		s.send(c, m)
+	}
+}
+
+// send queues a message for a client, dropping the client if it is too slow.
+func (s *Server) send(c *connection, m message) {
+	data, _ := json.Marshal(m)
+	select {
+	case c.out <- append(data, '\n'):
+	default:
+		s.drop(c)
+	}
+}
+
+// drop disconnects a client.
+func (s *Server) drop(c *connection) {
+	if !s.clients[c] {
+		return
+	}
+	delete(s.clients, c)
+	close(c.out)
+}
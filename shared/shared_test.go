@@ -0,0 +1,206 @@
+package shared
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// startServer starts a server sharing a blinker and a block, far apart.
+func startServer(t *testing.T) *Server {
+	t.Helper()
+	w := model.NewWorld()
+	for _, c := range [][2]int64{{0, 0}, {1, 0}, {2, 0}, {10, 10}, {11, 10}, {10, 11}, {11, 11}} {
+		w.AddCellIn(c[0], c[1], 0)
+	}
+	s, err := Listen("127.0.0.1:0", w, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Listen() unexpected error: %v", err)
+	}
+	go s.Run()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// dial connects a client to the server.
+func dial(t *testing.T, s *Server) *Client {
+	t.Helper()
+	c, err := Dial(s.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() unexpected error: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// waitFor evolves the clients until the condition holds.
+func waitFor(t *testing.T, what string, condition func() bool, clients ...*Client) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, c := range clients {
+			c.Evolve()
+			if c.Err() != nil {
+				t.Fatalf("client error: %v", c.Err())
+			}
+		}
+		if condition() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// cellsOf returns the sorted living cells of a world.
+func cellsOf(w types.World) [][2]int64 {
+	var cells [][2]int64
+	w.ForEachCell(func(x, y, turn int64) {
+		cells = append(cells, [2]int64{x, y})
+	})
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i][1] != cells[j][1] {
+			return cells[i][1] < cells[j][1]
+		}
+		return cells[i][0] < cells[j][0]
+	})
+	return cells
+}
+
+// sameCells compares the living cells of two worlds.
+func sameCells(a, b types.World) bool {
+	ca, cb := cellsOf(a), cellsOf(b)
+	if len(ca) != len(cb) {
+		return false
+	}
+	for i := range ca {
+		if ca[i] != cb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestShared_FollowsEvolution(t *testing.T) {
+	s := startServer(t)
+	a := dial(t, s)
+
+	waitFor(t, "generation 5", func() bool { return a.Turn() >= 5 }, a)
+	b := dial(t, s)
+	waitFor(t, "clients at the same generation", func() bool {
+		return a.Turn() == b.Turn() && a.Turn() >= 8
+	}, a, b)
+
+	if !sameCells(a, b) {
+		t.Errorf("clients disagree: %v and %v", cellsOf(a), cellsOf(b))
+	}
+	if len(cellsOf(a)) != 7 {
+		t.Errorf("client has %d cells, want 7", len(cellsOf(a)))
+	}
+	if a.IsAlive(1, 0) != true || a.IsAlive(0, 0) == a.IsAlive(1, -1) {
+		t.Error("blinker in the replica is not in a valid phase")
+	}
+}
+
+func TestShared_Edits(t *testing.T) {
+	s := startServer(t)
+	a := dial(t, s)
+	b := dial(t, s)
+
+	// A block is stable, so it stays until someone removes it
+	for _, c := range [][2]int64{{-20, -20}, {-19, -20}, {-20, -19}, {-19, -19}} {
+		a.AddCellIn(c[0], c[1], 0)
+	}
+	waitFor(t, "additions to reach the other client", func() bool {
+		return b.IsAlive(-20, -20) && b.IsAlive(-19, -19)
+	}, a, b)
+	b.RemoveCellIn(-20, -20)
+	b.RemoveCellIn(-19, -20)
+	waitFor(t, "edits to reach both clients", func() bool {
+		return !a.IsAlive(-20, -20) && !b.IsAlive(-20, -20) && a.Turn() == b.Turn()
+	}, a, b)
+	if !sameCells(a, b) {
+		t.Errorf("clients disagree after edits: %v and %v", cellsOf(a), cellsOf(b))
+	}
+}
+
+func TestShared_PauseVotes(t *testing.T) {
+	s := startServer(t)
+	a := dial(t, s)
+	b := dial(t, s)
+	waitFor(t, "both clients to join", func() bool { return a.clients == 2 && b.clients == 2 }, a, b)
+
+	listener := a.Listener(&scriptedListener{events: []event.Event{event.Pause, event.Up}})
+	if e := listener.Check(); e != event.None {
+		t.Errorf("Pause should become a vote, got %v", e)
+	}
+	if e := listener.Check(); e != event.Up {
+		t.Errorf("other events should pass through, got %v", e)
+	}
+	waitFor(t, "one vote", func() bool { return a.votes == 1 && b.votes == 1 }, a, b)
+	if a.paused {
+		t.Error("one vote out of two clients should not pause")
+	}
+
+	b.ToggleVote()
+	waitFor(t, "pause by majority", func() bool { return a.paused && b.paused }, a, b)
+	turn := a.Turn()
+	time.Sleep(50 * time.Millisecond)
+	a.Evolve()
+	if a.Turn() != turn {
+		t.Errorf("paused world went from generation %d to %d", turn, a.Turn())
+	}
+
+	b.Close()
+	waitFor(t, "the other client to leave", func() bool { return a.clients == 1 }, a)
+	if !a.paused {
+		t.Error("the remaining client still votes to pause")
+	}
+	a.ToggleVote()
+	waitFor(t, "resume", func() bool { return !a.paused && a.votes == 0 }, a)
+}
+
+func TestListen_Refused(t *testing.T) {
+	for _, rule := range []string{"WireWorld", "B2/S/C3", "B2/S34H"} {
+		w, err := model.NewWorldWithRule(rule)
+		if err != nil {
+			t.Fatalf("NewWorldWithRule(%q) unexpected error: %v", rule, err)
+		}
+		if s, err := Listen("127.0.0.1:0", w, time.Second); err == nil {
+			s.Close()
+			t.Errorf("Listen() with rule %s expected error", rule)
+		}
+	}
+
+	w, err := model.NewWorldWithRule("B36/S23")
+	if err != nil {
+		t.Fatalf("NewWorldWithRule() unexpected error: %v", err)
+	}
+	s, err := Listen("127.0.0.1:0", w, time.Second)
+	if err != nil {
+		t.Fatalf("Listen() with a Life-like rule unexpected error: %v", err)
+	}
+	s.Close()
+}
+
+// scriptedListener returns one scripted event per Check call.
+type scriptedListener struct {
+	events []event.Event
+}
+
+func (sl *scriptedListener) Start() {}
+
+func (sl *scriptedListener) Check() event.Event {
+	if len(sl.events) == 0 {
+		return event.None
+	}
+	e := sl.events[0]
+	sl.events = sl.events[1:]
+	return e
+}
+
+func (sl *scriptedListener) Stop() {}
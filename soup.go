@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/ui"
+)
+
+// runSoup implements the "soup" subcommand: it fills a region with random
+// cells and shows the result in the terminal UI, or writes it to an RLE file.
+// The seed is printed, so that the same soup can be made again.
+func runSoup(args []string) error {
+	flags := flag.NewFlagSet("soup", flag.ContinueOnError)
+	seed := flags.Uint64("seed", 0, "generate the soup from `seed` (random if not given)")
+	width := flags.Int64("width", model.DefaultSoupSize, "soup width in cells")
+	height := flags.Int64("height", model.DefaultSoupSize, "soup height in cells")
+	density := flags.Float64("density", model.DefaultSoupDensity, "probability of each cell being alive, between 0 and 1")
+	symmetry := flags.String("symmetry", string(model.C1), "soup symmetry: C1, D2, D4 or D8")
+	rule := flags.String("rule", "", "run the soup with `rule`, such as B36/S23")
+	output := flags.String("o", "", "write the soup to `file` in the RLE format instead of showing it")
+	sessionPath := flags.String("session", defaultSessionPath, "save the session to `file` with the save key")
+	autosave := flags.Bool("autosave", false, "save the session on exit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life soup [-seed n] [-width n] [-height n] [-density p] [-symmetry s] [-rule rule] [-o file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	s := model.NewSoup()
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			s.Seed = *seed
+		}
+	})
+	s.Width, s.Height, s.Density = *width, *height, *density
+	var err error
+	if s.Symmetry, err = model.ParseSymmetry(*symmetry); err != nil {
+		return err
+	}
+
+	w := model.NewWorld()
+	if *rule != "" {
+		if w, err = model.NewWorldWithRule(*rule); err != nil {
+			return err
+		}
+	}
+	game := ui.NewGame(w, defaultViewTop, defaultViewLeft, defaultViewBottom, defaultViewRight)
+	x, y := game.CenterCell()
+	s.Left, s.Top = x-s.Width/2, y-s.Height/2
+	if err := game.NewSoup(s); err != nil {
+		return err
+	}
+	repeat := func(s *model.Soup) string {
+		if *rule != "" {
+			return fmt.Sprintf("%s -rule %s", s, *rule)
+		}
+		return s.String()
+	}
+	fmt.Printf("Soup seed %d, repeat with: life soup %s\n", s.Seed, repeat(&s))
+
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		if err := model.WriteRLE(game.World(), f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	bindings, err := loadBindings()
+	if err != nil {
+		return fmt.Errorf("reading key bindings: %w", err)
+	}
+	game.SetSessionPath(*sessionPath)
+	ui.Show(game, event.NewListenerWithBindings(bindings), bindings)
+	if last := game.Soup(); last != nil {
+		fmt.Printf("Last soup seed %d, repeat with: life soup %s\n", last.Seed, repeat(last))
+	}
+	if *autosave {
+		return game.SaveSession(*sessionPath)
+	}
+	return nil
+}
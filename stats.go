@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// defaultStatsGenerations is the number of generations the "stats" subcommand
+// runs by default.
+const defaultStatsGenerations = 1000
+
+// runStats implements the "stats" subcommand: it runs a sample without a
+// display and writes the population, births, deaths and bounding box size of
+// every generation as CSV.
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	generations := flags.Int64("generations", defaultStatsGenerations, "number of generations to run")
+	rule := flags.String("rule", "", "run the pattern with `rule`, such as B36/S23")
+	output := flags.String("o", "", "write the CSV to `file` instead of the standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: life stats [-generations n] [-rule rule] [-o file] <sample>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("stats needs a sample")
+	}
+
+	w, err := model.ReadWorld(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *rule != "" {
+		if w, err = model.WithRule(w, *rule); err != nil {
+			return err
+		}
+	}
+	if _, ok := w.(types.StatsRecorder); !ok {
+		return fmt.Errorf("%s does not record stats", flags.Arg(0))
+	}
+
+	if *output == "" {
+		return writeStats(w, *generations, os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeStats(w, *generations, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeStats runs the world for the given number of generations, writing the
+// stats of each one to out.
+func writeStats(w types.World, generations int64, out io.Writer) error {
+	recorder := w.(types.StatsRecorder)
+	sw := model.NewStatsWriter(out)
+	for i := int64(0); i < generations; i++ {
+		w.Evolve()
+		history := recorder.History()
+		sw.Write(history[len(history)-1])
+	}
+	return sw.Flush()
+}
@@ -11,8 +11,86 @@ type Index interface {
 type World interface {
 	// AddCellIn adds a new cell at the specified coordinates.
 	AddCellIn(x, y, turn int64)
+	// RemoveCellIn removes the cell at the specified coordinates, if any.
+	RemoveCellIn(x, y int64)
+	// IsAlive returns true if there is a living cell at the specified coordinates.
+	IsAlive(x, y int64) bool
 	// Evolve advances the world by one generation.
 	Evolve()
+	// Turn returns the current generation number.
+	Turn() int64
+	// ForEachCell calls fn with the coordinates and birth turn of every living cell.
+	ForEachCell(fn func(x, y, turn int64))
 	// WindowContent returns a string representation of the world within the given bounds.
 	WindowContent(topLeft, bottomRight Index) string
 }
+
+// ChangeReporter is implemented by worlds that can list the cells changed by
+// their last generation.
+type ChangeReporter interface {
+	// LastChanges calls fn for every cell born (born is true) or died in the last generation.
+	LastChanges(fn func(x, y int64, born bool))
+}
+
+// RuleReporter is implemented by worlds that can name the rule they follow.
+type RuleReporter interface {
+	// Rule returns the rulestring, such as "B3/S23".
+	Rule() string
+}
+
+// MultiState is implemented by worlds whose cells have more states than alive
+// and dead, such as the dying states of Generations rules. State 0 is dead and
+// state 1 is alive; the meaning of higher states depends on the rule.
+type MultiState interface {
+	// States returns the number of cell states, counting the dead state.
+	States() int
+	// StateOf returns the state of the cell at the specified coordinates.
+	StateOf(x, y int64) int
+	// SetStateIn puts a cell in the given state at the specified coordinates.
+	SetStateIn(x, y int64, state int, turn int64)
+	// ForEachState calls fn with the coordinates and state of every cell that is not dead.
+	ForEachState(fn func(x, y int64, state int))
+}
+
+// Layout is implemented by worlds whose cells are not drawn one per column,
+// such as hexagonal worlds drawn with offset rows. The view window of such a
+// world is in columns and rows of the drawing.
+type Layout interface {
+	// CellAt returns the coordinates of the cell drawn at the given column and row.
+	CellAt(column, row int64) (x, y int64)
+	// Position returns the column and row where the cell at the given coordinates is drawn.
+	Position(x, y int64) (column, row int64)
+}
+
+// Stats describes a world right after one of its generations.
+type Stats struct {
+	Turn       int64
+	Population int64
+	// Births and Deaths count the cells that came to life and the living cells
+	// that died in the generation.
+	Births, Deaths int64
+	// Width and Height are the size of the bounding box of the non-empty
+	// cells, 0 when there are none.
+	Width, Height int64
+}
+
+// StatsRecorder is implemented by worlds that keep the Stats of their last
+// generations.
+type StatsRecorder interface {
+	// History returns the Stats of the last generations, oldest first.
+	History() []Stats
+}
+
+// ActivityReporter is implemented by worlds that can count, for every
+// location, the generations its cell was alive in and the times it changed
+// state. They only count once asked to, as the counts take time and memory.
+type ActivityReporter interface {
+	// TrackActivity starts counting from the next generation, or stops
+	// counting and forgets the counts.
+	TrackActivity(on bool)
+	// ActivityOf returns the counts of the location at the specified coordinates.
+	ActivityOf(x, y int64) (alive, changes int64)
+	// ForEachActivity calls fn with the coordinates and counts of every
+	// location that has been alive or changed.
+	ForEachActivity(fn func(x, y int64, alive, changes int64))
+}
@@ -0,0 +1,326 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/session"
+	"github.com/daniel-munoz/life/types"
+)
+
+// objectGap is the number of dead cells allowed between two cells of the same
+// labeled object, so that objects made of separate parts are labeled once.
+const objectGap = 1
+
+// Game is the state shown by the terminal UI: the world and the view on it.
+// Its methods change the world directly and must be called from the game loop,
+// which is where listeners are checked.
+type Game struct {
+	world       types.World
+	view        *GameView
+	stop        chan struct{}
+	sessionPath string
+	notice      string
+	soup        *model.Soup
+	tracker     *analysis.Tracker
+	lastEvent   *analysis.Event
+	query       *analysis.Query
+}
+
+// NewGame creates a game showing the world through a view window defined by the
+// top, left, bottom and right coordinates.
+func NewGame(w types.World, top, left, bottom, right int64) *Game {
+	// The stop is kept until it is read, so that the game loop never waits
+	// for Show, which may be stopping the loop itself
+	stop := make(chan struct{}, 1)
+	return &Game{
+		world: w,
+		view:  NewGameView(top, left, bottom, right, stop),
+		stop:  stop,
+	}
+}
+
+// NewGameFromSession creates a game in the state saved in the session: its
+// world, view window and pause state.
+func NewGameFromSession(s *session.Session) (*Game, error) {
+	w, err := s.World()
+	if err != nil {
+		return nil, err
+	}
+	g := NewGame(w, s.View.Top, s.View.Left, s.View.Bottom, s.View.Right)
+	g.view.SetPaused(s.Paused)
+	g.soup = s.Soup
+	return g, nil
+}
+
+// World returns the world being shown.
+func (g *Game) World() types.World {
+	return g.world
+}
+
+// View returns the view window on the world.
+func (g *Game) View() *GameView {
+	return g.view
+}
+
+// Execute executes the action associated to the given event. Edits and soups
+// act on the world and saves write the session; every other event is passed to
+// the view.
+func (g *Game) Execute(e event.Event) {
+	if e != event.None {
+		g.notice = ""
+	}
+	switch e {
+	case event.Edit:
+		x, y := g.CenterCell()
+		g.Toggle(x, y)
+	case event.Save:
+		g.notice = g.saveNotice()
+	case event.Soup:
+		g.notice = g.soupNotice()
+	case event.Objects:
+		g.ShowObjects(g.tracker == nil)
+	case event.Heatmap:
+		g.view.Execute(e)
+		g.trackActivity()
+	default:
+		g.view.Execute(e)
+	}
+}
+
+// trackActivity makes the world count its activity while the heatmap is
+// shown, and only then.
+func (g *Game) trackActivity() {
+	if reporter, ok := g.world.(types.ActivityReporter); ok {
+		reporter.TrackActivity(g.view.ShowHeatmap())
+	}
+}
+
+// CenterCell returns the coordinates of the cell at the center of the view
+// window, which the edit key toggles.
+func (g *Game) CenterCell() (x, y int64) {
+	x, y = g.view.Center()
+	if layout, ok := g.world.(types.Layout); ok {
+		return layout.CellAt(x, y)
+	}
+	return x, y
+}
+
+// saveNotice saves the session to the session path and describes the result.
+func (g *Game) saveNotice() string {
+	if g.sessionPath == "" {
+		return "No session file to save to"
+	}
+	if err := g.SaveSession(g.sessionPath); err != nil {
+		return fmt.Sprintf("Error saving session: %s", err)
+	}
+	return fmt.Sprintf("Session saved to %s", g.sessionPath)
+}
+
+// soupNotice replaces the world with a new soup, like the last one but with a
+// new seed and centered on the view window, and describes the result.
+func (g *Game) soupNotice() string {
+	s := model.NewSoup()
+	if g.soup != nil {
+		s.Width, s.Height, s.Density, s.Symmetry = g.soup.Width, g.soup.Height, g.soup.Density, g.soup.Symmetry
+	}
+	x, y := g.CenterCell()
+	s.Left, s.Top = x-s.Width/2, y-s.Height/2
+	if err := g.NewSoup(s); err != nil {
+		return fmt.Sprintf("Error making soup: %s", err)
+	}
+	return fmt.Sprintf("Soup seed %d", s.Seed)
+}
+
+// NewSoup replaces the world with the soup, following the same rule, at
+// generation 0.
+func (g *Game) NewSoup(s model.Soup) error {
+	w := model.NewWorld()
+	if reporter, ok := g.world.(types.RuleReporter); ok {
+		var err error
+		if w, err = model.NewWorldWithRule(reporter.Rule()); err != nil {
+			return err
+		}
+	}
+	if err := s.Fill(w); err != nil {
+		return err
+	}
+	g.world = w
+	g.soup = &s
+	g.trackActivity()
+	if g.tracker != nil {
+		g.startTracker()
+	}
+	return nil
+}
+
+// Soup returns the settings of the soup the world started from, or nil when
+// it did not start from a soup.
+func (g *Game) Soup() *model.Soup {
+	return g.soup
+}
+
+// Notice returns the message about the last action, if any, to show below
+// the world.
+func (g *Game) Notice() string {
+	return g.notice
+}
+
+// SetSessionPath sets the file written by the save key.
+func (g *Game) SetSessionPath(path string) {
+	g.sessionPath = path
+}
+
+// Session captures the state of the game.
+func (g *Game) Session() *session.Session {
+	topLeft, bottomRight := g.view.TopLeft(), g.view.BottomRight()
+	view := session.View{Top: topLeft.Y(), Left: topLeft.X(), Bottom: bottomRight.Y(), Right: bottomRight.X()}
+	s := session.Capture(g.world, view, g.view.IsPaused())
+	s.Soup = g.soup
+	return s
+}
+
+// SaveSession writes the state of the game to the file at path.
+func (g *Game) SaveSession(path string) error {
+	return g.Session().Save(path)
+}
+
+// Toggle brings the cell at the given coordinates to life, or kills it. In
+// worlds with more than two states it moves the cell to the next state
+// instead, so that every state can be drawn.
+func (g *Game) Toggle(x, y int64) {
+	if multi, ok := g.world.(types.MultiState); ok && multi.States() > 2 {
+		multi.SetStateIn(x, y, (multi.StateOf(x, y)+1)%multi.States(), g.world.Turn())
+		return
+	}
+	if g.world.IsAlive(x, y) {
+		g.world.RemoveCellIn(x, y)
+	} else {
+		g.world.AddCellIn(x, y, g.world.Turn())
+	}
+}
+
+// Evolve advances the world by one generation, following its objects when
+// they are shown.
+func (g *Game) Evolve() {
+	g.world.Evolve()
+	if g.tracker == nil {
+		return
+	}
+	if events := g.tracker.Update(g.world); len(events) > 0 {
+		g.lastEvent = &events[len(events)-1]
+	}
+}
+
+// Step advances the world by n generations, even when paused.
+func (g *Game) Step(n int64) {
+	for i := int64(0); i < n; i++ {
+		g.Evolve()
+	}
+}
+
+// ShowObjects starts or stops following the objects of the world, which the
+// terminal UI labels with their IDs.
+func (g *Game) ShowObjects(show bool) {
+	g.tracker = nil
+	g.lastEvent = nil
+	if show {
+		g.startTracker()
+	}
+}
+
+// Objects returns the objects of the world as of the last generation, or nil
+// when they are not shown.
+func (g *Game) Objects() []analysis.Object {
+	if g.tracker == nil {
+		return nil
+	}
+	return g.tracker.Objects()
+}
+
+// LastObjectEvent returns the last merge, collision, split, birth or death of
+// objects seen since they were shown, or nil if there was none.
+func (g *Game) LastObjectEvent() *analysis.Event {
+	return g.lastEvent
+}
+
+// SetQuery sets the pattern whose matches the terminal UI highlights, or
+// stops highlighting with nil.
+func (g *Game) SetQuery(q *analysis.Query) {
+	g.query = q
+}
+
+// Query returns the pattern whose matches are highlighted, or nil.
+func (g *Game) Query() *analysis.Query {
+	return g.query
+}
+
+// Matches returns the matches of the query in the world, with the default
+// margin, or nil when there is no query.
+func (g *Game) Matches() []analysis.Match {
+	if g.query == nil {
+		return nil
+	}
+	return g.query.Find(g.world, analysis.DefaultMargin)
+}
+
+// startTracker starts following the objects of the world from the current
+// generation, numbering them again.
+func (g *Game) startTracker() {
+	g.tracker = analysis.NewTracker(objectGap)
+	g.tracker.Update(g.world)
+	g.lastEvent = nil
+}
+
+// Turn returns the generation of the world.
+func (g *Game) Turn() int64 {
+	return g.world.Turn()
+}
+
+// Move moves the view window by dx columns and dy rows.
+func (g *Game) Move(dx, dy int64) {
+	g.view.Move(dx, dy)
+}
+
+// Load replaces the world with the named sample.
+func (g *Game) Load(sampleName string) error {
+	w, err := model.ReadWorld(sampleName)
+	if err != nil {
+		return err
+	}
+	g.world = w
+	g.soup = nil
+	g.trackActivity()
+	if g.tracker != nil {
+		g.startTracker()
+	}
+	return nil
+}
+
+// Save writes the world to the file at path, in the RLE format.
+func (g *Game) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := model.WriteRLE(g.world, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Stats describes the world and the view window in one line.
+func (g *Game) Stats() string {
+	population := 0
+	g.world.ForEachCell(func(x, y, turn int64) {
+		population++
+	})
+	topLeft, bottomRight := g.view.TopLeft(), g.view.BottomRight()
+	return fmt.Sprintf("generation %d population %d paused %t view (%d,%d) -> (%d,%d)",
+		g.world.Turn(), population, g.view.IsPaused(),
+		topLeft.X(), topLeft.Y(), bottomRight.X(), bottomRight.Y())
+}
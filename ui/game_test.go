@@ -0,0 +1,289 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/session"
+	"github.com/daniel-munoz/life/types"
+)
+
+// newBlinkerGame creates a game showing a horizontal blinker at the origin.
+func newBlinkerGame() *Game {
+	w := model.NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.AddCellIn(2, 0, 0)
+	return NewGame(w, -5, -5, 5, 5)
+}
+
+func TestGame_Step(t *testing.T) {
+	g := newBlinkerGame()
+
+	g.Step(3)
+	if g.Turn() != 3 {
+		t.Errorf("Turn() after Step(3) = %d, want 3", g.Turn())
+	}
+}
+
+func TestGame_HeatmapActivity(t *testing.T) {
+	g := newBlinkerGame()
+	changes := func() int64 {
+		var total int64
+		g.World().(types.ActivityReporter).ForEachActivity(func(x, y int64, alive, changes int64) {
+			total += changes
+		})
+		return total
+	}
+
+	g.Step(1)
+	if got := changes(); got != 0 {
+		t.Errorf("changes counted with the heatmap hidden = %d, want 0", got)
+	}
+	g.Execute(event.Heatmap)
+	g.Step(1)
+	if got := changes(); got != 4 {
+		t.Errorf("changes counted with the heatmap shown = %d, want 4", got)
+	}
+	g.Execute(event.Heatmap)
+	if got := changes(); got != 0 {
+		t.Errorf("changes kept after hiding the heatmap = %d, want 0", got)
+	}
+}
+
+func TestGame_StopWithoutReader(t *testing.T) {
+	// Stopping never waits for Show, which may be busy stopping the loop
+	g := newBlinkerGame()
+	g.Execute(event.Stop)
+	g.Execute(event.Stop)
+	select {
+	case <-g.stop:
+	default:
+		t.Error("Stop should leave a stop to read")
+	}
+}
+
+func TestGame_Move(t *testing.T) {
+	g := newBlinkerGame()
+	g.Move(5, -3)
+
+	topLeft, bottomRight := g.View().TopLeft(), g.View().BottomRight()
+	if topLeft.X() != 0 || topLeft.Y() != -8 || bottomRight.X() != 10 || bottomRight.Y() != 2 {
+		t.Errorf("view after Move(5, -3) = (%d,%d) -> (%d,%d), want (0,-8) -> (10,2)",
+			topLeft.X(), topLeft.Y(), bottomRight.X(), bottomRight.Y())
+	}
+}
+
+func TestGame_SaveAndStats(t *testing.T) {
+	g := newBlinkerGame()
+
+	path := filepath.Join(t.TempDir(), "out.rle")
+	if err := g.Save(path); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if !strings.Contains(string(content), "3o!") {
+		t.Errorf("saved RLE = %q, want it to contain the blinker", content)
+	}
+
+	if err := g.Save(filepath.Join(t.TempDir(), "missing", "out.rle")); err == nil {
+		t.Error("Save() into a missing directory expected error")
+	}
+
+	stats := g.Stats()
+	if !strings.Contains(stats, "generation 0 population 3 paused false") {
+		t.Errorf("Stats() = %q", stats)
+	}
+}
+
+func TestGame_Load(t *testing.T) {
+	g := newBlinkerGame()
+	original := g.World()
+
+	if err := g.Load("non-existent"); err == nil {
+		t.Error("Load() expected error for a missing sample")
+	}
+	if g.World() != original {
+		t.Error("failed Load() should keep the current world")
+	}
+}
+
+func TestGame_Edit(t *testing.T) {
+	g := newBlinkerGame()
+	g.View().Move(5, 0)
+
+	// The view is (0,-5) -> (10,5), so its center is (5,0)
+	g.Execute(event.Edit)
+	if !g.World().IsAlive(5, 0) {
+		t.Error("Edit should bring the center cell to life")
+	}
+	g.Execute(event.Edit)
+	if g.World().IsAlive(5, 0) {
+		t.Error("a second Edit should kill the center cell")
+	}
+
+	g.Execute(event.Pause)
+	if !g.View().IsPaused() {
+		t.Error("other events should reach the view")
+	}
+}
+
+func TestGame_EditHexagonal(t *testing.T) {
+	w, err := model.NewWorldWithRule("B2/S34H")
+	if err != nil {
+		t.Fatalf("NewWorldWithRule() unexpected error: %v", err)
+	}
+	g := NewGame(w, -4, 0, 4, 10)
+
+	// The center of the view is column 5 of row 0, which is between cells:
+	// it belongs to the cell drawn at column 4, (2,0)
+	if x, y := g.CenterCell(); x != 2 || y != 0 {
+		t.Errorf("CenterCell() = (%d, %d), want (2, 0)", x, y)
+	}
+	g.Execute(event.Edit)
+	if !w.IsAlive(2, 0) {
+		t.Error("Edit should bring the center cell to life")
+	}
+
+	// One row down, the cells are shifted by half a cell
+	g.Execute(event.Down)
+	g.Execute(event.Edit)
+	if !w.IsAlive(3, 1) {
+		t.Error("Edit should toggle the cell drawn at the center of an odd row")
+	}
+}
+
+func TestGame_Soup(t *testing.T) {
+	w, err := model.NewWorldWithRule("B36/S23")
+	if err != nil {
+		t.Fatalf("NewWorldWithRule() unexpected error: %v", err)
+	}
+	w.AddCellIn(100, 100, 0)
+	w.Evolve()
+	g := NewGame(w, 0, 0, 20, 40)
+
+	g.Execute(event.Soup)
+	if !strings.HasPrefix(g.Notice(), "Soup seed ") || g.Soup() == nil {
+		t.Fatalf("Notice() after a soup = %q, soup %v", g.Notice(), g.Soup())
+	}
+	soup := *g.Soup()
+	if soup.Left != 20-soup.Width/2 || soup.Top != 10-soup.Height/2 {
+		t.Errorf("soup at (%d,%d), want it centered on the view", soup.Left, soup.Top)
+	}
+	if g.World().Turn() != 0 || g.World().IsAlive(100, 100) {
+		t.Error("the soup should replace the world")
+	}
+	if reporter, ok := g.World().(types.RuleReporter); !ok || reporter.Rule() != "B36/S23" {
+		t.Error("the soup should keep the rule of the world")
+	}
+	if g.Session().Soup == nil || *g.Session().Soup != soup {
+		t.Errorf("Session().Soup = %v, want %+v", g.Session().Soup, soup)
+	}
+
+	// The next soup has the same settings and a new seed
+	g.Execute(event.Soup)
+	next := *g.Soup()
+	if next.Seed == soup.Seed || next.Width != soup.Width || next.Density != soup.Density {
+		t.Errorf("next soup = %+v after %+v", next, soup)
+	}
+}
+
+func TestGame_Objects(t *testing.T) {
+	g := newBlinkerGame()
+	g.World().AddCellIn(-20, -20, 0)
+	g.World().AddCellIn(10, 0, 0)
+	g.World().AddCellIn(11, 0, 0)
+	g.World().AddCellIn(10, 1, 0)
+	g.World().AddCellIn(11, 1, 0)
+	if g.Objects() != nil {
+		t.Fatal("Objects() should be nil until they are shown")
+	}
+
+	g.Execute(event.Objects)
+	if objects := g.Objects(); len(objects) != 3 {
+		t.Fatalf("Objects() = %v, want a lone cell, the blinker and the block", objects)
+	}
+
+	// The lone cell dies, and the blinker keeps its ID as it turns
+	g.Step(1)
+	if last := g.LastObjectEvent(); last == nil || last.String() != "turn 1: death of 1" {
+		t.Errorf("LastObjectEvent() = %v, want the death of the lone cell", last)
+	}
+	objects := g.Objects()
+	if len(objects) != 2 || objects[0].ID != 2 || objects[0].Height() != 3 {
+		t.Errorf("Objects() = %v, want the vertical blinker with ID 2", objects)
+	}
+
+	g.Execute(event.Objects)
+	if g.Objects() != nil || g.LastObjectEvent() != nil {
+		t.Error("Objects() should be nil once they are hidden")
+	}
+}
+
+func TestGame_Matches(t *testing.T) {
+	g := newBlinkerGame()
+	if g.Matches() != nil {
+		t.Fatal("Matches() should be nil without a query")
+	}
+
+	// A vertical blinker finds the horizontal one
+	pattern := model.NewWorld()
+	pattern.AddCellIn(0, 0, 0)
+	pattern.AddCellIn(0, 1, 0)
+	pattern.AddCellIn(0, 2, 0)
+	query, err := analysis.NewQuery(pattern, false)
+	if err != nil {
+		t.Fatalf("NewQuery() unexpected error: %v", err)
+	}
+	g.SetQuery(query)
+	matches := g.Matches()
+	if len(matches) != 1 || matches[0].X != 0 || matches[0].Y != 0 {
+		t.Errorf("Matches() = %v, want the blinker at (0,0)", matches)
+	}
+}
+
+func TestGame_SaveSession(t *testing.T) {
+	g := newBlinkerGame()
+	g.Step(5)
+	g.Move(2, 3)
+	g.Execute(event.Pause)
+	g.Execute(event.Edit)
+
+	if g.Execute(event.Save); g.Notice() != "No session file to save to" {
+		t.Errorf("Notice() without a session path = %q", g.Notice())
+	}
+
+	path := filepath.Join(t.TempDir(), "life.session")
+	g.SetSessionPath(path)
+	g.Execute(event.Save)
+	if !strings.Contains(g.Notice(), "Session saved") {
+		t.Fatalf("Notice() after saving = %q", g.Notice())
+	}
+	g.Execute(event.Up)
+	if g.Notice() != "" {
+		t.Errorf("Notice() should be cleared by the next event, got %q", g.Notice())
+	}
+
+	s, err := session.Load(path)
+	if err != nil {
+		t.Fatalf("session.Load() unexpected error: %v", err)
+	}
+	restored, err := NewGameFromSession(s)
+	if err != nil {
+		t.Fatalf("NewGameFromSession() unexpected error: %v", err)
+	}
+	if restored.Stats() != "generation 5 population 4 paused true view (-3,-2) -> (7,8)" {
+		t.Errorf("restored Stats() = %q", restored.Stats())
+	}
+	if !restored.World().IsAlive(2, 3) {
+		t.Error("edited cell not restored")
+	}
+}
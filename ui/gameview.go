@@ -11,14 +11,15 @@ const pageScrollAmount = 10
 
 // GameView is the view of the game. It shows the world in a view window, defined
 // by the top, left, bottom and right coordinates. It also keeps the status of the
-// pause and help flags.
+// pause, help, charts and heatmap flags.
 type GameView struct {
-	top, left, bottom, right int64
-	paused, showHelp, ended  bool
-	actions                  map[event.Event]Action
+	top, left, bottom, right                         int64
+	paused, showHelp, showCharts, showHeatmap, ended bool
+	actions                                          map[event.Event]Action
 }
 
-// NewGameView creates a new GameView.
+// NewGameView creates a new GameView. The Stop action sends on stopChannel
+// unless a stop is already waiting there, so the channel should be buffered.
 func NewGameView(top, left, bottom, right int64, stopChannel chan struct{}) *GameView {
 	gv := &GameView{
 		top:    top,
@@ -29,7 +30,10 @@ func NewGameView(top, left, bottom, right int64, stopChannel chan struct{}) *Gam
 	gv.actions = map[event.Event]Action{
 		event.Stop: func() {
 			gv.ended = true
-			stopChannel <- struct{}{}
+			select {
+			case stopChannel <- struct{}{}:
+			default:
+			}
 		},
 		event.Up: func() {
 			gv.top--
@@ -70,10 +74,29 @@ func NewGameView(top, left, bottom, right int64, stopChannel chan struct{}) *Gam
 		event.Pause: func() {
 			gv.paused = !gv.paused
 		},
+		event.Charts: func() {
+			gv.showCharts = !gv.showCharts
+		},
+		event.Heatmap: func() {
+			gv.showHeatmap = !gv.showHeatmap
+		},
 	}
 	return gv
 }
 
+// Move moves the view window by dx columns and dy rows.
+func (gv *GameView) Move(dx, dy int64) {
+	gv.left += dx
+	gv.right += dx
+	gv.top += dy
+	gv.bottom += dy
+}
+
+// Center returns the coordinates of the cell at the center of the view window.
+func (gv *GameView) Center() (x, y int64) {
+	return (gv.left + gv.right) / 2, (gv.top + gv.bottom) / 2
+}
+
 // TopLeft returns the top and left coordinates of the view window.
 func (gv *GameView) TopLeft() types.Index {
 	return model.NewIndex(gv.left, gv.top)
@@ -89,6 +112,11 @@ func (gv *GameView) IsPaused() bool {
 	return gv.paused
 }
 
+// SetPaused pauses or resumes the game.
+func (gv *GameView) SetPaused(paused bool) {
+	gv.paused = paused
+}
+
 // ShowHelp returns true if the help is being shown.
 func (gv *GameView) ShowHelp() bool {
 	return gv.showHelp
@@ -99,6 +127,17 @@ func (gv *GameView) ToggleHelp() {
 	gv.showHelp = !gv.showHelp
 }
 
+// ShowCharts returns true if the charts of the world's stats are being shown.
+func (gv *GameView) ShowCharts() bool {
+	return gv.showCharts
+}
+
+// ShowHeatmap returns true if the heatmap of the world's activity is being
+// shown.
+func (gv *GameView) ShowHeatmap() bool {
+	return gv.showHeatmap
+}
+
 // Execute executes the action associated to the given event.
 func (gv *GameView) Execute(e event.Event) {
 	action, ok := gv.actions[e]
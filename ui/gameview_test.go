@@ -15,12 +15,12 @@ func TestNewGameView(t *testing.T) {
 			gv.top, gv.left, gv.bottom, gv.right)
 	}
 
-	if gv.paused || gv.showHelp || gv.ended {
+	if gv.paused || gv.showHelp || gv.showCharts || gv.showHeatmap || gv.ended {
 		t.Error("New GameView should start with flags set to false")
 	}
 
-	if len(gv.actions) != 11 {
-		t.Errorf("Expected 11 actions, got %d", len(gv.actions))
+	if len(gv.actions) != 13 {
+		t.Errorf("Expected 13 actions, got %d", len(gv.actions))
 	}
 }
 
@@ -142,6 +142,28 @@ func TestGameView_ToggleFlags(t *testing.T) {
 	if gv.ShowHelp() {
 		t.Error("Help should be hidden after ToggleHelp")
 	}
+
+	// Test charts toggle
+	if gv.ShowCharts() {
+		t.Error("Charts should start hidden")
+	}
+	gv.Execute(event.Charts)
+	if !gv.ShowCharts() {
+		t.Error("Charts should be shown after Charts event")
+	}
+	gv.Execute(event.Charts)
+	if gv.ShowCharts() {
+		t.Error("Charts should be hidden after second Charts event")
+	}
+
+	// Test heatmap toggle
+	if gv.ShowHeatmap() {
+		t.Error("Heatmap should start hidden")
+	}
+	gv.Execute(event.Heatmap)
+	if !gv.ShowHeatmap() {
+		t.Error("Heatmap should be shown after Heatmap event")
+	}
 }
 
 func TestGameView_Stop(t *testing.T) {
@@ -1,14 +1,18 @@
 package ui
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"atomicgo.dev/cursor"
+	"github.com/daniel-munoz/life/analysis"
 	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
 	"github.com/daniel-munoz/life/types"
 )
 
@@ -18,42 +22,319 @@ const (
 	frameDelay          = 200 * time.Millisecond  // Minimum time between frame updates
 )
 
-// options contains the help text shown when user presses 'h'.
+// editMarker shows the cell that the edit key toggles, while the game is paused.
+const editMarker = '+'
+
+// matchMarker replaces the living cells of the matches of the query.
+const matchMarker = '*'
+
+// sparkBars are the bars of the charts, from the lowest value to the highest.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// heatPalette are the ANSI 256-color backgrounds of the heatmap, from the
+// least active cells to the most active ones: dark red to red, yellow and
+// white.
+var heatPalette = []int{52, 88, 124, 160, 196, 202, 208, 214, 220, 226, 228, 230, 231}
+
+// chartLabelWidth and chartValueWidth are the columns taken by the name and the
+// last value of each chart.
 const (
-	options string = `Keys:
-  Up   : moves window 1 space up       Down : moves window 1 space down
-  Left : moves window 1 space left     Right: moves window 1 space right
-  I    : moves window 10 spaces up     K    : moves window 10 spaces down
-  J    : moves window 10 spaces left   L    : moves window 10 spaces right
-  Q    : ends the program              H    : displays this help
-`
+	chartLabelWidth = 11
+	chartValueWidth = 10
 )
 
+// helpDescriptions describes what each bindable event does, for the help text.
+var helpDescriptions = map[event.Event]string{
+	event.Up:        "moves window 1 space up",
+	event.Down:      "moves window 1 space down",
+	event.Left:      "moves window 1 space left",
+	event.Right:     "moves window 1 space right",
+	event.PageUp:    "moves window 10 spaces up",
+	event.PageDown:  "moves window 10 spaces down",
+	event.PageLeft:  "moves window 10 spaces left",
+	event.PageRight: "moves window 10 spaces right",
+	event.Stop:      "ends the program",
+	event.Help:      "displays this help",
+	event.Pause:     "pauses or resumes the game",
+	event.Edit:      "toggles the cell at the center",
+	event.Save:      "saves the session",
+	event.Soup:      "starts a new random soup",
+	event.Objects:   "labels the objects",
+	event.Charts:    "charts the population",
+	event.Heatmap:   "shows where cells changed",
+}
+
+// helpText builds the help shown when the user presses the help key, from the
+// active key bindings, two entries per line.
+func helpText(bindings *event.Bindings) string {
+	var entries []string
+	keyWidth := 0
+	for _, e := range bindings.Events() {
+		keys := strings.Join(bindings.Keys(e), "/")
+		if len(keys) > keyWidth {
+			keyWidth = len(keys)
+		}
+	}
+	for _, e := range bindings.Events() {
+		keys := strings.Join(bindings.Keys(e), "/")
+		if keys == "" {
+			keys = "-"
+		}
+		entries = append(entries, fmt.Sprintf("%-*s: %-30s", keyWidth, keys, helpDescriptions[e]))
+	}
+
+	buffer := &strings.Builder{}
+	fmt.Fprintln(buffer, "Keys:")
+	for i := 0; i < len(entries); i += 2 {
+		line := "  " + entries[i]
+		if i+1 < len(entries) {
+			line += "   " + entries[i+1]
+		}
+		fmt.Fprintln(buffer, strings.TrimRight(line, " "))
+	}
+	return buffer.String()
+}
+
 // Action is a function that updates the status of the world.
 type Action func()
 
-// runGameLoop runs the main game loop, handling display updates and event processing.
-func runGameLoop(w types.World, gameView *GameView, display Display, listener event.Listener) {
+// runGameLoop runs the main game loop, handling display updates and event
+// processing, until the game ends or the done channel is closed.
+func runGameLoop(game *Game, display Display, listener event.Listener, help string, done <-chan struct{}) {
+	gameView := game.View()
 	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		w := game.World()
 		if gameView.ShowHelp() {
-			display.UpdateAndLock(options, helpDisplayDuration)
+			display.UpdateAndLock(help, helpDisplayDuration)
 			gameView.ToggleHelp()
 		}
 		if !gameView.IsPaused() {
-			w.Evolve()
+			game.Evolve()
 		}
 
 		topLeft, bottomRight := gameView.TopLeft(), gameView.BottomRight()
-		display.UpdateAndLock(w.WindowContent(topLeft, bottomRight), frameDelay)
+		content := w.WindowContent(topLeft, bottomRight)
+		if game.Query() != nil {
+			matches := game.Matches()
+			content = highlightMatches(content, w, matches, topLeft) + fmt.Sprintf("Matches: %d\n", len(matches))
+		}
+		if objects := game.Objects(); objects != nil {
+			content = labelObjects(content, w, objects, topLeft) + objectsLine(objects, game.LastObjectEvent())
+		}
+		if recorder, ok := w.(types.StatsRecorder); ok && gameView.ShowCharts() {
+			content += charts(recorder.History(), int(bottomRight.X()-topLeft.X()+1))
+		}
+		if gameView.IsPaused() {
+			x, y := game.CenterCell()
+			if layout, ok := w.(types.Layout); ok {
+				x, y = layout.Position(x, y)
+			}
+			content = markCell(content, x-topLeft.X(), y-topLeft.Y())
+		}
+		if reporter, ok := w.(types.ActivityReporter); ok && gameView.ShowHeatmap() {
+			content = heatmap(content, w, reporter, topLeft, bottomRight)
+		}
+		if notice := game.Notice(); notice != "" {
+			content += notice + "\n"
+		}
+		display.UpdateAndLock(content, frameDelay)
 
 		check := listener.Check()
-		gameView.Execute(check)
+		game.Execute(check)
 		if gameView.Ended() {
 			return
 		}
 	}
 }
 
+// markCell draws the edit marker over an empty cell of the window content, at
+// the given column and row of the window (the status line is skipped).
+func markCell(content string, column, row int64) string {
+	lines := strings.Split(content, "\n")
+	if row < 0 || row+1 >= int64(len(lines)) {
+		return content
+	}
+	line := []rune(lines[row+1])
+	if column < 0 || column >= int64(len(line)) || line[column] != ' ' {
+		return content
+	}
+	line[column] = editMarker
+	lines[row+1] = string(line)
+	return strings.Join(lines, "\n")
+}
+
+// labelObjects writes the ID of each object of the world over the empty cells
+// just above the top left corner of its bounding box, in the window content
+// whose top left cell is given (the status line is skipped).
+func labelObjects(content string, w types.World, objects []analysis.Object, topLeft types.Index) string {
+	lines := strings.Split(content, "\n")
+	layout, hasLayout := w.(types.Layout)
+	for _, o := range objects {
+		column, row := o.Left, o.Top-1
+		if hasLayout {
+			column, row = layout.Position(column, row)
+		}
+		column, row = column-topLeft.X(), row-topLeft.Y()
+		if row < 0 || row+1 >= int64(len(lines)) {
+			continue
+		}
+		line := []rune(lines[row+1])
+		for i, r := range fmt.Sprint(o.ID) {
+			c := column + int64(i)
+			if c >= 0 && c < int64(len(line)) && line[c] == ' ' {
+				line[c] = r
+			}
+		}
+		lines[row+1] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightMatches draws the living cells of the matches with the match
+// marker, in the window content whose top left cell is given (the status line
+// is skipped).
+func highlightMatches(content string, w types.World, matches []analysis.Match, topLeft types.Index) string {
+	lines := strings.Split(content, "\n")
+	layout, hasLayout := w.(types.Layout)
+	for _, m := range matches {
+		for _, c := range m.Cells {
+			column, row := c.X, c.Y
+			if hasLayout {
+				column, row = layout.Position(column, row)
+			}
+			column, row = column-topLeft.X(), row-topLeft.Y()
+			if row < 0 || row+1 >= int64(len(lines)) {
+				continue
+			}
+			line := []rune(lines[row+1])
+			if column >= 0 && column < int64(len(line)) {
+				line[column] = matchMarker
+				lines[row+1] = string(line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// heatmap colors the background of the window content whose corners are
+// given (the status line is skipped) by how many times each cell changed, as
+// counted by the reporter. It must be drawn last, as the colors take more
+// characters than the cells.
+func heatmap(content string, w types.World, reporter types.ActivityReporter, topLeft, bottomRight types.Index) string {
+	layout, hasLayout := w.(types.Layout)
+	cellAt := func(column, row int64) (int64, int64) {
+		if hasLayout {
+			return layout.CellAt(column, row)
+		}
+		return column, row
+	}
+	var highest int64
+	for row := topLeft.Y(); row <= bottomRight.Y(); row++ {
+		for column := topLeft.X(); column <= bottomRight.X(); column++ {
+			_, changes := reporter.ActivityOf(cellAt(column, row))
+			highest = max(highest, changes)
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	for row := int64(0); row <= bottomRight.Y()-topLeft.Y() && row+1 < int64(len(lines)); row++ {
+		buffer := &strings.Builder{}
+		current := -1
+		for column, r := range []rune(lines[row+1]) {
+			color := -1
+			_, changes := reporter.ActivityOf(cellAt(topLeft.X()+int64(column), topLeft.Y()+row))
+			if changes > 0 {
+				level := model.HeatLevel(changes, highest)
+				color = heatPalette[int(level*float64(len(heatPalette)-1))]
+			}
+			if color != current {
+				if color < 0 {
+					buffer.WriteString("\x1b[0m")
+				} else {
+					fmt.Fprintf(buffer, "\x1b[48;5;%dm", color)
+				}
+				current = color
+			}
+			buffer.WriteRune(r)
+		}
+		if current >= 0 {
+			buffer.WriteString("\x1b[0m")
+		}
+		lines[row+1] = buffer.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// charts draws the population, births, deaths and bounding box area of the
+// last generations as one sparkline each, fitting in the given number of
+// columns, with the last value after each.
+func charts(history []types.Stats, columns int) string {
+	width := max(columns-chartLabelWidth-chartValueWidth, 1)
+	history = history[max(len(history)-width, 0):]
+	series := []struct {
+		name  string
+		value func(s types.Stats) int64
+	}{
+		{"population", func(s types.Stats) int64 { return s.Population }},
+		{"births", func(s types.Stats) int64 { return s.Births }},
+		{"deaths", func(s types.Stats) int64 { return s.Deaths }},
+		{"box", func(s types.Stats) int64 { return s.Width * s.Height }},
+	}
+
+	buffer := &strings.Builder{}
+	for _, chart := range series {
+		values := make([]int64, len(history))
+		for i, s := range history {
+			values[i] = chart.value(s)
+		}
+		last := "-"
+		if len(history) > 0 {
+			last = fmt.Sprint(values[len(values)-1])
+			if chart.name == "box" {
+				s := history[len(history)-1]
+				last = fmt.Sprintf("%dx%d", s.Width, s.Height)
+			}
+		}
+		fmt.Fprintf(buffer, "%-*s%-*s %s\n", chartLabelWidth, chart.name, width, sparkline(values), last)
+	}
+	return buffer.String()
+}
+
+// sparkline draws the values as bars scaled between the lowest and the
+// highest of them.
+func sparkline(values []int64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if high > low {
+			level = int((v - low) * int64(len(sparkBars)-1) / (high - low))
+		}
+		bars[i] = sparkBars[level]
+	}
+	return string(bars)
+}
+
+// objectsLine describes the objects shown and the last thing that happened to
+// them, in one line.
+func objectsLine(objects []analysis.Object, last *analysis.Event) string {
+	if last == nil {
+		return fmt.Sprintf("Objects: %d\n", len(objects))
+	}
+	return fmt.Sprintf("Objects: %d, last %s\n", len(objects), last)
+}
+
 // resetTerminal forces a terminal reset using stty to restore normal input mode
 func resetTerminal() {
 	// Use stty to reset terminal to sane state
@@ -64,10 +345,9 @@ func resetTerminal() {
 	cmd.Run() // Ignore errors as this is best-effort cleanup
 }
 
-// Show displays the world in a terminal window.
-func Show(w types.World, top, left, bottom, right int64) {
-	stopChannel := make(chan struct{})
-	
+// Show displays the game in a terminal window, taking events from the listener.
+// The bindings are used to build the help text.
+func Show(game *Game, listener event.Listener, bindings *event.Bindings) {
 	// Set up signal handling for proper cleanup
 	sigChannel := make(chan os.Signal, 1)
 	signal.Notify(sigChannel, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
@@ -83,16 +363,26 @@ func Show(w types.World, top, left, bottom, right int64) {
 	display := NewDisplay()
 	defer display.Close()
 
-	listener := event.NewListener()
 	listener.Start()
 	defer listener.Stop()
 
-	gameView := NewGameView(top, left, bottom, right, stopChannel)
+	// The game loop is stopped before returning, so the caller can use the
+	// game safely, for example to save the session
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		runGameLoop(game, display, listener, helpText(bindings), done)
+	}()
+	stopLoop := func() {
+		close(done)
+		<-finished
+	}
 
-	go runGameLoop(w, gameView, display, listener)
 	for {
 		select {
-		case <-stopChannel:
+		case <-game.stop:
+			stopLoop()
 			display.UpdateAndClose("Time to stop")
 			cursor.Show()
 			resetTerminal()
@@ -101,6 +391,7 @@ func Show(w types.World, top, left, bottom, right int64) {
 			// Handle signals for proper cleanup
 			switch sig {
 			case syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP:
+				stopLoop()
 				display.UpdateAndClose("Program interrupted")
 				cursor.Show()
 				resetTerminal()
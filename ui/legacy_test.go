@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daniel-munoz/life/analysis"
+	"github.com/daniel-munoz/life/event"
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+func TestHelpText(t *testing.T) {
+	bindings, err := event.NewBindings("vi")
+	if err != nil {
+		t.Fatalf("NewBindings() unexpected error: %v", err)
+	}
+	bindings.Bind(event.Pause, "p", "space")
+
+	help := helpText(bindings)
+
+	for _, want := range []string{
+		"k/up",
+		"moves window 1 space up",
+		"q/ctrl+c",
+		"p/space",
+		"pauses or resumes the game",
+		"?",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("helpText() missing %q in:\n%s", want, help)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(help), "\n")
+	wantLines := 1 + (len(bindings.Events())+1)/2
+	if len(lines) != wantLines {
+		t.Errorf("helpText() has %d lines, want %d", len(lines), wantLines)
+	}
+}
+
+func TestMarkCell(t *testing.T) {
+	content := "Turn: 1\n x \n   \n"
+
+	tests := []struct {
+		name        string
+		column, row int64
+		want        string
+	}{
+		{name: "empty cell", column: 2, row: 1, want: "Turn: 1\n x \n  +\n"},
+		{name: "live cell", column: 1, row: 0, want: content},
+		{name: "outside", column: 3, row: 0, want: content},
+		{name: "below", column: 0, row: 2, want: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markCell(content, tt.column, tt.row); got != tt.want {
+				t.Errorf("markCell(%d, %d) = %q, want %q", tt.column, tt.row, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelObjects(t *testing.T) {
+	content := "Turn: 1\n      \n xx   \n xx  x\n"
+	objects := []analysis.Object{
+		{ID: 7, Left: 1, Top: 1, Right: 2, Bottom: 2},
+		{ID: 12, Left: 4, Top: 2, Right: 4, Bottom: 2},
+		{ID: 3, Left: 2, Top: -5, Right: 2, Bottom: -5},
+	}
+
+	got := labelObjects(content, model.NewWorld(), objects, model.NewIndex(0, 0))
+	want := "Turn: 1\n 7    \n xx 12\n xx  x\n"
+	if got != want {
+		t.Errorf("labelObjects() = %q, want %q", got, want)
+	}
+}
+
+func TestObjectsLine(t *testing.T) {
+	objects := make([]analysis.Object, 3)
+	if got := objectsLine(objects, nil); got != "Objects: 3\n" {
+		t.Errorf("objectsLine() = %q", got)
+	}
+	last := &analysis.Event{Kind: analysis.Merge, Turn: 4, ID: 1, Parents: []int{1, 2}}
+	if got := objectsLine(objects, last); got != "Objects: 3, last turn 4: merge of 1 and 2 into 1\n" {
+		t.Errorf("objectsLine() = %q", got)
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	content := "Turn: 1\n x  \nxxx \n"
+	matches := []analysis.Match{
+		{X: 0, Y: 1, Cells: []analysis.Cell{{X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1}}},
+		{X: 3, Y: 9, Cells: []analysis.Cell{{X: 3, Y: 9}}},
+	}
+
+	got := highlightMatches(content, model.NewWorld(), matches, model.NewIndex(0, 0))
+	want := "Turn: 1\n x  \n*** \n"
+	if got != want {
+		t.Errorf("highlightMatches() = %q, want %q", got, want)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+		want   string
+	}{
+		{name: "empty", values: nil, want: ""},
+		{name: "flat", values: []int64{5, 5, 5}, want: "▁▁▁"},
+		{name: "rising", values: []int64{0, 1, 2, 3, 4, 5, 6, 7}, want: "▁▂▃▄▅▆▇█"},
+		{name: "scaled", values: []int64{10, 24, 17}, want: "▁█▄"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sparkline(tt.values); got != tt.want {
+				t.Errorf("sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharts(t *testing.T) {
+	var history []types.Stats
+	for turn := int64(1); turn <= 40; turn++ {
+		history = append(history, types.Stats{Turn: turn, Population: turn, Births: 2, Deaths: 1, Width: 3, Height: turn})
+	}
+
+	got := charts(history, chartLabelWidth+chartValueWidth+8)
+	want := "population ▁▂▃▄▅▆▇█ 40\n" +
+		"births     ▁▁▁▁▁▁▁▁ 2\n" +
+		"deaths     ▁▁▁▁▁▁▁▁ 1\n" +
+		"box        ▁▂▃▄▅▆▇█ 3x40\n"
+	if got != want {
+		t.Errorf("charts() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	// A blinker two generations on: its ends and the cells above and below
+	// its center changed twice, its center never
+	w := model.NewWorld()
+	for x := int64(0); x < 3; x++ {
+		w.AddCellIn(x, 0, 0)
+	}
+	w.(types.ActivityReporter).TrackActivity(true)
+	w.Evolve()
+	w.Evolve()
+	content := "Turn: 2\n   \nxxx\n   \nNotice\n"
+
+	got := heatmap(content, w, w.(types.ActivityReporter), model.NewIndex(0, -1), model.NewIndex(2, 1))
+	hot := "\x1b[48;5;231m"
+	reset := "\x1b[0m"
+	want := "Turn: 2\n" +
+		" " + hot + " " + reset + " \n" +
+		hot + "x" + reset + "x" + hot + "x" + reset + "\n" +
+		" " + hot + " " + reset + " \n" +
+		"Notice\n"
+	if got != want {
+		t.Errorf("heatmap() = %q, want %q", got, want)
+	}
+}
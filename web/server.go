@@ -0,0 +1,342 @@
+// Package web serves a running simulation over HTTP, with an embedded browser
+// canvas viewer, a Server-Sent Events stream of generations and a small REST API.
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// Limits for the delay between generations.
+const (
+	defaultDelay = 200 * time.Millisecond
+	minDelay     = 10 * time.Millisecond
+	maxDelay     = 10 * time.Second
+)
+
+// Limits of the control requests, which hold up the simulation while they run.
+const (
+	// maxStep is the largest number of generations a single step request can
+	// ask for.
+	maxStep = 1000
+	// maxLoadBytes is the size of the largest pattern a load request can upload.
+	maxLoadBytes = 8 << 20
+)
+
+//go:embed static
+var static embed.FS
+
+// Frame is one generation of the world, as sent to browsers.
+type Frame struct {
+	Turn   int64      `json:"turn"`
+	Paused bool       `json:"paused"`
+	Cells  [][2]int64 `json:"cells"`
+}
+
+// Status describes the simulation, as returned by the control endpoints.
+type Status struct {
+	Turn       int64 `json:"turn"`
+	Paused     bool  `json:"paused"`
+	DelayMs    int64 `json:"delay_ms"`
+	Population int   `json:"population"`
+}
+
+// Server owns a world, evolves it at a configurable speed and serves it over HTTP.
+//
+// Endpoints:
+//
+//	GET  /                  the canvas viewer
+//	GET  /api/stream        Server-Sent Events, one Frame per generation
+//	GET  /api/cells         the current cells, as a Frame (?format=json) or RLE (?format=rle)
+//	GET  /api/status        the current Status
+//	POST /api/load          replace the world with the sample ?sample=<name>, or with the RLE request body
+//	POST /api/step          advance ?n=<generations> (default 1)
+//	POST /api/pause         pause or resume with ?paused=true|false, or toggle
+//	POST /api/speed         set the delay between generations with ?delay=<duration>
+type Server struct {
+	mu          sync.Mutex
+	world       types.World
+	paused      bool
+	delay       time.Duration
+	subscribers map[chan []byte]struct{}
+	mux         *http.ServeMux
+}
+
+// NewServer creates a server for the world.
+func NewServer(w types.World) *Server {
+	s := &Server{
+		world:       w,
+		delay:       defaultDelay,
+		subscribers: make(map[chan []byte]struct{}),
+		mux:         http.NewServeMux(),
+	}
+	content, _ := fs.Sub(static, "static")
+	s.mux.Handle("/", http.FileServer(http.FS(content)))
+	s.mux.HandleFunc("/api/stream", s.handleStream)
+	s.mux.HandleFunc("/api/cells", s.handleCells)
+	s.mux.HandleFunc("/api/status", s.handleStatus)
+	s.mux.HandleFunc("/api/load", s.handleLoad)
+	s.mux.HandleFunc("/api/step", s.post(s.handleStep))
+	s.mux.HandleFunc("/api/pause", s.post(s.handlePause))
+	s.mux.HandleFunc("/api/speed", s.post(s.handleSpeed))
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run evolves the world until done is closed, sending every generation to the
+// connected browsers.
+func (s *Server) Run(done <-chan struct{}) {
+	for {
+		s.mu.Lock()
+		delay := s.delay
+		s.mu.Unlock()
+
+		select {
+		case <-done:
+			return
+		case <-time.After(delay):
+		}
+
+		s.mu.Lock()
+		if !s.paused {
+			s.world.Evolve()
+			s.broadcast()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// frame builds the current Frame. The caller must hold the lock.
+func (s *Server) frame() Frame {
+	f := Frame{Turn: s.world.Turn(), Paused: s.paused, Cells: [][2]int64{}}
+	s.world.ForEachCell(func(x, y, turn int64) {
+		f.Cells = append(f.Cells, [2]int64{x, y})
+	})
+	return f
+}
+
+// status builds the current Status. The caller must hold the lock.
+func (s *Server) status() Status {
+	population := 0
+	s.world.ForEachCell(func(x, y, turn int64) {
+		population++
+	})
+	return Status{
+		Turn:       s.world.Turn(),
+		Paused:     s.paused,
+		DelayMs:    s.delay.Milliseconds(),
+		Population: population,
+	}
+}
+
+// broadcast sends the current frame to every subscriber. Subscribers that are
+// still busy with the previous frame skip this one. The caller must hold the lock.
+func (s *Server) broadcast() {
+	if len(s.subscribers) == 0 {
+		return
+	}
+	data, _ := json.Marshal(s.frame())
+	for sub := range s.subscribers {
+		select {
+		case sub <- data:
+		default:
+		}
+	}
+}
+
+// handleStream sends a frame per generation as Server-Sent Events.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	sub := make(chan []byte, 1)
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	first, _ := json.Marshal(s.frame())
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}()
+
+	data := first
+	for {
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case data = <-sub:
+		}
+	}
+}
+
+// handleCells returns the current cells as JSON or RLE.
+func (s *Server) handleCells(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		writeJSON(w, s.frame())
+	case "rle":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		model.WriteRLE(s.world, w)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+	}
+}
+
+// handleStatus returns the current status.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, s.status())
+}
+
+// handleLoad replaces the world with a sample or an uploaded RLE pattern. The
+// pattern is read before taking the lock, so that slow or large uploads do not
+// hold up the simulation.
+func (s *Server) handleLoad(w http.ResponseWriter, r *http.Request) {
+	if !allowPost(w, r) {
+		return
+	}
+	world, code, err := readLoad(w, r)
+	s.post(func(*http.Request) (int, error) {
+		if err != nil {
+			return code, err
+		}
+		s.world = world
+		return http.StatusOK, nil
+	})(w, r)
+}
+
+// readLoad reads the world of a load request: the sample it names, or the RLE
+// pattern of its body, up to maxLoadBytes long.
+func readLoad(w http.ResponseWriter, r *http.Request) (types.World, int, error) {
+	if sample := r.URL.Query().Get("sample"); sample != "" {
+		if !isSampleName(sample) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid sample name %q", sample)
+		}
+		world, err := model.ReadWorld(sample)
+		if err != nil {
+			return nil, http.StatusNotFound, err
+		}
+		return world, http.StatusOK, nil
+	}
+	world, err := model.ReadRLE(http.MaxBytesReader(w, r.Body, maxLoadBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("pattern larger than %d bytes", maxLoadBytes)
+		}
+		return nil, http.StatusBadRequest, err
+	}
+	return world, http.StatusOK, nil
+}
+
+// isSampleName reports whether the name can only be the name of a sample in
+// the samples directory: a name without path separators or an extension, which
+// would make ReadWorld read it as the path of a file.
+func isSampleName(name string) bool {
+	return !strings.ContainsAny(name, `./\`)
+}
+
+// handleStep advances the world by the requested number of generations.
+func (s *Server) handleStep(r *http.Request) (int, error) {
+	n := int64(1)
+	if value := r.URL.Query().Get("n"); value != "" {
+		var err error
+		n, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 || n > maxStep {
+			return http.StatusBadRequest, fmt.Errorf("n must be a number between 0 and %d", maxStep)
+		}
+	}
+	for i := int64(0); i < n; i++ {
+		s.world.Evolve()
+	}
+	return http.StatusOK, nil
+}
+
+// handlePause pauses, resumes or toggles the evolution.
+func (s *Server) handlePause(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("paused")
+	if value == "" {
+		s.paused = !s.paused
+		return http.StatusOK, nil
+	}
+	paused, err := strconv.ParseBool(value)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid paused value %q", value)
+	}
+	s.paused = paused
+	return http.StatusOK, nil
+}
+
+// handleSpeed sets the delay between generations.
+func (s *Server) handleSpeed(r *http.Request) (int, error) {
+	delay, err := time.ParseDuration(r.URL.Query().Get("delay"))
+	if err != nil || delay < minDelay || delay > maxDelay {
+		return http.StatusBadRequest, fmt.Errorf("delay must be a duration between %s and %s", minDelay, maxDelay)
+	}
+	s.delay = delay
+	return http.StatusOK, nil
+}
+
+// post wraps a control handler: it only accepts POST requests, runs the handler
+// with the lock held, tells the browsers about the change and replies with the
+// new status.
+func (s *Server) post(handler func(*http.Request) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowPost(w, r) {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if code, err := handler(r); err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		s.broadcast()
+		writeJSON(w, s.status())
+	}
+}
+
+// allowPost reports whether the request is a POST request, replying with an
+// error when it is not.
+func allowPost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
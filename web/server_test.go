@@ -0,0 +1,190 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daniel-munoz/life/model"
+	"github.com/daniel-munoz/life/types"
+)
+
+// newBlinker creates a world with a horizontal blinker at the origin.
+func newBlinker() types.World {
+	w := model.NewWorld()
+	w.AddCellIn(0, 0, 0)
+	w.AddCellIn(1, 0, 0)
+	w.AddCellIn(2, 0, 0)
+	return w
+}
+
+// do sends a request to the server and returns the recorded response.
+func do(s *Server, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+// decodeStatus decodes a Status response.
+func decodeStatus(t *testing.T, rec *httptest.ResponseRecorder) Status {
+	t.Helper()
+	var st Status
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("Failed to decode status %q: %v", rec.Body.String(), err)
+	}
+	return st
+}
+
+func TestServer_Index(t *testing.T) {
+	rec := do(NewServer(newBlinker()), http.MethodGet, "/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET / status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "<canvas") {
+		t.Error("GET / should serve the canvas viewer")
+	}
+}
+
+func TestServer_Cells(t *testing.T) {
+	s := NewServer(newBlinker())
+
+	rec := do(s, http.MethodGet, "/api/cells", "")
+	var f Frame
+	if err := json.NewDecoder(rec.Body).Decode(&f); err != nil {
+		t.Fatalf("Failed to decode frame: %v", err)
+	}
+	if f.Turn != 0 || len(f.Cells) != 3 {
+		t.Errorf("GET /api/cells = %+v, want turn 0 with 3 cells", f)
+	}
+
+	rec = do(s, http.MethodGet, "/api/cells?format=rle", "")
+	if want := "x = 3, y = 1, rule = B3/S23\n3o!\n"; rec.Body.String() != want {
+		t.Errorf("GET /api/cells?format=rle = %q, want %q", rec.Body.String(), want)
+	}
+
+	rec = do(s, http.MethodGet, "/api/cells?format=png", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_Controls(t *testing.T) {
+	s := NewServer(newBlinker())
+
+	rec := do(s, http.MethodPost, "/api/step?n=5", "")
+	if st := decodeStatus(t, rec); st.Turn != 5 || st.Population != 3 {
+		t.Errorf("step status = %+v, want turn 5 and population 3", st)
+	}
+
+	rec = do(s, http.MethodPost, "/api/pause", "")
+	if st := decodeStatus(t, rec); !st.Paused {
+		t.Error("pause without value should toggle to paused")
+	}
+	rec = do(s, http.MethodPost, "/api/pause?paused=false", "")
+	if st := decodeStatus(t, rec); st.Paused {
+		t.Error("pause?paused=false should resume")
+	}
+
+	rec = do(s, http.MethodPost, "/api/speed?delay=50ms", "")
+	if st := decodeStatus(t, rec); st.DelayMs != 50 {
+		t.Errorf("speed status delay = %d, want 50", st.DelayMs)
+	}
+
+	rec = do(s, http.MethodPost, "/api/load", "x = 2, y = 2\n2o$2o!")
+	if st := decodeStatus(t, rec); st.Turn != 0 || st.Population != 4 {
+		t.Errorf("load status = %+v, want turn 0 and population 4", st)
+	}
+
+	rec = do(s, http.MethodGet, "/api/status", "")
+	if st := decodeStatus(t, rec); st.Population != 4 || st.DelayMs != 50 {
+		t.Errorf("GET /api/status = %+v", st)
+	}
+}
+
+func TestServer_ControlErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		wantCode int
+	}{
+		{name: "step with GET", method: http.MethodGet, target: "/api/step", wantCode: http.StatusMethodNotAllowed},
+		{name: "negative step", method: http.MethodPost, target: "/api/step?n=-1", wantCode: http.StatusBadRequest},
+		{name: "huge step", method: http.MethodPost, target: "/api/step?n=100000000", wantCode: http.StatusBadRequest},
+		{name: "bad pause", method: http.MethodPost, target: "/api/pause?paused=maybe", wantCode: http.StatusBadRequest},
+		{name: "missing delay", method: http.MethodPost, target: "/api/speed", wantCode: http.StatusBadRequest},
+		{name: "tiny delay", method: http.MethodPost, target: "/api/speed?delay=1ns", wantCode: http.StatusBadRequest},
+		{name: "missing sample", method: http.MethodPost, target: "/api/load?sample=non-existent", wantCode: http.StatusNotFound},
+		{name: "sample path", method: http.MethodPost, target: "/api/load?sample=../samples/gun", wantCode: http.StatusBadRequest},
+		{name: "sample file", method: http.MethodPost, target: "/api/load?sample=gun.rle", wantCode: http.StatusBadRequest},
+		{name: "huge upload", method: http.MethodPost, target: "/api/load", body: strings.Repeat("#C comment\n", maxLoadBytes/10) + "x = 1, y = 1\no!", wantCode: http.StatusRequestEntityTooLarge},
+		{name: "bad RLE", method: http.MethodPost, target: "/api/load", body: "x = 1, y = 1\no?!", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(NewServer(newBlinker()), tt.method, tt.target, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestServer_Stream(t *testing.T) {
+	s := NewServer(newBlinker())
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	do(s, http.MethodPost, "/api/speed?delay=10ms", "")
+	go s.Run(done)
+
+	resp, err := http.Get(ts.URL + "/api/stream")
+	if err != nil {
+		t.Fatalf("GET /api/stream unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// Read frames until the world has evolved a few generations
+	frames := make(chan Frame)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var f Frame
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &f) == nil {
+				frames <- f
+			}
+		}
+	}()
+
+	timeout := time.After(2 * time.Second)
+	lastTurn := int64(-1)
+	for lastTurn < 3 {
+		select {
+		case f := <-frames:
+			if f.Turn <= lastTurn {
+				t.Errorf("frame turn %d after %d, want increasing turns", f.Turn, lastTurn)
+			}
+			if len(f.Cells) != 3 {
+				t.Errorf("frame %d has %d cells, want 3", f.Turn, len(f.Cells))
+			}
+			lastTurn = f.Turn
+		case <-timeout:
+			t.Fatalf("timed out waiting for frames, last turn %d", lastTurn)
+		}
+	}
+}